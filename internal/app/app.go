@@ -11,14 +11,21 @@ import (
 	appConstants "github.com/beebeeoii/lominus/internal/constants"
 	"github.com/beebeeoii/lominus/internal/file"
 	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/pkg/store"
 	"github.com/boltdb/bolt"
 )
 
 var dbInstance *bolt.DB
 
 // Init initialises and ensures log and preference files that Lominus requires are available.
+// The database is migrated to SCHEMA_VERSION, and NewerSchemaError is returned if it was migrated
+// by a newer version of Lominus.
 // Directory in Preferences defaults to empty string ("").
-// Frequency in Preferences defaults to -1.
+// Schedule in Preferences defaults to "disabled", or to the interval of the legacy frequency if it was set.
+// TrashRetentionDays in Preferences defaults to 30.
+// ConflictPolicy in Preferences defaults to "keepBoth".
+// CollisionPolicy in Preferences defaults to "renameOld".
+// VersionsKeepLast in Preferences defaults to 5.
 func Init() (*bolt.DB, error) {
 	baseDir, retrieveBaseDirErr := appDir.GetBaseDir()
 	if retrieveBaseDirErr != nil {
@@ -36,35 +43,16 @@ func Init() (*bolt.DB, error) {
 		return nil, dbErr
 	}
 
-	err := db.Update(func(tx *bolt.Tx) error {
-		tx.CreateBucketIfNotExists([]byte("Auth"))
-		tx.CreateBucketIfNotExists([]byte("Integrations"))
-		prefBucket, prefBucketErr := tx.CreateBucketIfNotExists([]byte("Preferences"))
-		if prefBucketErr != nil {
-			return prefBucketErr
-		}
-
-		if prefBucket.Get([]byte("frequency")) == nil {
-			prefBucket.Put([]byte("frequency"), []byte("-1"))
-		}
-
-		logLevel := prefBucket.Get([]byte("logLevel"))
-		if logLevel == nil {
-			logLevel = []byte("info")
-			prefBucket.Put([]byte("logLevel"), []byte(logLevel))
-
-		}
-
-		logInitErr := logs.Init(string(logLevel))
-		if logInitErr != nil {
-			return logInitErr
-		}
-
-		return nil
-	})
+	logInitErr := logs.Init(getLogLevel(db))
+	if logInitErr != nil {
+		db.Close()
+		return nil, logInitErr
+	}
 
-	if err != nil {
-		return nil, err
+	migrateErr := migrate(db)
+	if migrateErr != nil {
+		db.Close()
+		return nil, migrateErr
 	}
 
 	dbInstance = db
@@ -72,6 +60,16 @@ func Init() (*bolt.DB, error) {
 	return db, nil
 }
 
+// getLogLevel is a helper function that returns the log level in Preferences, which defaults to "info".
+func getLogLevel(db *bolt.DB) string {
+	logLevel, _ := store.BoltStore{DB: db}.Get("Preferences", "logLevel")
+	if logLevel == nil {
+		return "info"
+	}
+
+	return string(logLevel)
+}
+
 // GetOs returns user's running program's operating system target:
 // one of darwin, freebsd, linux, and so on.
 // To view possible combinations of GOOS and GOARCH, run "go tool dist list".
@@ -82,3 +80,18 @@ func GetOs() string {
 func GetDBInstance() *bolt.DB {
 	return dbInstance
 }
+
+// GetStore returns the store.Store backed by the database. Data shared with the public API, such as credentials,
+// and the preferences, filters, hooks, sync state and history are stored in it.
+// The index, the search index and the secrets, which are rewritten within one transaction, use the database directly.
+func GetStore() store.Store {
+	return store.BoltStore{DB: dbInstance}
+}
+
+// Update calls fn with a store.Store that writes to the database within one transaction, such that
+// either every write is saved or, if fn returns an error, none is.
+func Update(fn func(s store.Store) error) error {
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		return fn(store.TxStore{Tx: tx})
+	})
+}
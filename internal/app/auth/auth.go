@@ -3,41 +3,38 @@ package appAuth
 
 import (
 	"github.com/beebeeoii/lominus/internal/app"
-	"github.com/boltdb/bolt"
+	appSecrets "github.com/beebeeoii/lominus/internal/app/secrets"
+	"github.com/beebeeoii/lominus/pkg/auth"
 )
 
 type CanvasCredentials struct {
 	CanvasApiToken string
 }
 
-func GetCanvasCredentials() (CanvasCredentials, error) {
-	dbInstance := app.GetDBInstance()
-	var canvasCredentials CanvasCredentials
-
-	err := dbInstance.View(func(tx *bolt.Tx) error {
-		authBucket := tx.Bucket([]byte("Auth"))
-		canvasToken := string(authBucket.Get([]byte("canvasToken")))
-
-		canvasCredentials.CanvasApiToken = canvasToken
+func init() {
+	app.RegisterBuckets(auth.CANVAS_CREDENTIALS_BUCKET)
+}
 
-		return nil
-	})
+func GetCanvasCredentials() (CanvasCredentials, error) {
+	credentials, loadErr := auth.LoadCanvasCredentials(app.GetStore())
+	if loadErr != nil {
+		return CanvasCredentials{}, loadErr
+	}
 
-	if err != nil {
-		return CanvasCredentials{}, err
+	canvasToken, decryptErr := appSecrets.Decrypt(credentials.CanvasApiToken)
+	if decryptErr != nil {
+		return CanvasCredentials{}, decryptErr
 	}
 
-	return canvasCredentials, nil
+	return CanvasCredentials{CanvasApiToken: canvasToken}, nil
 }
 
-// SaveCanvasCredentials saves the user's Canvas API token locally.
+// SaveCanvasCredentials saves the user's Canvas API token locally, encrypted.
 func SaveCanvasCredentials(cred CanvasCredentials) error {
-	dbInstance := app.GetDBInstance()
-
-	updateErr := dbInstance.Update(func(tx *bolt.Tx) error {
-		err := tx.Bucket([]byte("Auth")).Put([]byte("canvasToken"), []byte(cred.CanvasApiToken))
-		return err
-	})
+	canvasToken, encryptErr := appSecrets.Encrypt(cred.CanvasApiToken)
+	if encryptErr != nil {
+		return encryptErr
+	}
 
-	return updateErr
+	return auth.CanvasCredentials{CanvasApiToken: canvasToken}.SaveTo(app.GetStore())
 }
@@ -0,0 +1,23 @@
+// Package appCache provides path retrievers for the Lominus HTTP cache directory.
+package appCache
+
+import (
+	"path/filepath"
+
+	appDir "github.com/beebeeoii/lominus/internal/app/dir"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+)
+
+// GetCacheDir returns the path to the directory Canvas responses are cached in.
+func GetCacheDir() (string, error) {
+	var cacheDir string
+
+	baseDir, retrieveBaseDirErr := appDir.GetBaseDir()
+	if retrieveBaseDirErr != nil {
+		return cacheDir, retrieveBaseDirErr
+	}
+
+	cacheDir = filepath.Join(baseDir, appConstants.CACHE_DIR_NAME)
+
+	return cacheDir, nil
+}
@@ -0,0 +1,503 @@
+// Package appConfig provides primitives to export the user's settings to a config file,
+// and to import them from one.
+package appConfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/beebeeoii/lominus/internal/app"
+	appAuth "github.com/beebeeoii/lominus/internal/app/auth"
+	appDir "github.com/beebeeoii/lominus/internal/app/dir"
+	appFilter "github.com/beebeeoii/lominus/internal/app/filter"
+	appHooks "github.com/beebeeoii/lominus/internal/app/hooks"
+	intTelegram "github.com/beebeeoii/lominus/internal/app/integrations/telegram"
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appSecrets "github.com/beebeeoii/lominus/internal/app/secrets"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/internal/hooks"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/schedule"
+	"github.com/beebeeoii/lominus/pkg/auth"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
+	"github.com/beebeeoii/lominus/pkg/store"
+	"github.com/sirupsen/logrus"
+)
+
+// CONFIG_PATH_ENV is the environment variable that sets the path of the config file read at startup,
+// in place of CONFIG_FILE_NAME in the Lominus folder.
+const CONFIG_PATH_ENV = "LOMINUS_CONFIG"
+
+// ALLOW_HOOKS_ENV is the environment variable that must be set to "true" for the config file read at startup
+// to install hooks, as they run commands. Otherwise, the hooks in it are left out.
+const ALLOW_HOOKS_ENV = "LOMINUS_CONFIG_ALLOW_HOOKS"
+
+// SECRETS_WARNING is written at the top of config files that contain secrets.
+const SECRETS_WARNING = "WARNING: this file contains your Canvas API token and Telegram bot token. Anyone with this file can access your Canvas account. Do not share it."
+
+// Config struct describes the user's settings as written in a config file.
+// Settings that are not set, such as fields that are nil, are left as they are when the config is applied,
+// such that a config file only needs to contain the settings it changes.
+// Filters and Hooks are the lines of the filter rules and hooks. They are exported as empty lists if there are none,
+// and an empty list clears them when the config is applied.
+// Credentials is only set if secrets are exported.
+type Config struct {
+	Warning     string             `toml:"-" json:"warning,omitempty"`
+	Filters     []string           `toml:"filters" json:"filters"`
+	Hooks       []string           `toml:"hooks" json:"hooks"`
+	Preferences PreferencesConfig  `toml:"preferences" json:"preferences"`
+	Telegram    *TelegramConfig    `toml:"telegram,omitempty" json:"telegram,omitempty"`
+	Credentials *CredentialsConfig `toml:"credentials,omitempty" json:"credentials,omitempty"`
+}
+
+// PreferencesConfig struct describes the user's preferences as written in a config file.
+// Schedule and QuietHours are written as accepted by schedule.Parse and schedule.ParseQuietHours.
+type PreferencesConfig struct {
+	Directory               *string           `toml:"directory,omitempty" json:"directory,omitempty"`
+	Schedule                *string           `toml:"schedule,omitempty" json:"schedule,omitempty"`
+	QuietHours              *string           `toml:"quietHours,omitempty" json:"quietHours,omitempty"`
+	IncrementalSync         *bool             `toml:"incrementalSync,omitempty" json:"incrementalSync,omitempty"`
+	ExtractArchives         *bool             `toml:"extractArchives,omitempty" json:"extractArchives,omitempty"`
+	LogLevel                *string           `toml:"logLevel,omitempty" json:"logLevel,omitempty"`
+	MirrorDeletions         *bool             `toml:"mirrorDeletions,omitempty" json:"mirrorDeletions,omitempty"`
+	TrashRetentionDays      *int              `toml:"trashRetentionDays,omitempty" json:"trashRetentionDays,omitempty"`
+	ConflictPolicy          *string           `toml:"conflictPolicy,omitempty" json:"conflictPolicy,omitempty"`
+	CollisionPolicy         *string           `toml:"collisionPolicy,omitempty" json:"collisionPolicy,omitempty"`
+	ModuleCollisionPolicies map[string]string `toml:"moduleCollisionPolicies,omitempty" json:"moduleCollisionPolicies,omitempty"`
+	VersionsKeepLast        *int              `toml:"versionsKeepLast,omitempty" json:"versionsKeepLast,omitempty"`
+	VersionsKeepDays        *int              `toml:"versionsKeepDays,omitempty" json:"versionsKeepDays,omitempty"`
+}
+
+// TelegramConfig struct describes the user's Telegram integration as written in a config file.
+// BotId is a secret, and is only set if secrets are exported.
+type TelegramConfig struct {
+	UserId *string `toml:"userId,omitempty" json:"userId,omitempty"`
+	BotId  *string `toml:"botId,omitempty" json:"botId,omitempty"`
+}
+
+// CredentialsConfig struct describes the user's Canvas credentials as written in a config file.
+type CredentialsConfig struct {
+	CanvasApiToken string `toml:"canvasApiToken" json:"canvasApiToken"`
+}
+
+// conflictPolicies lists every conflict policy.
+var conflictPolicies = []string{
+	appPref.CONFLICT_KEEP_BOTH,
+	appPref.CONFLICT_SKIP,
+	appPref.CONFLICT_OVERWRITE,
+}
+
+// Export returns the user's current settings. The Canvas API token and Telegram bot token are
+// only included if includeSecrets is true.
+func Export(includeSecrets bool) (Config, error) {
+	pref, prefErr := appPref.GetPreferences()
+	if prefErr != nil {
+		return Config{}, prefErr
+	}
+
+	filterText, filterErr := appFilter.GetFilterText()
+	if filterErr != nil {
+		return Config{}, filterErr
+	}
+
+	hooksText, hooksErr := appHooks.GetHooksText()
+	if hooksErr != nil {
+		return Config{}, hooksErr
+	}
+
+	telegramIds, telegramErr := intTelegram.GetTelegramIds()
+	if telegramErr != nil {
+		return Config{}, telegramErr
+	}
+
+	config := Config{
+		Filters: getLines(filterText),
+		Hooks:   getLines(hooksText),
+		Preferences: PreferencesConfig{
+			Directory:               &pref.Directory,
+			Schedule:                stringPtr(pref.Schedule.String()),
+			QuietHours:              stringPtr(pref.QuietHours.String()),
+			IncrementalSync:         &pref.IncrementalSync,
+			ExtractArchives:         &pref.ExtractArchives,
+			LogLevel:                &pref.LogLevel,
+			MirrorDeletions:         &pref.MirrorDeletions,
+			TrashRetentionDays:      &pref.TrashRetentionDays,
+			ConflictPolicy:          &pref.ConflictPolicy,
+			CollisionPolicy:         &pref.CollisionPolicy,
+			ModuleCollisionPolicies: pref.ModuleCollisionPolicies,
+			VersionsKeepLast:        &pref.VersionsKeepLast,
+			VersionsKeepDays:        &pref.VersionsKeepDays,
+		},
+	}
+
+	if telegramIds.UserId != "" {
+		config.Telegram = &TelegramConfig{UserId: &telegramIds.UserId}
+	}
+
+	if includeSecrets {
+		credentials, credentialsErr := appAuth.GetCanvasCredentials()
+		if credentialsErr != nil {
+			return Config{}, credentialsErr
+		}
+
+		config.Warning = SECRETS_WARNING
+		config.Credentials = &CredentialsConfig{CanvasApiToken: credentials.CanvasApiToken}
+		if telegramIds.BotId != "" {
+			if config.Telegram == nil {
+				config.Telegram = &TelegramConfig{}
+			}
+			config.Telegram.BotId = &telegramIds.BotId
+		}
+	}
+
+	return config, nil
+}
+
+// Validate checks whether every setting in the config is valid, such that it can be applied.
+func (config Config) Validate() error {
+	pref := config.Preferences
+
+	if pref.Schedule != nil {
+		if _, err := schedule.Parse(*pref.Schedule); err != nil {
+			return fmt.Errorf("preferences.schedule: %s", err.Error())
+		}
+	}
+
+	if pref.QuietHours != nil {
+		if _, err := schedule.ParseQuietHours(*pref.QuietHours); err != nil {
+			return fmt.Errorf("preferences.quietHours: %s", err.Error())
+		}
+	}
+
+	if pref.LogLevel != nil {
+		if _, err := logrus.ParseLevel(*pref.LogLevel); err != nil {
+			return fmt.Errorf("preferences.logLevel: %s", err.Error())
+		}
+	}
+
+	if pref.ConflictPolicy != nil && !slices.Contains(conflictPolicies, *pref.ConflictPolicy) {
+		return fmt.Errorf("preferences.conflictPolicy: unknown conflict policy %q, expected one of %s",
+			*pref.ConflictPolicy, strings.Join(conflictPolicies, ", "))
+	}
+
+	if pref.CollisionPolicy != nil && !slices.Contains(appPref.CollisionPolicies, *pref.CollisionPolicy) {
+		return fmt.Errorf("preferences.collisionPolicy: unknown collision policy %q, expected one of %s",
+			*pref.CollisionPolicy, strings.Join(appPref.CollisionPolicies, ", "))
+	}
+
+	if pref.ModuleCollisionPolicies != nil {
+		formatted := appPref.FormatModuleCollisionPolicies(pref.ModuleCollisionPolicies)
+		if _, err := appPref.ParseModuleCollisionPolicies(formatted); err != nil {
+			return fmt.Errorf("preferences.moduleCollisionPolicies: %s", err.Error())
+		}
+	}
+
+	if config.Filters != nil {
+		if _, err := filter.Parse(strings.Join(config.Filters, "\n")); err != nil {
+			return fmt.Errorf("filters: %s", err.Error())
+		}
+	}
+
+	if config.Hooks != nil {
+		if _, err := hooks.Parse(strings.Join(config.Hooks, "\n")); err != nil {
+			return fmt.Errorf("hooks: %s", err.Error())
+		}
+	}
+
+	return nil
+}
+
+// Apply validates the config and saves the settings set in it locally, replacing the current ones.
+// The settings are saved within one transaction: nothing is saved if any setting is invalid
+// or cannot be saved.
+func Apply(config Config) error {
+	if validateErr := config.Validate(); validateErr != nil {
+		return validateErr
+	}
+
+	// Secrets are encrypted beforehand, as setting up the key they are encrypted with writes to the database.
+	var canvasToken string
+	var botId *string
+	if config.Credentials != nil {
+		encrypted, encryptErr := appSecrets.Encrypt(config.Credentials.CanvasApiToken)
+		if encryptErr != nil {
+			return encryptErr
+		}
+		canvasToken = encrypted
+	}
+	if config.Telegram != nil && config.Telegram.BotId != nil {
+		encrypted, encryptErr := appSecrets.Encrypt(*config.Telegram.BotId)
+		if encryptErr != nil {
+			return encryptErr
+		}
+		botId = &encrypted
+	}
+
+	updateErr := app.Update(func(s store.Store) error {
+		if savePrefErr := savePreferences(s, config.Preferences); savePrefErr != nil {
+			return savePrefErr
+		}
+
+		if config.Filters != nil {
+			if saveErr := appFilter.SaveFilterTextTo(s, strings.Join(config.Filters, "\n")); saveErr != nil {
+				return saveErr
+			}
+		}
+
+		if config.Hooks != nil {
+			if saveErr := appHooks.SaveHooksTextTo(s, strings.Join(config.Hooks, "\n")); saveErr != nil {
+				return saveErr
+			}
+		}
+
+		if config.Telegram != nil {
+			if saveErr := saveTelegram(s, config.Telegram.UserId, botId); saveErr != nil {
+				return saveErr
+			}
+		}
+
+		if config.Credentials != nil {
+			return auth.CanvasCredentials{CanvasApiToken: canvasToken}.SaveTo(s)
+		}
+
+		return nil
+	})
+	if updateErr != nil {
+		return updateErr
+	}
+
+	if config.Preferences.LogLevel != nil {
+		logs.SetLogLevel(*config.Preferences.LogLevel)
+	}
+
+	return nil
+}
+
+// ExportFile writes the user's current settings to a config file. The file is written in JSON if its
+// extension is .json, or else in TOML. The Canvas API token and Telegram bot token are only included
+// if includeSecrets is true, in which case the file is only readable by the user.
+func ExportFile(filePath string, includeSecrets bool) error {
+	config, exportErr := Export(includeSecrets)
+	if exportErr != nil {
+		return exportErr
+	}
+
+	data, encodeErr := Encode(config, filePath)
+	if encodeErr != nil {
+		return encodeErr
+	}
+
+	perm := os.FileMode(0644)
+	if includeSecrets {
+		perm = 0600
+	}
+
+	return os.WriteFile(filePath, data, perm)
+}
+
+// ImportFile reads the config file and applies it. The file is read as JSON if its extension is .json,
+// or else as TOML.
+func ImportFile(filePath string) error {
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		return readErr
+	}
+
+	config, decodeErr := Decode(data, filePath)
+	if decodeErr != nil {
+		return decodeErr
+	}
+
+	return Apply(config)
+}
+
+// ApplyStartupConfig applies the config file at the path in CONFIG_PATH_ENV, or else CONFIG_FILE_NAME in
+// the Lominus folder, if it exists. It is meant to be called at startup, such that managed setups can
+// enforce settings: those set in the file override the ones saved locally every time Lominus starts.
+// As hooks run commands, the hooks in the file are only installed if ALLOW_HOOKS_ENV is set to "true",
+// and every hook installed is logged as a warning.
+// It returns the path of the file applied, or an empty string if there is none.
+func ApplyStartupConfig() (string, error) {
+	configPath := os.Getenv(CONFIG_PATH_ENV)
+	if configPath == "" {
+		baseDir, retrieveBaseDirErr := appDir.GetBaseDir()
+		if retrieveBaseDirErr != nil {
+			return "", retrieveBaseDirErr
+		}
+
+		configPath = filepath.Join(baseDir, appConstants.CONFIG_FILE_NAME)
+		if _, statErr := os.Stat(configPath); os.IsNotExist(statErr) {
+			return "", nil
+		}
+	}
+
+	data, readErr := os.ReadFile(configPath)
+	if readErr != nil {
+		return configPath, fmt.Errorf("%s: %w", configPath, readErr)
+	}
+
+	config, decodeErr := Decode(data, configPath)
+	if decodeErr != nil {
+		return configPath, fmt.Errorf("%s: %w", configPath, decodeErr)
+	}
+
+	if config.Hooks != nil {
+		if os.Getenv(ALLOW_HOOKS_ENV) != "true" {
+			logs.Logger.Warnf("%s: hooks not installed - set %s=true to install the hooks of the config file", configPath, ALLOW_HOOKS_ENV)
+			config.Hooks = nil
+		} else {
+			for _, hook := range config.Hooks {
+				if strings.TrimSpace(hook) == "" {
+					continue
+				}
+				logs.Logger.Warnf("%s: installing hook - %s", configPath, hook)
+			}
+		}
+	}
+
+	if applyErr := Apply(config); applyErr != nil {
+		return configPath, fmt.Errorf("%s: %w", configPath, applyErr)
+	}
+
+	return configPath, nil
+}
+
+// Encode encodes the config in the format of the file at filePath: JSON if its extension is .json,
+// or else TOML. TOML files containing secrets start with SECRETS_WARNING as a comment.
+func Encode(config Config, filePath string) ([]byte, error) {
+	if isJson(filePath) {
+		return json.MarshalIndent(config, "", "  ")
+	}
+
+	buffer := bytes.Buffer{}
+	if config.Warning != "" {
+		fmt.Fprintf(&buffer, "# %s\n\n", config.Warning)
+	}
+
+	encoder := toml.NewEncoder(&buffer)
+	encoder.Indent = ""
+	if encodeErr := encoder.Encode(config); encodeErr != nil {
+		return nil, encodeErr
+	}
+
+	return buffer.Bytes(), nil
+}
+
+// Decode decodes the config in the format of the file at filePath: JSON if its extension is .json,
+// or else TOML. Unknown settings are reported as errors, such that typos are not silently ignored.
+func Decode(data []byte, filePath string) (Config, error) {
+	config := Config{}
+
+	if isJson(filePath) {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		return config, decoder.Decode(&config)
+	}
+
+	metadata, decodeErr := toml.Decode(string(data), &config)
+	if decodeErr != nil {
+		return config, decodeErr
+	}
+
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		return config, fmt.Errorf("unknown setting %q", undecoded[0].String())
+	}
+
+	return config, nil
+}
+
+// savePreferences is a helper function that saves the preferences set in the config into the Store,
+// keeping the current value of the ones that are not set.
+func savePreferences(s store.Store, prefConfig PreferencesConfig) error {
+	pref, prefErr := appPref.LoadPreferences(s)
+	if prefErr != nil {
+		return prefErr
+	}
+
+	if prefConfig.Directory != nil {
+		pref.Directory = *prefConfig.Directory
+	}
+	if prefConfig.Schedule != nil {
+		pref.Schedule, _ = schedule.Parse(*prefConfig.Schedule)
+	}
+	if prefConfig.QuietHours != nil {
+		pref.QuietHours, _ = schedule.ParseQuietHours(*prefConfig.QuietHours)
+	}
+	if prefConfig.IncrementalSync != nil {
+		pref.IncrementalSync = *prefConfig.IncrementalSync
+	}
+	if prefConfig.ExtractArchives != nil {
+		pref.ExtractArchives = *prefConfig.ExtractArchives
+	}
+	if prefConfig.LogLevel != nil {
+		pref.LogLevel = *prefConfig.LogLevel
+	}
+	if prefConfig.MirrorDeletions != nil {
+		pref.MirrorDeletions = *prefConfig.MirrorDeletions
+	}
+	if prefConfig.TrashRetentionDays != nil {
+		pref.TrashRetentionDays = *prefConfig.TrashRetentionDays
+	}
+	if prefConfig.ConflictPolicy != nil {
+		pref.ConflictPolicy = *prefConfig.ConflictPolicy
+	}
+	if prefConfig.CollisionPolicy != nil {
+		pref.CollisionPolicy = *prefConfig.CollisionPolicy
+	}
+	if prefConfig.ModuleCollisionPolicies != nil {
+		pref.ModuleCollisionPolicies = prefConfig.ModuleCollisionPolicies
+	}
+	if prefConfig.VersionsKeepLast != nil {
+		pref.VersionsKeepLast = *prefConfig.VersionsKeepLast
+	}
+	if prefConfig.VersionsKeepDays != nil {
+		pref.VersionsKeepDays = *prefConfig.VersionsKeepDays
+	}
+
+	return appPref.SavePreferences(s, pref)
+}
+
+// saveTelegram is a helper function that saves the Telegram integration into the Store, keeping the current
+// value of the id that is nil.
+func saveTelegram(s store.Store, userId *string, encryptedBotId *string) error {
+	telegramInfo, loadErr := telegram.LoadTelegramInfo(s)
+	if loadErr != nil {
+		return loadErr
+	}
+
+	if userId != nil {
+		telegramInfo.UserId = *userId
+	}
+	if encryptedBotId != nil {
+		telegramInfo.BotApi = *encryptedBotId
+	}
+
+	return telegram.SaveTelegramInfo(s, telegramInfo)
+}
+
+// getLines is a helper function that splits text into its lines, without the trailing empty ones.
+func getLines(text string) []string {
+	text = strings.TrimRight(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	if text == "" {
+		return []string{}
+	}
+
+	return strings.Split(text, "\n")
+}
+
+// isJson is a helper function that checks whether the file is a JSON file, by its extension.
+func isJson(filePath string) bool {
+	return strings.EqualFold(filepath.Ext(filePath), ".json")
+}
+
+// stringPtr is a helper function that returns a pointer to s.
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,199 @@
+package appConfig
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	appFilter "github.com/beebeeoii/lominus/internal/app/filter"
+	appHooks "github.com/beebeeoii/lominus/internal/app/hooks"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithTestDB(m))
+}
+
+func TestExportRoundTrips(t *testing.T) {
+	applied := Config{
+		Filters: []string{"exclude ext mp4", "", "[CS2040]", "include glob CS2040/Recordings/*.mp4"},
+		Hooks:   []string{"on finished do echo done"},
+		Preferences: PreferencesConfig{
+			Directory:               stringPtr("/home/user/Lominus"),
+			Schedule:                stringPtr("daily 08:00;18:30"),
+			QuietHours:              stringPtr("23:00-07:00"),
+			IncrementalSync:         boolPtr(true),
+			ExtractArchives:         boolPtr(true),
+			LogLevel:                stringPtr("debug"),
+			MirrorDeletions:         boolPtr(true),
+			TrashRetentionDays:      intPtr(14),
+			ConflictPolicy:          stringPtr("skip"),
+			CollisionPolicy:         stringPtr("renameNew"),
+			ModuleCollisionPolicies: map[string]string{"CS2040": "skip"},
+			VersionsKeepLast:        intPtr(3),
+			VersionsKeepDays:        intPtr(7),
+		},
+		Telegram:    &TelegramConfig{UserId: stringPtr("user"), BotId: stringPtr("bot")},
+		Credentials: &CredentialsConfig{CanvasApiToken: "token"},
+	}
+
+	if err := Apply(applied); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fileName := range []string{"config.toml", "config.json"} {
+		filePath := filepath.Join(t.TempDir(), fileName)
+		if err := ExportFile(filePath, true); err != nil {
+			t.Fatal(err)
+		}
+
+		data, readErr := os.ReadFile(filePath)
+		if readErr != nil {
+			t.Fatal(readErr)
+		}
+
+		exported, decodeErr := Decode(data, filePath)
+		if decodeErr != nil {
+			t.Fatal(decodeErr)
+		}
+
+		applied.Warning = exported.Warning
+		if !reflect.DeepEqual(exported, applied) {
+			t.Errorf("%s = %+v, want the config applied %+v", fileName, exported, applied)
+		}
+	}
+
+	// Secrets are only exported when asked for.
+	exported, exportErr := Export(false)
+	if exportErr != nil {
+		t.Fatal(exportErr)
+	}
+	if exported.Credentials != nil || exported.Telegram.BotId != nil || *exported.Telegram.UserId != "user" {
+		t.Errorf("Export(false) = %+v, want the secrets left out", exported)
+	}
+}
+
+func TestApplyInvalidConfigSavesNothing(t *testing.T) {
+	if err := Apply(Config{Preferences: PreferencesConfig{Directory: stringPtr("/before")}, Filters: []string{"exclude ext mp4"}}); err != nil {
+		t.Fatal(err)
+	}
+	before, _ := Export(true)
+
+	invalid := []Config{
+		{Preferences: PreferencesConfig{Directory: stringPtr("/after"), Schedule: stringPtr("hourly")}},
+		{Preferences: PreferencesConfig{Directory: stringPtr("/after")}, Filters: []string{"exclude size 1MB"}},
+		{Preferences: PreferencesConfig{Directory: stringPtr("/after")}, Hooks: []string{"on deleted do echo"}},
+		{Preferences: PreferencesConfig{Directory: stringPtr("/after"), ConflictPolicy: stringPtr("merge")}},
+	}
+
+	for _, config := range invalid {
+		if err := Apply(config); err == nil {
+			t.Errorf("Apply(%+v) = nil, want an error", config)
+		}
+
+		if after, _ := Export(true); !reflect.DeepEqual(after, before) {
+			t.Errorf("Apply(%+v) saved %+v, want nothing saved", config, after)
+		}
+	}
+}
+
+func TestEmptyListsClear(t *testing.T) {
+	if err := Apply(Config{Filters: []string{"exclude ext mp4"}, Hooks: []string{"on finished do echo done"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Lists that are not set are left as they are.
+	if err := Apply(Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if filterText, _ := appFilter.GetFilterText(); filterText != "exclude ext mp4" {
+		t.Errorf("GetFilterText() = %q, want the filter rules kept", filterText)
+	}
+
+	config, decodeErr := Decode([]byte("filters = []\n[preferences]\n"), "config.toml")
+	if decodeErr != nil {
+		t.Fatal(decodeErr)
+	}
+	if err := Apply(config); err != nil {
+		t.Fatal(err)
+	}
+	if filterText, _ := appFilter.GetFilterText(); filterText != "" {
+		t.Errorf("GetFilterText() = %q, want the filter rules cleared", filterText)
+	}
+	if hooksText, _ := appHooks.GetHooksText(); hooksText != "on finished do echo done" {
+		t.Errorf("GetHooksText() = %q, want the hooks kept", hooksText)
+	}
+
+	exported, _ := Export(false)
+	data, encodeErr := Encode(exported, "config.toml")
+	if encodeErr != nil {
+		t.Fatal(encodeErr)
+	}
+	if !strings.Contains(string(data), "filters = []") {
+		t.Errorf("Encode() = %s, want the filter rules written as an empty list", data)
+	}
+}
+
+func TestApplyStartupConfigHooksNeedOptIn(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.toml")
+	t.Setenv(CONFIG_PATH_ENV, configPath)
+	if err := Apply(Config{Hooks: []string{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	data := "hooks = [\"on finished do echo done\"]\n[preferences]\ndirectory = \"/startup\"\n"
+	if err := os.WriteFile(configPath, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if applied, err := ApplyStartupConfig(); err != nil || applied != configPath {
+		t.Fatalf("ApplyStartupConfig() = %q, %v, want %q applied", applied, err, configPath)
+	}
+	if hooksText, _ := appHooks.GetHooksText(); hooksText != "" {
+		t.Errorf("GetHooksText() = %q without %s, want no hook installed", hooksText, ALLOW_HOOKS_ENV)
+	}
+	if exported, _ := Export(false); *exported.Preferences.Directory != "/startup" {
+		t.Errorf("directory = %q, want the other settings applied", *exported.Preferences.Directory)
+	}
+
+	t.Setenv(ALLOW_HOOKS_ENV, "true")
+	if _, err := ApplyStartupConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if hooksText, _ := appHooks.GetHooksText(); hooksText != "on finished do echo done" {
+		t.Errorf("GetHooksText() = %q with %s, want the hooks installed", hooksText, ALLOW_HOOKS_ENV)
+	}
+}
+
+// runWithTestDB is a helper function that runs the tests with the Lominus folder in a temporary folder.
+func runWithTestDB(m *testing.M) int {
+	configDir, tempErr := os.MkdirTemp("", "lominus-config")
+	if tempErr != nil {
+		panic(tempErr)
+	}
+	defer os.RemoveAll(configDir)
+
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		os.Setenv(env, configDir)
+	}
+
+	db, initErr := app.Init()
+	if initErr != nil {
+		panic(initErr)
+	}
+	defer db.Close()
+
+	return m.Run()
+}
+
+// boolPtr is a helper function that returns a pointer to b.
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// intPtr is a helper function that returns a pointer to i.
+func intPtr(i int) *int {
+	return &i
+}
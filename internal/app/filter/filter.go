@@ -0,0 +1,46 @@
+// Package appFilter provides retrievers for Lominus file filter rules.
+package appFilter
+
+import (
+	"github.com/beebeeoii/lominus/internal/app"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/pkg/store"
+)
+
+// FILTERS_BUCKET_NAME is the name of the bucket the user's filter rules are stored in, with the key FILTERS_KEY.
+const FILTERS_BUCKET_NAME = "Filters"
+
+const FILTERS_KEY = "rules"
+
+func init() {
+	app.RegisterBuckets(FILTERS_BUCKET_NAME)
+}
+
+// GetFilterText returns the user's filter rules in their text representation.
+func GetFilterText() (string, error) {
+	return app.GetText(FILTERS_BUCKET_NAME, FILTERS_KEY)
+}
+
+// GetFilterRules returns the user's parsed filter rules.
+func GetFilterRules() (filter.Rules, error) {
+	text, err := GetFilterText()
+	if err != nil {
+		return filter.Rules{}, err
+	}
+
+	return filter.Parse(text)
+}
+
+// SaveFilterText validates and saves the user's filter rules locally.
+func SaveFilterText(text string) error {
+	return SaveFilterTextTo(app.GetStore(), text)
+}
+
+// SaveFilterTextTo validates and saves the user's filter rules into the Store.
+func SaveFilterTextTo(s store.Store, text string) error {
+	if _, parseErr := filter.Parse(text); parseErr != nil {
+		return parseErr
+	}
+
+	return s.Put(FILTERS_BUCKET_NAME, FILTERS_KEY, []byte(text))
+}
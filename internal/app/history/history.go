@@ -0,0 +1,190 @@
+// Package appHistory provides retrievers for the history of Lominus sync runs.
+package appHistory
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
+)
+
+// Run struct describes the outcome of one sync run.
+// Trigger describes what started the run, and is one of TRIGGER_SCHEDULED or TRIGGER_MANUAL.
+// Errors contains the errors that are not specific to a file, and Report contains all the errors
+// collated by module and category.
+type Run struct {
+	Started   time.Time
+	Finished  time.Time
+	Trigger   string
+	Modules   []string
+	Added     int
+	Updated   int
+	Failed    int
+	Errors    []string
+	Files     []FileResult
+	Report    appSync.Report
+	Cancelled bool
+}
+
+// FileResult struct describes what happened to a file during a sync run.
+// Result is one of RESULT_DOWNLOADED, RESULT_FAILED, RESULT_CONFLICT, RESULT_COLLISION or RESULT_TRASHED.
+// Policy is the collision policy applied for RESULT_COLLISION.
+type FileResult struct {
+	Key    string
+	Action string
+	Result string
+	Error  string
+	Policy string
+}
+
+// Triggers
+const (
+	TRIGGER_SCHEDULED = "scheduled"
+	TRIGGER_MANUAL    = "manual"
+)
+
+// File results
+const (
+	RESULT_DOWNLOADED = "downloaded"
+	RESULT_FAILED     = "failed"
+	RESULT_CONFLICT   = "conflict"
+	RESULT_COLLISION  = "collision"
+	RESULT_TRASHED    = "trashed"
+)
+
+// HISTORY_BUCKET_NAME is the name of the bucket the runs are stored in.
+const HISTORY_BUCKET_NAME = "History"
+
+func init() {
+	app.RegisterBuckets(HISTORY_BUCKET_NAME)
+}
+
+// MAX_RUNS is the number of most recent runs kept in the history.
+const MAX_RUNS = 100
+
+// NewRun creates the Run describing the outcome of a sync, started by trigger.
+// runErr is the error that stopped the sync, if any.
+func NewRun(result appSync.Result, trigger string, runErr error) Run {
+	run := Run{
+		Started:   result.Started,
+		Finished:  result.Finished,
+		Trigger:   trigger,
+		Modules:   result.Plan.VisitedModules(),
+		Failed:    len(result.Failed),
+		Errors:    []string{},
+		Files:     []FileResult{},
+		Report:    appSync.NewReport(result),
+		Cancelled: result.Cancelled,
+	}
+
+	if runErr != nil {
+		run.Errors = append(run.Errors, runErr.Error())
+	}
+
+	for _, err := range result.Errors {
+		run.Errors = append(run.Errors, err.Error())
+	}
+
+	for _, entry := range result.Downloaded {
+		if entry.Action == appSync.ActionNew {
+			run.Added++
+		} else {
+			run.Updated++
+		}
+
+		run.Files = append(run.Files, FileResult{Key: entry.Key, Action: string(entry.Action), Result: RESULT_DOWNLOADED})
+	}
+
+	for _, fileErr := range result.Failed {
+		run.Files = append(run.Files, FileResult{
+			Key:    fileErr.Entry.Key,
+			Action: string(fileErr.Entry.Action),
+			Result: RESULT_FAILED,
+			Error:  fileErr.Err.Error(),
+		})
+	}
+
+	for _, entry := range result.Conflicts {
+		run.Files = append(run.Files, FileResult{Key: entry.Key, Action: string(entry.Action), Result: RESULT_CONFLICT})
+	}
+
+	for _, collision := range result.Collisions {
+		run.Files = append(run.Files, FileResult{
+			Key:    collision.Entry.Key,
+			Action: string(collision.Entry.Action),
+			Result: RESULT_COLLISION,
+			Policy: collision.Policy,
+		})
+	}
+
+	for _, entry := range result.Trashed {
+		run.Files = append(run.Files, FileResult{Key: entry.Key, Action: string(entry.Action), Result: RESULT_TRASHED})
+	}
+
+	return run
+}
+
+// SaveRun saves the run into the history, removing the oldest runs beyond MAX_RUNS.
+func SaveRun(run Run) error {
+	historyStore := app.GetStore()
+
+	runData, marshalErr := json.Marshal(run)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	putErr := historyStore.Put(HISTORY_BUCKET_NAME, getRunKey(run), runData)
+	if putErr != nil {
+		return putErr
+	}
+
+	keys := []string{}
+	forEachErr := historyStore.ForEach(HISTORY_BUCKET_NAME, func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if forEachErr != nil {
+		return forEachErr
+	}
+
+	for i := 0; i < len(keys)-MAX_RUNS; i++ {
+		deleteErr := historyStore.Delete(HISTORY_BUCKET_NAME, keys[i])
+		if deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	return nil
+}
+
+// GetRuns returns the runs in the history, most recent first.
+func GetRuns() ([]Run, error) {
+	runs := []Run{}
+
+	err := app.GetStore().ForEach(HISTORY_BUCKET_NAME, func(_ string, value []byte) error {
+		var run Run
+		unmarshalErr := json.Unmarshal(value, &run)
+		if unmarshalErr != nil {
+			return unmarshalErr
+		}
+
+		runs = append(runs, run)
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	slices.Reverse(runs)
+
+	return runs, nil
+}
+
+// getRunKey is a helper function that returns the key of the run, which sorts in the order the runs started.
+func getRunKey(run Run) string {
+	return fmt.Sprintf("%020d", run.Started.UnixNano())
+}
@@ -0,0 +1,104 @@
+package appHistory
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithTestDB(m))
+}
+
+func TestNewRun(t *testing.T) {
+	started := time.Date(2024, 3, 1, 13, 45, 0, 0, time.UTC)
+	result := appSync.Result{
+		Started:  started,
+		Finished: started.Add(time.Minute),
+		Downloaded: []appSync.PlanEntry{
+			{Action: appSync.ActionNew, Key: "CS2040/Lecture1.pdf"},
+			{Action: appSync.ActionUpdate, Key: "CS2040/Lecture2.pdf"},
+		},
+		Failed:     []appSync.FileError{{Entry: appSync.PlanEntry{Action: appSync.ActionNew, Key: "CS2040/Lecture3.pdf"}, Err: errors.New("timeout")}},
+		Conflicts:  []appSync.PlanEntry{{Action: appSync.ActionUpdate, Key: "CS2040/Notes.txt"}},
+		Collisions: []appSync.Collision{{Entry: appSync.PlanEntry{Action: appSync.ActionNew, Key: "CS2030S/Slides.pdf"}, Policy: "skip"}},
+		Trashed:    []appSync.PlanEntry{{Action: appSync.ActionRemoteDeleted, Key: "CS2030S/Old.pdf"}},
+		Errors:     []appSync.ModuleError{{Module: "CS2030S", Err: errors.New("forbidden")}},
+	}
+
+	run := NewRun(result, TRIGGER_MANUAL, errors.New("cancelled"))
+
+	if run.Added != 1 || run.Updated != 1 || run.Failed != 1 || run.Trigger != TRIGGER_MANUAL || !run.Started.Equal(started) {
+		t.Errorf("NewRun() = %+v, want 1 file added, 1 updated and 1 failed", run)
+	}
+
+	wantErrors := []string{"cancelled", result.Errors[0].Error()}
+	if !reflect.DeepEqual(run.Errors, wantErrors) {
+		t.Errorf("NewRun() errors = %q, want %q", run.Errors, wantErrors)
+	}
+
+	wantFiles := []FileResult{
+		{Key: "CS2040/Lecture1.pdf", Action: string(appSync.ActionNew), Result: RESULT_DOWNLOADED},
+		{Key: "CS2040/Lecture2.pdf", Action: string(appSync.ActionUpdate), Result: RESULT_DOWNLOADED},
+		{Key: "CS2040/Lecture3.pdf", Action: string(appSync.ActionNew), Result: RESULT_FAILED, Error: "timeout"},
+		{Key: "CS2040/Notes.txt", Action: string(appSync.ActionUpdate), Result: RESULT_CONFLICT},
+		{Key: "CS2030S/Slides.pdf", Action: string(appSync.ActionNew), Result: RESULT_COLLISION, Policy: "skip"},
+		{Key: "CS2030S/Old.pdf", Action: string(appSync.ActionRemoteDeleted), Result: RESULT_TRASHED},
+	}
+	if !reflect.DeepEqual(run.Files, wantFiles) {
+		t.Errorf("NewRun() files = %+v, want %+v", run.Files, wantFiles)
+	}
+}
+
+func TestSaveRunAndGetRuns(t *testing.T) {
+	started := time.Date(2024, 3, 1, 13, 45, 0, 0, time.UTC)
+
+	for i := 0; i < MAX_RUNS+2; i++ {
+		run := Run{Started: started.Add(time.Duration(i) * time.Hour), Trigger: TRIGGER_SCHEDULED, Added: i}
+		if err := SaveRun(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	runs, getErr := GetRuns()
+	if getErr != nil {
+		t.Fatal(getErr)
+	}
+
+	// Only the most recent runs are kept, most recent first.
+	if len(runs) != MAX_RUNS {
+		t.Fatalf("GetRuns() = %d runs, want %d", len(runs), MAX_RUNS)
+	}
+	if runs[0].Added != MAX_RUNS+1 || runs[len(runs)-1].Added != 2 {
+		t.Errorf("GetRuns() = runs %d to %d, want runs %d to 2", runs[0].Added, runs[len(runs)-1].Added, MAX_RUNS+1)
+	}
+	if !runs[0].Started.Equal(started.Add((MAX_RUNS+1)*time.Hour)) || runs[0].Trigger != TRIGGER_SCHEDULED {
+		t.Errorf("GetRuns()[0] = %+v, want the run as it was saved", runs[0])
+	}
+}
+
+// runWithTestDB is a helper function that runs the tests with the Lominus folder in a temporary folder.
+func runWithTestDB(m *testing.M) int {
+	configDir, tempErr := os.MkdirTemp("", "lominus-history")
+	if tempErr != nil {
+		panic(tempErr)
+	}
+	defer os.RemoveAll(configDir)
+
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		os.Setenv(env, configDir)
+	}
+
+	db, initErr := app.Init()
+	if initErr != nil {
+		panic(initErr)
+	}
+	defer db.Close()
+
+	return m.Run()
+}
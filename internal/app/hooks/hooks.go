@@ -0,0 +1,46 @@
+// Package appHooks provides retrievers for the hooks configured by the user.
+package appHooks
+
+import (
+	"github.com/beebeeoii/lominus/internal/app"
+	"github.com/beebeeoii/lominus/internal/hooks"
+	"github.com/beebeeoii/lominus/pkg/store"
+)
+
+// HOOKS_BUCKET_NAME is the name of the bucket the user's hooks are stored in, with the key HOOKS_KEY.
+const HOOKS_BUCKET_NAME = "Hooks"
+
+const HOOKS_KEY = "hooks"
+
+func init() {
+	app.RegisterBuckets(HOOKS_BUCKET_NAME)
+}
+
+// GetHooksText returns the user's hooks in their text representation.
+func GetHooksText() (string, error) {
+	return app.GetText(HOOKS_BUCKET_NAME, HOOKS_KEY)
+}
+
+// GetHooks returns the user's parsed hooks.
+func GetHooks() (hooks.Hooks, error) {
+	text, err := GetHooksText()
+	if err != nil {
+		return hooks.Hooks{}, err
+	}
+
+	return hooks.Parse(text)
+}
+
+// SaveHooksText validates and saves the user's hooks locally.
+func SaveHooksText(text string) error {
+	return SaveHooksTextTo(app.GetStore(), text)
+}
+
+// SaveHooksTextTo validates and saves the user's hooks into the Store.
+func SaveHooksTextTo(s store.Store, text string) error {
+	if _, parseErr := hooks.Parse(text); parseErr != nil {
+		return parseErr
+	}
+
+	return s.Put(HOOKS_BUCKET_NAME, HOOKS_KEY, []byte(text))
+}
@@ -3,7 +3,8 @@ package intTelegram
 
 import (
 	"github.com/beebeeoii/lominus/internal/app"
-	"github.com/boltdb/bolt"
+	appSecrets "github.com/beebeeoii/lominus/internal/app/secrets"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
 )
 
 type TelegramIds struct {
@@ -11,42 +12,30 @@ type TelegramIds struct {
 	BotId  string
 }
 
-func GetTelegramIds() (TelegramIds, error) {
-	dbInstance := app.GetDBInstance()
-	var telegramIds TelegramIds
-
-	err := dbInstance.View(func(tx *bolt.Tx) error {
-		intBucket := tx.Bucket([]byte("Integrations"))
-		telegramUserId := string(intBucket.Get([]byte("telegramUserId")))
-		telegramBotId := string(intBucket.Get([]byte("telegramBotId")))
-
-		telegramIds.UserId = telegramUserId
-		telegramIds.BotId = telegramBotId
+func init() {
+	app.RegisterBuckets(telegram.TELEGRAM_BUCKET)
+}
 
-		return nil
-	})
+func GetTelegramIds() (TelegramIds, error) {
+	telegramInfo, loadErr := telegram.LoadTelegramInfo(app.GetStore())
+	if loadErr != nil {
+		return TelegramIds{}, loadErr
+	}
 
-	if err != nil {
-		return TelegramIds{}, err
+	botId, decryptErr := appSecrets.Decrypt(telegramInfo.BotApi)
+	if decryptErr != nil {
+		return TelegramIds{}, decryptErr
 	}
 
-	return telegramIds, nil
+	return TelegramIds{UserId: telegramInfo.UserId, BotId: botId}, nil
 }
 
-// SaveTelegramCredentials saves the user's Telegram userId and botId locally.
+// SaveTelegramCredentials saves the user's Telegram userId and botId locally, with the botId encrypted.
 func SaveTelegramCredentials(userId string, botId string) error {
-	dbInstance := app.GetDBInstance()
-
-	updateErr := dbInstance.Update(func(tx *bolt.Tx) error {
-		err := tx.Bucket([]byte("Integrations")).Put([]byte("telegramUserId"), []byte(userId))
-		err1 := tx.Bucket([]byte("Integrations")).Put([]byte("telegramBotId"), []byte(botId))
-
-		if err != nil {
-			return err
-		}
-
-		return err1
-	})
+	encryptedBotId, encryptErr := appSecrets.Encrypt(botId)
+	if encryptErr != nil {
+		return encryptErr
+	}
 
-	return updateErr
+	return telegram.SaveTelegramInfo(app.GetStore(), telegram.TelegramInfo{BotApi: encryptedBotId, UserId: userId})
 }
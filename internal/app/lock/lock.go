@@ -2,13 +2,15 @@
 package appLock
 
 import (
+	"os"
 	"path/filepath"
 
 	appDir "github.com/beebeeoii/lominus/internal/app/dir"
 	appConstants "github.com/beebeeoii/lominus/internal/constants"
 )
 
-// GetLockPath returns the file path to Lominus lock file.
+// GetLockPath returns the file path to Lominus lock file, creating the Lominus folder if it does not exist,
+// as the lock is taken before the app is initialised.
 func GetLockPath() (string, error) {
 	var lockPath string
 
@@ -17,6 +19,10 @@ func GetLockPath() (string, error) {
 		return lockPath, retrieveBaseDirErr
 	}
 
+	if mkdirErr := os.MkdirAll(baseDir, os.ModePerm); mkdirErr != nil {
+		return lockPath, mkdirErr
+	}
+
 	lockPath = filepath.Join(baseDir, appConstants.LOCK_FILE_NAME)
 
 	return lockPath, nil
@@ -0,0 +1,236 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/file"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/schedule"
+	"github.com/beebeeoii/lominus/pkg/auth"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
+	"github.com/beebeeoii/lominus/pkg/store"
+	"github.com/boltdb/bolt"
+)
+
+// migration struct describes a change to the database schema that brings it to version.
+// Databases created before the schema was versioned are at version 0 and go through every migration,
+// so migrations must leave data that is already migrated as it is.
+type migration struct {
+	version     int
+	description string
+	migrate     func(tx *bolt.Tx) error
+}
+
+// migrations lists every migration in the order they are run. Migrations are only ever appended,
+// with the version after the last one.
+var migrations = []migration{
+	{version: 1, description: "create buckets", migrate: createBuckets},
+	{version: 2, description: "seed preference defaults", migrate: seedPreferences},
+	{version: 3, description: "import legacy credential and telegram files", migrate: importLegacyFiles},
+}
+
+// SCHEMA_VERSION is the version of the database schema this version of Lominus uses.
+var SCHEMA_VERSION = migrations[len(migrations)-1].version
+
+// NewerSchemaError is returned when the database was migrated by a newer version of Lominus.
+type NewerSchemaError struct {
+	Version int
+}
+
+// Error returns the description of the error.
+func (e *NewerSchemaError) Error() string {
+	return fmt.Sprintf(
+		"database schema version %d is newer than version %d supported by this version of Lominus - please update Lominus",
+		e.Version,
+		SCHEMA_VERSION,
+	)
+}
+
+// GetSchemaVersion returns the version of the database schema, which is 0 for databases created
+// before the schema was versioned.
+func GetSchemaVersion(db *bolt.DB) (int, error) {
+	version := 0
+
+	err := db.View(func(tx *bolt.Tx) error {
+		metaBucket := tx.Bucket([]byte("Meta"))
+		if metaBucket == nil {
+			return nil
+		}
+
+		schemaVersion := metaBucket.Get([]byte("schemaVersion"))
+		if schemaVersion == nil {
+			return nil
+		}
+
+		var parseErr error
+		version, parseErr = strconv.Atoi(string(schemaVersion))
+		return parseErr
+	})
+
+	return version, err
+}
+
+// migrate is a helper function that runs the migrations the database has not gone through yet, in order.
+// Each migration runs in its own transaction together with the update of the schema version, such that
+// a failed migration leaves the database at the version before it. A copy of the database is saved next to it,
+// eg. lominus.db.v1.bak for a database at version 1, before the first migration, unless the database is new.
+// NewerSchemaError is returned if the database was migrated by a newer version of Lominus.
+func migrate(db *bolt.DB) error {
+	version, versionErr := GetSchemaVersion(db)
+	if versionErr != nil {
+		return versionErr
+	}
+
+	if version > SCHEMA_VERSION {
+		return &NewerSchemaError{Version: version}
+	}
+
+	if version == SCHEMA_VERSION {
+		return nil
+	}
+
+	if !isEmpty(db) {
+		backupPath := fmt.Sprintf("%s.v%d.bak", db.Path(), version)
+		backupErr := db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(backupPath, 0600)
+		})
+		if backupErr != nil {
+			return backupErr
+		}
+		logs.Logger.Infof("database backed up before migrating: %s", backupPath)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		migrateErr := db.Update(func(tx *bolt.Tx) error {
+			if err := m.migrate(tx); err != nil {
+				return err
+			}
+
+			metaBucket, metaBucketErr := tx.CreateBucketIfNotExists([]byte("Meta"))
+			if metaBucketErr != nil {
+				return metaBucketErr
+			}
+
+			return metaBucket.Put([]byte("schemaVersion"), []byte(strconv.Itoa(m.version)))
+		})
+		if migrateErr != nil {
+			return fmt.Errorf("migration to version %d (%s) failed: %w", m.version, m.description, migrateErr)
+		}
+
+		logs.Logger.Infof("database migrated to version %d: %s", m.version, m.description)
+	}
+
+	return nil
+}
+
+// isEmpty is a helper function that checks whether the database has no buckets, as when it is new.
+func isEmpty(db *bolt.DB) bool {
+	empty := true
+
+	db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			empty = false
+			return nil
+		})
+	})
+
+	return empty
+}
+
+// buckets lists the buckets registered with RegisterBuckets.
+var buckets []string
+
+// RegisterBuckets registers the buckets a package stores its data in, such that they are created when
+// the database is migrated. It is called from the init function of the package that owns the buckets.
+// Buckets registered after a database has been migrated are created when they are first written to.
+func RegisterBuckets(names ...string) {
+	buckets = append(buckets, names...)
+}
+
+// createBuckets is a migration that creates the buckets registered with RegisterBuckets.
+func createBuckets(tx *bolt.Tx) error {
+	for _, bucket := range buckets {
+		if _, err := tx.CreateBucketIfNotExists([]byte(bucket)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// seedPreferences is a migration that sets the preferences that are not set to their defaults.
+// Schedule defaults to the interval of the legacy frequency if it was set.
+func seedPreferences(tx *bolt.Tx) error {
+	prefBucket, prefBucketErr := tx.CreateBucketIfNotExists([]byte("Preferences"))
+	if prefBucketErr != nil {
+		return prefBucketErr
+	}
+
+	if prefBucket.Get([]byte("schedule")) == nil {
+		frequency, _ := strconv.Atoi(string(prefBucket.Get([]byte("frequency"))))
+		if err := prefBucket.Put([]byte("schedule"), []byte(schedule.FromFrequency(frequency).String())); err != nil {
+			return err
+		}
+	}
+
+	defaults := [][2]string{
+		{"trashRetentionDays", "30"},
+		{"conflictPolicy", "keepBoth"},
+		{"collisionPolicy", "renameOld"},
+		{"versionsKeepLast", "5"},
+		{"logLevel", "info"},
+	}
+
+	for _, pref := range defaults {
+		if prefBucket.Get([]byte(pref[0])) != nil {
+			continue
+		}
+
+		if err := prefBucket.Put([]byte(pref[0]), []byte(pref[1])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importLegacyFiles is a migration that imports the credentials and Telegram data saved in gob files
+// in the Lominus folder by the public API into the database, unless the database already has them.
+// The files are left as they are, and files that cannot be imported are skipped. Imported secrets are
+// encrypted by appSecrets.Init.
+func importLegacyFiles(tx *bolt.Tx) error {
+	baseDir := filepath.Dir(tx.DB().Path())
+	txStore := store.TxStore{Tx: tx}
+
+	credentialsPath := filepath.Join(baseDir, appConstants.LEGACY_CREDENTIALS_FILE_NAME)
+	credentialsImported, importCredentialsErr := auth.ImportCredentialsData(credentialsPath, txStore)
+	logImport(credentialsPath, credentialsImported, importCredentialsErr)
+
+	telegramDataPath := filepath.Join(baseDir, appConstants.LEGACY_TELEGRAM_FILE_NAME)
+	telegramImported, importTelegramErr := telegram.ImportTelegramData(telegramDataPath, txStore)
+	logImport(telegramDataPath, telegramImported, importTelegramErr)
+
+	return nil
+}
+
+// logImport is a helper function that logs whether the legacy file at filePath was imported.
+// Missing files are not logged.
+func logImport(filePath string, imported bool, importErr error) {
+	var fileNotFoundErr *file.FileNotFoundError
+
+	switch {
+	case errors.As(importErr, &fileNotFoundErr):
+	case importErr != nil:
+		logs.Logger.Warnf("unable to import %s: %s", filePath, importErr)
+	case imported:
+		logs.Logger.Infof("imported %s", filePath)
+	}
+}
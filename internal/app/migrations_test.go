@@ -0,0 +1,105 @@
+package app
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/pkg/auth"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
+	"github.com/beebeeoii/lominus/pkg/store"
+	"github.com/boltdb/bolt"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = logrus.New()
+	logs.Logger.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+func TestMigrateImportsLegacyFiles(t *testing.T) {
+	baseDir := t.TempDir()
+
+	credentialsPath := filepath.Join(baseDir, appConstants.LEGACY_CREDENTIALS_FILE_NAME)
+	if err := (auth.CanvasCredentials{CanvasApiToken: "token"}).Save(credentialsPath); err != nil {
+		t.Fatal(err)
+	}
+	telegramDataPath := filepath.Join(baseDir, appConstants.LEGACY_TELEGRAM_FILE_NAME)
+	if err := telegram.SaveTelegramData(telegramDataPath, telegram.TelegramInfo{BotApi: "bot", UserId: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openTestDB(t, baseDir)
+	if err := migrate(db); err != nil {
+		t.Fatal(err)
+	}
+
+	s := store.BoltStore{DB: db}
+	if credentials, err := auth.LoadCanvasCredentials(s); err != nil || credentials.CanvasApiToken != "token" {
+		t.Errorf("LoadCanvasCredentials() = %+v, %v, want the token in %s", credentials, err, credentialsPath)
+	}
+	if telegramInfo, err := telegram.LoadTelegramInfo(s); err != nil || telegramInfo.BotApi != "bot" || telegramInfo.UserId != "user" {
+		t.Errorf("LoadTelegramInfo() = %+v, %v, want the data in %s", telegramInfo, err, telegramDataPath)
+	}
+
+	if version, err := GetSchemaVersion(db); err != nil || version != SCHEMA_VERSION {
+		t.Errorf("GetSchemaVersion() = %d, %v, want %d", version, err, SCHEMA_VERSION)
+	}
+}
+
+func TestMigrateSkipsUnreadableLegacyFiles(t *testing.T) {
+	baseDir := t.TempDir()
+
+	telegramDataPath := filepath.Join(baseDir, appConstants.LEGACY_TELEGRAM_FILE_NAME)
+	if err := os.WriteFile(telegramDataPath, []byte("not a gob"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	db := openTestDB(t, baseDir)
+	if err := migrate(db); err != nil {
+		t.Fatalf("migrate() = %v, want the unreadable file and the missing file skipped", err)
+	}
+
+	if telegramInfo, _ := telegram.LoadTelegramInfo(store.BoltStore{DB: db}); telegramInfo != (telegram.TelegramInfo{}) {
+		t.Errorf("LoadTelegramInfo() = %+v, want nothing imported", telegramInfo)
+	}
+}
+
+func TestMigrateNewerSchema(t *testing.T) {
+	db := openTestDB(t, t.TempDir())
+
+	updateErr := db.Update(func(tx *bolt.Tx) error {
+		metaBucket, metaBucketErr := tx.CreateBucketIfNotExists([]byte("Meta"))
+		if metaBucketErr != nil {
+			return metaBucketErr
+		}
+
+		return metaBucket.Put([]byte("schemaVersion"), []byte("999"))
+	})
+	if updateErr != nil {
+		t.Fatal(updateErr)
+	}
+
+	if err := migrate(db); err == nil {
+		t.Errorf("migrate() of a newer schema = nil, want NewerSchemaError")
+	}
+}
+
+// openTestDB is a helper function that opens a database in baseDir, which is closed when the test ends.
+func openTestDB(t *testing.T, baseDir string) *bolt.DB {
+	t.Helper()
+
+	db, dbErr := bolt.Open(filepath.Join(baseDir, appConstants.DATABASE_FILE_NAME), 0600, &bolt.Options{Timeout: time.Second})
+	if dbErr != nil {
+		t.Fatal(dbErr)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
@@ -3,75 +3,290 @@ package appPref
 
 import (
 	"fmt"
+	"slices"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/beebeeoii/lominus/internal/app"
-	"github.com/boltdb/bolt"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/schedule"
+	"github.com/beebeeoii/lominus/pkg/store"
 )
 
 // Preferences struct describes the data being stored in the user's preferences file.
+// Schedule describes when automatic syncs run, and QuietHours when no sync or notification runs.
+// IncrementalSync describes whether syncs only retrieve the files that changed since the last sync.
+// ExtractArchives describes whether downloaded archives, such as zip files, are extracted into a folder next to them.
+// MirrorDeletions describes whether files deleted remotely are moved into the trash folder.
+// TrashRetentionDays describes how long trashed files are kept, and forever if not positive.
+// ConflictPolicy describes what happens when a locally modified file is updated remotely,
+// and is one of CONFLICT_KEEP_BOTH, CONFLICT_SKIP or CONFLICT_OVERWRITE.
+// CollisionPolicy describes what happens when a file not downloaded by Lominus is in the way of a download,
+// and is one of the COLLISION_ policies. ModuleCollisionPolicies overrides it for the modules in it,
+// keyed by module code.
+// VersionsKeepLast and VersionsKeepDays describe how many superseded versions of every file are kept,
+// and for how many days. A limit that is not positive is not applied.
 type Preferences struct {
-	Directory string
-	Frequency int
-	LogLevel  string
+	Directory               string
+	Schedule                schedule.Schedule
+	QuietHours              schedule.QuietHours
+	IncrementalSync         bool
+	ExtractArchives         bool
+	LogLevel                string
+	MirrorDeletions         bool
+	TrashRetentionDays      int
+	ConflictPolicy          string
+	CollisionPolicy         string
+	ModuleCollisionPolicies map[string]string
+	VersionsKeepLast        int
+	VersionsKeepDays        int
 }
 
-func GetPreferences() (Preferences, error) {
-	dbInstance := app.GetDBInstance()
-	var pref Preferences
+// Conflict policies
+const (
+	// CONFLICT_KEEP_BOTH renames the locally modified file before downloading the update.
+	CONFLICT_KEEP_BOTH = "keepBoth"
+	// CONFLICT_SKIP keeps the locally modified file and does not download the update.
+	CONFLICT_SKIP = "skip"
+	// CONFLICT_OVERWRITE replaces the locally modified file with the update, keeping it in the .versions folder.
+	CONFLICT_OVERWRITE = "overwrite"
+)
+
+// Collision policies
+const (
+	// COLLISION_RENAME_OLD renames the file in the way before downloading.
+	COLLISION_RENAME_OLD = "renameOld"
+	// COLLISION_RENAME_NEW keeps the file in the way and downloads under a name with the file Id.
+	COLLISION_RENAME_NEW = "renameNew"
+	// COLLISION_OVERWRITE replaces the file in the way with the download.
+	COLLISION_OVERWRITE = "overwrite"
+	// COLLISION_SKIP keeps the file in the way and does not download.
+	COLLISION_SKIP = "skip"
+)
+
+// CollisionPolicies lists every collision policy.
+var CollisionPolicies = []string{
+	COLLISION_RENAME_OLD,
+	COLLISION_RENAME_NEW,
+	COLLISION_OVERWRITE,
+	COLLISION_SKIP,
+}
+
+// PREFERENCES_BUCKET_NAME is the name of the bucket the Preferences are stored in.
+const PREFERENCES_BUCKET_NAME = "Preferences"
 
-	err := dbInstance.View(func(tx *bolt.Tx) error {
-		prefBucket := tx.Bucket([]byte("Preferences"))
-		directory := string(prefBucket.Get([]byte("directory")))
-		frequency, _ := strconv.Atoi(string(prefBucket.Get([]byte("frequency"))))
-		logLevel := string(prefBucket.Get([]byte("logLevel")))
+func init() {
+	app.RegisterBuckets(PREFERENCES_BUCKET_NAME)
+}
+
+func GetPreferences() (Preferences, error) {
+	return LoadPreferences(app.GetStore())
+}
 
-		pref.Directory = directory
-		pref.Frequency = frequency
-		pref.LogLevel = logLevel
+// LoadPreferences loads the user's preferences from the Store.
+func LoadPreferences(s store.Store) (Preferences, error) {
+	var pref Preferences
 
+	values := map[string]string{}
+	forEachErr := s.ForEach(PREFERENCES_BUCKET_NAME, func(key string, value []byte) error {
+		values[key] = string(value)
 		return nil
 	})
+	if forEachErr != nil {
+		return Preferences{}, forEachErr
+	}
 
-	if err != nil {
-		return Preferences{}, err
+	// Preferences that cannot be parsed fall back to their defaults, such that Lominus still starts
+	// and the user can fix them in Preferences.
+	syncSchedule, scheduleErr := schedule.Parse(values["schedule"])
+	if scheduleErr != nil {
+		logs.Logger.Warnf("invalid schedule preference, automatic syncs disabled: %s", scheduleErr)
+		syncSchedule = schedule.Disabled
+	}
+	quietHours, quietHoursErr := schedule.ParseQuietHours(values["quietHours"])
+	if quietHoursErr != nil {
+		logs.Logger.Warnf("invalid quiet hours preference, ignored: %s", quietHoursErr)
+		quietHours = schedule.QuietHours{}
+	}
+	moduleCollisionPolicies, moduleCollisionPoliciesErr := ParseModuleCollisionPolicies(values["moduleCollisionPolicies"])
+	if moduleCollisionPoliciesErr != nil {
+		logs.Logger.Warnf("invalid module collision policies preference, ignored: %s", moduleCollisionPoliciesErr)
+		moduleCollisionPolicies = map[string]string{}
 	}
+	trashRetentionDays, _ := strconv.Atoi(values["trashRetentionDays"])
+	versionsKeepLast, _ := strconv.Atoi(values["versionsKeepLast"])
+	versionsKeepDays, _ := strconv.Atoi(values["versionsKeepDays"])
+
+	pref.Directory = values["directory"]
+	pref.Schedule = syncSchedule
+	pref.QuietHours = quietHours
+	pref.IncrementalSync = values["incrementalSync"] == "true"
+	pref.ExtractArchives = values["extractArchives"] == "true"
+	pref.LogLevel = values["logLevel"]
+	pref.MirrorDeletions = values["mirrorDeletions"] == "true"
+	pref.TrashRetentionDays = trashRetentionDays
+	pref.ConflictPolicy = values["conflictPolicy"]
+	pref.CollisionPolicy = values["collisionPolicy"]
+	pref.ModuleCollisionPolicies = moduleCollisionPolicies
+	pref.VersionsKeepLast = versionsKeepLast
+	pref.VersionsKeepDays = versionsKeepDays
 
 	return pref, nil
 }
 
+// SavePreferences saves every one of the user's preferences into the Store.
+func SavePreferences(s store.Store, pref Preferences) error {
+	values := map[string]string{
+		"directory":               pref.Directory,
+		"schedule":                pref.Schedule.String(),
+		"quietHours":              pref.QuietHours.String(),
+		"incrementalSync":         strconv.FormatBool(pref.IncrementalSync),
+		"extractArchives":         strconv.FormatBool(pref.ExtractArchives),
+		"logLevel":                pref.LogLevel,
+		"mirrorDeletions":         strconv.FormatBool(pref.MirrorDeletions),
+		"trashRetentionDays":      fmt.Sprint(pref.TrashRetentionDays),
+		"conflictPolicy":          pref.ConflictPolicy,
+		"collisionPolicy":         pref.CollisionPolicy,
+		"moduleCollisionPolicies": FormatModuleCollisionPolicies(pref.ModuleCollisionPolicies),
+		"versionsKeepLast":        fmt.Sprint(pref.VersionsKeepLast),
+		"versionsKeepDays":        fmt.Sprint(pref.VersionsKeepDays),
+	}
+
+	for key, value := range values {
+		if putErr := s.Put(PREFERENCES_BUCKET_NAME, key, []byte(value)); putErr != nil {
+			return putErr
+		}
+	}
+
+	return nil
+}
+
 // SaveRootSyncDirectory saves the user's root sync directory locally.
 func SaveRootSyncDirectory(directory string) error {
-	dbInstance := app.GetDBInstance()
-
-	updateErr := dbInstance.Update(func(tx *bolt.Tx) error {
-		err := tx.Bucket([]byte("Preferences")).Put([]byte("directory"), []byte(directory))
-		return err
-	})
+	return savePreference("directory", directory)
+}
 
-	return updateErr
+// SaveSchedule saves when the user wants automatic syncs to run locally.
+func SaveSchedule(syncSchedule schedule.Schedule) error {
+	return savePreference("schedule", syncSchedule.String())
 }
 
-// SaveSyncFrequency saves the user's sync frequency locally.
-func SaveSyncFrequency(frequency int) error {
-	dbInstance := app.GetDBInstance()
+// SaveQuietHours saves the time of the day during which the user wants no sync or notification to run locally.
+func SaveQuietHours(quietHours schedule.QuietHours) error {
+	return savePreference("quietHours", quietHours.String())
+}
 
-	updateErr := dbInstance.Update(func(tx *bolt.Tx) error {
-		err := tx.Bucket([]byte("Preferences")).Put([]byte("frequency"), []byte(fmt.Sprint(frequency)))
-		return err
-	})
+// SaveIncrementalSync saves whether the user wants syncs to only retrieve the files that changed
+// since the last sync locally.
+func SaveIncrementalSync(incrementalSync bool) error {
+	return savePreference("incrementalSync", strconv.FormatBool(incrementalSync))
+}
 
-	return updateErr
+// SaveExtractArchives saves whether the user wants downloaded archives to be extracted locally.
+func SaveExtractArchives(extractArchives bool) error {
+	return savePreference("extractArchives", strconv.FormatBool(extractArchives))
 }
 
 // SaveDebugMode saves the user's chosen debug mode locally.
 func SaveDebugMode(logLevel string) error {
-	dbInstance := app.GetDBInstance()
+	return savePreference("logLevel", logLevel)
+}
 
-	updateErr := dbInstance.Update(func(tx *bolt.Tx) error {
-		err := tx.Bucket([]byte("Preferences")).Put([]byte("logLevel"), []byte(logLevel))
+// SaveMirrorDeletions saves whether the user wants files deleted remotely to be moved
+// into the trash folder locally.
+func SaveMirrorDeletions(mirrorDeletions bool) error {
+	return savePreference("mirrorDeletions", strconv.FormatBool(mirrorDeletions))
+}
+
+// SaveTrashRetentionDays saves the number of days the user wants trashed files to be kept locally.
+func SaveTrashRetentionDays(trashRetentionDays int) error {
+	return savePreference("trashRetentionDays", fmt.Sprint(trashRetentionDays))
+}
+
+// SaveConflictPolicy saves what the user wants to happen when a locally modified file is updated remotely.
+func SaveConflictPolicy(conflictPolicy string) error {
+	return savePreference("conflictPolicy", conflictPolicy)
+}
+
+// SaveCollisionPolicy saves what the user wants to happen when a file not downloaded by Lominus
+// is in the way of a download.
+func SaveCollisionPolicy(collisionPolicy string) error {
+	return savePreference("collisionPolicy", collisionPolicy)
+}
+
+// SaveModuleCollisionPolicies saves the collision policies the user wants for specific modules,
+// keyed by module code.
+func SaveModuleCollisionPolicies(moduleCollisionPolicies map[string]string) error {
+	return savePreference("moduleCollisionPolicies", FormatModuleCollisionPolicies(moduleCollisionPolicies))
+}
+
+// SaveVersionsRetention saves how many superseded versions of every file the user wants to keep,
+// and for how many days.
+func SaveVersionsRetention(keepLast int, keepDays int) error {
+	if err := savePreference("versionsKeepLast", fmt.Sprint(keepLast)); err != nil {
 		return err
-	})
+	}
+
+	return savePreference("versionsKeepDays", fmt.Sprint(keepDays))
+}
+
+// savePreference is a helper function that saves the value of the preference locally.
+func savePreference(key string, value string) error {
+	return app.GetStore().Put(PREFERENCES_BUCKET_NAME, key, []byte(value))
+}
+
+// GetCollisionPolicy returns the collision policy for the module, which is the one set for the module
+// if any, or else the global one.
+func (pref Preferences) GetCollisionPolicy(moduleCode string) string {
+	for module, policy := range pref.ModuleCollisionPolicies {
+		if strings.EqualFold(module, moduleCode) {
+			return policy
+		}
+	}
+
+	return pref.CollisionPolicy
+}
+
+// ParseModuleCollisionPolicies parses collision policies for specific modules, written as
+// comma separated pairs of module code and policy, eg. "CS2040=skip, MA1521=overwrite".
+func ParseModuleCollisionPolicies(s string) (map[string]string, error) {
+	moduleCollisionPolicies := map[string]string{}
+
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+
+		module, policy, found := strings.Cut(pair, "=")
+		module, policy = strings.TrimSpace(module), strings.TrimSpace(policy)
+		if !found || module == "" {
+			return nil, fmt.Errorf("invalid module collision policy %q, expected MODULE=policy", strings.TrimSpace(pair))
+		}
+
+		if !slices.Contains(CollisionPolicies, policy) {
+			return nil, fmt.Errorf("unknown collision policy %q, expected one of %s", policy, strings.Join(CollisionPolicies, ", "))
+		}
+
+		moduleCollisionPolicies[module] = policy
+	}
+
+	return moduleCollisionPolicies, nil
+}
+
+// FormatModuleCollisionPolicies formats collision policies for specific modules such that they can be
+// parsed by ParseModuleCollisionPolicies. The modules are sorted.
+func FormatModuleCollisionPolicies(moduleCollisionPolicies map[string]string) string {
+	modules := []string{}
+	for module := range moduleCollisionPolicies {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	pairs := []string{}
+	for _, module := range modules {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", module, moduleCollisionPolicies[module]))
+	}
 
-	return updateErr
+	return strings.Join(pairs, ", ")
 }
@@ -0,0 +1,244 @@
+// Package appSecrets provides primitives to encrypt the user's secrets, such as the Canvas API token,
+// before they are stored locally, with the key file in the Lominus folder or a passphrase in PASSPHRASE_ENV.
+package appSecrets
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	appDir "github.com/beebeeoii/lominus/internal/app/dir"
+	"github.com/beebeeoii/lominus/internal/secrets"
+	"github.com/beebeeoii/lominus/pkg/auth"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
+	"github.com/boltdb/bolt"
+)
+
+// SECRETS_BUCKET_NAME is the name of the bucket the key source, CHECK_VALUE encrypted and the salt are stored in.
+const SECRETS_BUCKET_NAME = "Secrets"
+
+func init() {
+	app.RegisterBuckets(SECRETS_BUCKET_NAME)
+}
+
+// PASSPHRASE_ENV is the environment variable that holds the passphrase secrets are encrypted with.
+// If it is not set, secrets are encrypted with the key in the key file instead. See the secrets package.
+// The passphrase is deliberately only read from the environment, as there is no UI to enter it: scheduled
+// syncs start before any window is shown, and must not wait for the user.
+const PASSPHRASE_ENV = "LOMINUS_PASSPHRASE"
+
+// Key sources
+const (
+	// KEY_SOURCE_KEY_FILE encrypts secrets with the key in secrets.KEY_FILE_NAME in the Lominus folder.
+	KEY_SOURCE_KEY_FILE = "keyFile"
+	// KEY_SOURCE_PASSPHRASE encrypts secrets with a key derived from the passphrase in PASSPHRASE_ENV.
+	KEY_SOURCE_PASSPHRASE = "passphrase"
+)
+
+// CHECK_VALUE is encrypted and stored to check whether the key is the one secrets were encrypted with.
+const CHECK_VALUE = "lominus"
+
+// secretLocation struct describes where a secret is stored: its bucket and key.
+type secretLocation struct {
+	bucket string
+	key    string
+}
+
+// secretLocations lists where every secret is stored.
+var secretLocations = []secretLocation{
+	{bucket: auth.CANVAS_CREDENTIALS_BUCKET, key: auth.CANVAS_TOKEN_KEY},
+	{bucket: telegram.TELEGRAM_BUCKET, key: telegram.TELEGRAM_BOT_ID_KEY},
+}
+
+var cipherMutex sync.Mutex
+var currentCipher *secrets.Cipher
+
+// Init sets up the key secrets are encrypted with and encrypts the secrets stored in plaintext by earlier
+// versions of Lominus. If PASSPHRASE_ENV is set for the first time, the secrets encrypted with the key file
+// are encrypted with the passphrase instead.
+// secrets.ErrWrongKey is returned if the key is not the one the stored secrets were encrypted with.
+func Init() error {
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
+	return initCipher()
+}
+
+// Encrypt encrypts the secret such that it can be stored.
+func Encrypt(secret string) (string, error) {
+	cipher, cipherErr := getCipher()
+	if cipherErr != nil {
+		return "", cipherErr
+	}
+
+	return cipher.Encrypt(secret)
+}
+
+// Decrypt decrypts a secret encrypted by Encrypt. Secrets stored in plaintext are returned as they are.
+func Decrypt(secret string) (string, error) {
+	if !secrets.IsEncrypted(secret) {
+		return secret, nil
+	}
+
+	cipher, cipherErr := getCipher()
+	if cipherErr != nil {
+		return "", cipherErr
+	}
+
+	return cipher.Decrypt(secret)
+}
+
+// getCipher is a helper function that returns the cipher secrets are encrypted with, setting it up first
+// if Init has not been called.
+func getCipher() (*secrets.Cipher, error) {
+	cipherMutex.Lock()
+	defer cipherMutex.Unlock()
+
+	if currentCipher == nil {
+		if initErr := initCipher(); initErr != nil {
+			return nil, initErr
+		}
+	}
+
+	return currentCipher, nil
+}
+
+// initCipher is a helper function that carries out Init. cipherMutex must be held.
+func initCipher() error {
+	dbInstance := app.GetDBInstance()
+
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		secretsBucket, secretsBucketErr := tx.CreateBucketIfNotExists([]byte(SECRETS_BUCKET_NAME))
+		if secretsBucketErr != nil {
+			return secretsBucketErr
+		}
+
+		keySource := KEY_SOURCE_KEY_FILE
+		if os.Getenv(PASSPHRASE_ENV) != "" {
+			keySource = KEY_SOURCE_PASSPHRASE
+		}
+
+		cipher, cipherErr := newCipher(secretsBucket, keySource)
+		if cipherErr != nil {
+			return cipherErr
+		}
+
+		// The secrets are decrypted with the key they were encrypted with, which is another one if the key source changed.
+		previousCipher := cipher
+		previousKeySource := string(secretsBucket.Get([]byte("keySource")))
+		check := string(secretsBucket.Get([]byte("check")))
+		if check != "" && previousKeySource != keySource {
+			if previousKeySource != KEY_SOURCE_KEY_FILE {
+				return fmt.Errorf("secrets are encrypted with a passphrase - set %s to start Lominus", PASSPHRASE_ENV)
+			}
+
+			var previousCipherErr error
+			previousCipher, previousCipherErr = newCipher(secretsBucket, previousKeySource)
+			if previousCipherErr != nil {
+				return previousCipherErr
+			}
+		}
+
+		if check != "" {
+			checkValue, checkErr := previousCipher.Decrypt(check)
+			if checkErr != nil || checkValue != CHECK_VALUE {
+				if hasEncryptedSecrets(tx) {
+					return secrets.ErrWrongKey
+				}
+
+				// Without encrypted secrets, nothing is lost by starting over with the key.
+				previousCipher = cipher
+			}
+		}
+
+		for _, location := range secretLocations {
+			bucket := tx.Bucket([]byte(location.bucket))
+			if bucket == nil {
+				continue
+			}
+
+			value := string(bucket.Get([]byte(location.key)))
+			if secrets.IsEncrypted(value) && previousCipher == cipher {
+				continue
+			}
+
+			secret, decryptErr := previousCipher.Decrypt(value)
+			if decryptErr != nil {
+				return decryptErr
+			}
+
+			encrypted, encryptErr := cipher.Encrypt(secret)
+			if encryptErr != nil {
+				return encryptErr
+			}
+
+			if putErr := bucket.Put([]byte(location.key), []byte(encrypted)); putErr != nil {
+				return putErr
+			}
+		}
+
+		newCheck, encryptErr := cipher.Encrypt(CHECK_VALUE)
+		if encryptErr != nil {
+			return encryptErr
+		}
+
+		err := secretsBucket.Put([]byte("check"), []byte(newCheck))
+		err1 := secretsBucket.Put([]byte("keySource"), []byte(keySource))
+		if err != nil {
+			return err
+		}
+		if err1 != nil {
+			return err1
+		}
+
+		currentCipher = cipher
+		return nil
+	})
+}
+
+// newCipher is a helper function that creates the cipher for the key source. The salt of keys derived
+// from passphrases is created and stored in secretsBucket the first time.
+func newCipher(secretsBucket *bolt.Bucket, keySource string) (*secrets.Cipher, error) {
+	if keySource == KEY_SOURCE_PASSPHRASE {
+		salt := secretsBucket.Get([]byte("salt"))
+		if salt == nil {
+			newSalt, saltErr := secrets.NewSalt()
+			if saltErr != nil {
+				return nil, saltErr
+			}
+
+			if putErr := secretsBucket.Put([]byte("salt"), newSalt); putErr != nil {
+				return nil, putErr
+			}
+			salt = newSalt
+		}
+
+		key, keyErr := secrets.DeriveKey(os.Getenv(PASSPHRASE_ENV), salt)
+		if keyErr != nil {
+			return nil, keyErr
+		}
+
+		return secrets.NewCipher(key)
+	}
+
+	baseDir, retrieveBaseDirErr := appDir.GetBaseDir()
+	if retrieveBaseDirErr != nil {
+		return nil, retrieveBaseDirErr
+	}
+
+	return secrets.NewKeyFileCipher(filepath.Join(baseDir, secrets.KEY_FILE_NAME), true)
+}
+
+// hasEncryptedSecrets is a helper function that checks whether any secret is stored encrypted.
+func hasEncryptedSecrets(tx *bolt.Tx) bool {
+	for _, location := range secretLocations {
+		bucket := tx.Bucket([]byte(location.bucket))
+		if bucket != nil && secrets.IsEncrypted(string(bucket.Get([]byte(location.key)))) {
+			return true
+		}
+	}
+
+	return false
+}
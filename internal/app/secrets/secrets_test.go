@@ -0,0 +1,114 @@
+package appSecrets
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	"github.com/beebeeoii/lominus/internal/secrets"
+	"github.com/beebeeoii/lominus/pkg/auth"
+	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithTestDB(m))
+}
+
+func TestInitEncryptsPlaintextSecrets(t *testing.T) {
+	// Secrets stored in plaintext by earlier versions of Lominus
+	if err := (auth.CanvasCredentials{CanvasApiToken: "token"}).SaveTo(app.GetStore()); err != nil {
+		t.Fatal(err)
+	}
+	if err := telegram.SaveTelegramInfo(app.GetStore(), telegram.TelegramInfo{BotApi: "bot", UserId: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	assertSecrets(t, "token", "bot")
+
+	telegramInfo, _ := telegram.LoadTelegramInfo(app.GetStore())
+	if telegramInfo.UserId != "user" {
+		t.Errorf("UserId = %q, want it kept in plaintext", telegramInfo.UserId)
+	}
+
+	// Secrets already encrypted are left as they are.
+	credentials, _ := auth.LoadCanvasCredentials(app.GetStore())
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := auth.LoadCanvasCredentials(app.GetStore()); again != credentials {
+		t.Errorf("Init() encrypted the token again, want it kept")
+	}
+
+	// Setting a passphrase encrypts the secrets with it instead.
+	t.Setenv(PASSPHRASE_ENV, "passphrase")
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	assertSecrets(t, "token", "bot")
+
+	t.Setenv(PASSPHRASE_ENV, "wrong")
+	if err := Init(); !errors.Is(err, secrets.ErrWrongKey) {
+		t.Errorf("Init() with the wrong passphrase = %v, want ErrWrongKey", err)
+	}
+
+	os.Unsetenv(PASSPHRASE_ENV)
+	if err := Init(); err == nil {
+		t.Errorf("Init() without the passphrase = nil, want an error")
+	}
+
+	t.Setenv(PASSPHRASE_ENV, "passphrase")
+	if err := Init(); err != nil {
+		t.Fatal(err)
+	}
+	assertSecrets(t, "token", "bot")
+}
+
+// assertSecrets is a helper function that fails the test if the Canvas API token and Telegram bot token
+// are not stored encrypted, or do not decrypt to canvasToken and botId.
+func assertSecrets(t *testing.T, canvasToken string, botId string) {
+	t.Helper()
+
+	credentials, credentialsErr := auth.LoadCanvasCredentials(app.GetStore())
+	if credentialsErr != nil {
+		t.Fatal(credentialsErr)
+	}
+	telegramInfo, telegramErr := telegram.LoadTelegramInfo(app.GetStore())
+	if telegramErr != nil {
+		t.Fatal(telegramErr)
+	}
+
+	for stored, want := range map[string]string{credentials.CanvasApiToken: canvasToken, telegramInfo.BotApi: botId} {
+		if !secrets.IsEncrypted(stored) {
+			t.Errorf("stored %q, want it encrypted", stored)
+		}
+
+		if decrypted, err := Decrypt(stored); err != nil || decrypted != want {
+			t.Errorf("Decrypt() = %q, %v, want %q", decrypted, err, want)
+		}
+	}
+}
+
+// runWithTestDB is a helper function that runs the tests with the Lominus folder in a temporary folder.
+func runWithTestDB(m *testing.M) int {
+	configDir, tempErr := os.MkdirTemp("", "lominus-secrets")
+	if tempErr != nil {
+		panic(tempErr)
+	}
+	defer os.RemoveAll(configDir)
+
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		os.Setenv(env, configDir)
+	}
+
+	db, initErr := app.Init()
+	if initErr != nil {
+		panic(initErr)
+	}
+	defer db.Close()
+
+	return m.Run()
+}
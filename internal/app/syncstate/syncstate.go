@@ -0,0 +1,58 @@
+// Package appSyncState provides retrievers for what Lominus remembers between syncs to only
+// retrieve the files that changed remotely.
+package appSyncState
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/beebeeoii/lominus/internal/app"
+)
+
+// SyncState struct describes what Lominus remembers between syncs.
+// LastFullSync describes when the files of every module were last listed completely.
+// HighWaterMarks describes, for every module code, the time of the most recent remote change
+// that has been synced.
+type SyncState struct {
+	LastFullSync   time.Time
+	HighWaterMarks map[string]time.Time
+}
+
+// SYNC_STATE_BUCKET_NAME is the name of the bucket the SyncState is stored in.
+const SYNC_STATE_BUCKET_NAME = "SyncState"
+
+func init() {
+	app.RegisterBuckets(SYNC_STATE_BUCKET_NAME)
+}
+
+// GetSyncState returns what Lominus remembers between syncs.
+func GetSyncState() (SyncState, error) {
+	state := SyncState{HighWaterMarks: map[string]time.Time{}}
+
+	stateData, getErr := app.GetStore().Get(SYNC_STATE_BUCKET_NAME, "state")
+	if getErr != nil {
+		return SyncState{}, getErr
+	}
+
+	if stateData != nil {
+		if unmarshalErr := json.Unmarshal(stateData, &state); unmarshalErr != nil {
+			return SyncState{}, unmarshalErr
+		}
+	}
+
+	if state.HighWaterMarks == nil {
+		state.HighWaterMarks = map[string]time.Time{}
+	}
+
+	return state, nil
+}
+
+// SaveSyncState saves what Lominus remembers between syncs locally.
+func SaveSyncState(state SyncState) error {
+	stateData, marshalErr := json.Marshal(state)
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	return app.GetStore().Put(SYNC_STATE_BUCKET_NAME, "state", stateData)
+}
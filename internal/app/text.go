@@ -0,0 +1,17 @@
+package app
+
+// GetText returns the text saved with the key in the bucket, such as the user's filter rules,
+// or an empty string if there is none.
+func GetText(bucket string, key string) (string, error) {
+	value, err := GetStore().Get(bucket, key)
+	if err != nil {
+		return "", err
+	}
+
+	return string(value), nil
+}
+
+// SaveText saves the text with the key in the bucket locally.
+func SaveText(bucket string, key string, text string) error {
+	return GetStore().Put(bucket, key, []byte(text))
+}
@@ -9,3 +9,23 @@ const LOCK_FILE_NAME = "lominus.lock"
 const DATABASE_FILE_NAME = "lominus.db"
 
 const LOG_FILE_NAME = "lominus.log"
+
+const TRASH_DIR_NAME = ".lominus-trash"
+
+const VERSIONS_DIR_NAME = ".versions"
+
+const CACHE_DIR_NAME = "cache"
+
+const EXTRACT_MANIFEST_NAME = ".lominus-extracted.json"
+
+// DOWNLOAD_TEMP_SUFFIX is the suffix of the hidden files downloads are written to before they complete.
+const DOWNLOAD_TEMP_SUFFIX = ".part"
+
+// EXTRACT_TEMP_DIR_PREFIX is the prefix of the hidden folders archives are extracted into before they complete.
+const EXTRACT_TEMP_DIR_PREFIX = ".lominus-extract-"
+
+const CONFIG_FILE_NAME = "config.toml"
+
+const LEGACY_CREDENTIALS_FILE_NAME = "credentials.gob"
+
+const LEGACY_TELEGRAM_FILE_NAME = "telegram.gob"
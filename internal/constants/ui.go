@@ -23,20 +23,84 @@ const (
 	FILE_DIRECTORY_SELECT_DIRECTORY_TEXT = "Choose folder"
 	FILE_DIRECTORY_CHOOSE_LOCATION_TEXT  = "Choose"
 
-	SYNC_TAB_TITLE             = "Sync"
-	SYNC_TAB_DESCRIPTION       = "Sync files and more **automatically** at the frequency specified below."
-	SYNC_FREQUENCY_DISABLED    = "Disabled"
-	SYNC_FREQUENCY_ONE_HOUR    = "1 hour"
-	SYNC_FREQUENCY_TWO_HOUR    = "2 hour"
-	SYNC_FREQUENCY_FOUR_HOUR   = "4 hour"
-	SYNC_FREQUENCY_SIX_HOUR    = "6 hour"
-	SYNC_FREQUENCY_TWELVE_HOUR = "12 hour"
+	SYNC_TAB_TITLE              = "Sync"
+	SYNC_TAB_DESCRIPTION        = "Sync files and more **automatically** according to the schedule specified below. No scheduled sync or notification runs during quiet hours."
+	SYNC_SCHEDULE_DISABLED      = "Disabled"
+	SYNC_SCHEDULE_EVERY         = "Every"
+	SYNC_SCHEDULE_CRON          = "Cron expression"
+	SYNC_SCHEDULE_DAILY         = "Daily at"
+	SYNC_SCHEDULE_EVERY_HINT    = "Interval, eg. 30m or 2h"
+	SYNC_SCHEDULE_CRON_HINT     = "Cron expression, eg. 0 9 * * 1-5"
+	SYNC_SCHEDULE_DAILY_HINT    = "Times, eg. 08:00;18:30"
+	EXTRACT_ARCHIVES_TITLE      = "Extract downloaded zip and tar.gz archives into a folder next to them"
+	INCREMENTAL_SYNC_TITLE      = "Only check for files changed since the last sync (every file is still checked daily)"
+	QUIET_HOURS_TEXT            = "Quiet hours"
+	QUIET_HOURS_PLACEHOLDER     = "eg. 23:00-07:00, leave empty for none"
+	SAVE_SCHEDULE_TEXT          = "Save Schedule"
+	SCHEDULE_SAVED_MESSAGE      = "Schedule saved."
+	SCHEDULE_INVALID_MESSAGE    = "Invalid schedule, %s"
+	QUIET_HOURS_INVALID_MESSAGE = "Invalid quiet hours, %s"
+
+	DELETIONS_TAB_TITLE         = "Remote Deletions"
+	DELETIONS_TAB_DESCRIPTION   = "Move files that were downloaded by Lominus but are no longer on Canvas into the `.lominus-trash` folder. Files are never deleted until the retention period below is over."
+	MIRROR_DELETIONS_TITLE      = "Mirror remote deletions"
+	TRASH_RETENTION_SEVEN_DAYS  = "Keep trashed files for 7 days"
+	TRASH_RETENTION_THIRTY_DAYS = "Keep trashed files for 30 days"
+	TRASH_RETENTION_NINETY_DAYS = "Keep trashed files for 90 days"
+	TRASH_RETENTION_FOREVER     = "Keep trashed files forever"
+	VIEW_TRASH_TEXT             = "View Trash"
+	TRASH_TITLE                 = "Trash"
+	TRASH_EMPTY_MESSAGE         = "There are no files in the trash."
+	RESTORE_TEXT                = "Restore"
+	RESTORE_SUCCESSFUL_MESSAGE  = "%s has been restored."
+	RESTORE_FAILED_MESSAGE      = "Unable to restore %s. Please ensure that no file exists at its original location."
+
+	CONFLICTS_TAB_TITLE       = "Local Modifications"
+	CONFLICTS_TAB_DESCRIPTION = "Choose what happens when a file you modified locally, such as an annotated PDF, is updated on Canvas."
+	CONFLICT_KEEP_BOTH_TEXT   = "Keep both, renaming my copy"
+	CONFLICT_SKIP_TEXT        = "Keep my copy, skip the update"
+	CONFLICT_OVERWRITE_TEXT   = "Overwrite my copy with the update"
+
+	COLLISIONS_TAB_TITLE                      = "Existing Files"
+	COLLISIONS_TAB_DESCRIPTION                = "Choose what happens when a file that was not downloaded by Lominus, such as one you put there, is in the way of a download. Modules can be set to a different policy, eg. `CS2040=skip, MA1521=overwrite`."
+	COLLISION_RENAME_OLD_TEXT                 = "Rename the existing file"
+	COLLISION_RENAME_NEW_TEXT                 = "Rename the downloaded file"
+	COLLISION_OVERWRITE_TEXT                  = "Overwrite the existing file"
+	COLLISION_SKIP_TEXT                       = "Keep the existing file, skip the download"
+	MODULE_COLLISION_POLICIES_TEXT            = "Per module"
+	MODULE_COLLISION_POLICIES_PLACEHOLDER     = "eg. CS2040=skip, leave empty for none"
+	SAVE_MODULE_COLLISION_POLICIES_TEXT       = "Save Module Policies"
+	MODULE_COLLISION_POLICIES_SAVED_MESSAGE   = "Module policies saved."
+	MODULE_COLLISION_POLICIES_INVALID_MESSAGE = "Invalid module policies, %s"
+
+	VERSIONS_TAB_TITLE                 = "Versions"
+	VERSIONS_TAB_DESCRIPTION           = "When a file is updated, its previous version is kept in the `.versions` folder next to it."
+	VERSIONS_KEEP_LAST_TEXT            = "Keep last %d versions"
+	VERSIONS_KEEP_ALL_TEXT             = "Keep all versions"
+	VERSIONS_KEEP_DAYS_TEXT            = "Keep versions for %d days"
+	VERSIONS_KEEP_FOREVER_TEXT         = "Keep versions forever"
+	BROWSE_VERSIONS_TEXT               = "Browse Versions"
+	NO_VERSIONS_MESSAGE                = "There are no previous versions of %s."
+	RESTORE_VERSION_SUCCESSFUL_MESSAGE = "%s has been restored to the version from %s."
+	RESTORE_VERSION_FAILED_MESSAGE     = "Unable to restore %s."
+
+	CONFIG_TITLE                  = "Configuration File"
+	CONFIG_DESCRIPTION            = "Export your preferences, filters, hooks and integrations to a TOML or JSON file, and import them on another computer. Settings in %s are applied every time Lominus starts."
+	EXPORT_CONFIG_TEXT            = "Export Configuration"
+	IMPORT_CONFIG_TEXT            = "Import Configuration"
+	EXPORT_CONFIG_SECRETS_MESSAGE = "Include your Canvas API token and Telegram bot token in the file? Anyone with the file will be able to access your Canvas account."
+	CONFIG_EXPORTED_MESSAGE       = "Configuration exported to %s."
+	CONFIG_IMPORTED_MESSAGE       = "Configuration imported. Please restart Lominus for changes to take place."
+	CONFIG_IMPORT_FAILED_MESSAGE  = "Unable to import configuration, %s"
 
 	ADVANCED_TAB_TITLE                 = "Advanced"
 	DEBUG_CHECKBOX_TITLE               = "Debug Mode"
 	DEBUG_CHECKBOX_W_LINK_DESCRIPTION  = "Debug mode enables extensive logging to the [logfile](<%s>)."
 	DEBUG_CHECKBOX_WO_LINK_DESCRIPTION = "Debug mode enables extensive logging to the logfile."
 	DEBUG_TOGGLE_SUCCESSFUL_MESSAGE    = "Please restart Lominus for changes to take place."
+	CLEAR_CACHE_DESCRIPTION            = "Folder and file listings from Canvas are cached to only transfer them again when they change. Clear the cache if your files seem out of date."
+	CLEAR_CACHE_TEXT                   = "Clear Cache"
+	CACHE_CLEARED_MESSAGE              = "Cache cleared."
 
 	PREFERENCES_FAILED_MESSAGE = "An error has occurred :( Please try again"
 
@@ -55,11 +119,58 @@ const (
 	TELEGRAM_TESTING_FAILED_MESSAGE     = "Telegram integration failed.\nPlease ensure that you have chatted with your bot before."
 	SAVE_TELEGRAM_DATA_TEXT             = "Save Telegram Info"
 
+	// Filters Tab
+	FILTERS_TITLE           = "Filters"
+	FILTERS_DESCRIPTION     = "Skip files during syncs. One rule per line: `<include|exclude> <glob|ext|larger|before> <value>`. The first matching rule wins. Rules below a `[MODULECODE]` line apply to that module only."
+	FILTERS_PLACEHOLDER     = "exclude ext mp4\nexclude glob ~$*\nexclude larger 100MB\n\n[CS2040]\nexclude before 2024-01-01"
+	SAVE_FILTERS_TEXT       = "Save Filters"
+	FILTERS_SAVED_MESSAGE   = "Filters saved."
+	FILTERS_INVALID_MESSAGE = "Invalid filter rule, %s"
+
+	HOOKS_TITLE           = "Hooks"
+	HOOKS_DESCRIPTION     = "Run commands when files are synced. One hook per line: `on <downloaded|updated|finished> [glob <pattern>] [timeout <duration>] do <command>`. Hooks below a `[MODULECODE]` line run for that module only. Commands receive the file in `LOMINUS_FILE`, its module in `LOMINUS_MODULE` and its Canvas details in other `LOMINUS_` variables. Their output is written to the log."
+	HOOKS_PLACEHOLDER     = "on downloaded glob *.pptx timeout 2m do soffice --headless --convert-to pdf --outdir \"$LOMINUS_DIR\" \"$LOMINUS_FILE\"\n\n[CS2040]\non updated do echo \"$LOMINUS_KEY\" >> updated.txt"
+	SAVE_HOOKS_TEXT       = "Save Hooks"
+	HOOKS_SAVED_MESSAGE   = "Hooks saved."
+	HOOKS_INVALID_MESSAGE = "Invalid hook, %s"
+
+	// Search Tab
+	SEARCH_TITLE               = "Search"
+	SEARCH_DESCRIPTION         = "Search the text of synced PDF, DOCX, PPTX, TXT and MD files. The index is updated after every sync. Select a result to open the file."
+	SEARCH_PLACEHOLDER         = "dijkstra shortest path"
+	SEARCH_TEXT                = "Search"
+	UPDATE_SEARCH_INDEX_TEXT   = "Update Index"
+	SEARCH_INDEX_UPDATING_TEXT = "Updating Index..."
+	SEARCH_INDEX_UPDATED_TEXT  = "Search index updated. %d files indexed."
+	SEARCH_NO_RESULTS_MESSAGE  = "No files found."
+	SEARCH_RESULT_TEXT         = "[%s] %s"
+
+	// History Tab
+	HISTORY_TITLE          = "History"
+	HISTORY_DESCRIPTION    = "The most recent syncs. Select a sync to see what happened to each file."
+	HISTORY_EMPTY_MESSAGE  = "No syncs yet."
+	HISTORY_RUN_TEXT       = "%s (%s) - %d added, %d updated, %d failed"
+	HISTORY_DETAILS_TEXT   = "Started: %s\nFinished: %s\nTrigger: %s\nModules: %s"
+	HISTORY_ERRORS_TEXT    = "Errors:"
+	HISTORY_FILES_TEXT     = "Files:"
+	HISTORY_NO_FILES_TEXT  = "No files were changed."
+	HISTORY_CANCELLED_TEXT = "This sync was cancelled before it finished."
+	REFRESH_TEXT           = "Refresh"
+
 	// General
 	NO_FOLDER_DIRECTORY_SELECTED = "Please select a folder to store your files: Preferences > Folder Directory"
-	NO_FREQUENCY_SELECTED        = "Please choose a sync frequency: Preferences > Sync"
 	CANCEL_TEXT                  = "Cancel"
+	CLOSE_TEXT                   = "Close"
 	SYNC_TEXT                    = "Sync"
+	SYNC_IN_PROGRESS_TEXT        = "Sync in progress..."
+	SYNC_IN_PROGRESS_TITLE       = "Sync in progress"
+	SYNC_IN_PROGRESS_MESSAGE     = "A sync is already in progress. Do you want to wait for it to finish, or cancel it and start a new sync?"
+	WAIT_TEXT                    = "Wait"
+	CANCEL_AND_RESTART_TEXT      = "Cancel and Restart"
+	PREVIEW_SYNC_TEXT            = "Preview Sync"
+	PREVIEW_SYNC_TITLE           = "Sync Preview"
+	PREVIEWING_SYNC_MESSAGE      = "Please wait while we compare your files..."
+	PREVIEW_SYNC_FAILED_MESSAGE  = "Unable to preview sync. Please check your credentials and try again."
 	QUIT_LOMINUS_TEXT            = "Quit Lominus"
 
 	DIALOG_PADDING = 30
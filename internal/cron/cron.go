@@ -2,20 +2,21 @@
 package cron
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
-	appAuth "github.com/beebeeoii/lominus/internal/app/auth"
+	appHistory "github.com/beebeeoii/lominus/internal/app/history"
+	appHooks "github.com/beebeeoii/lominus/internal/app/hooks"
 	appInt "github.com/beebeeoii/lominus/internal/app/integrations/telegram"
 	appPref "github.com/beebeeoii/lominus/internal/app/pref"
-	appFiles "github.com/beebeeoii/lominus/internal/file"
-	"github.com/beebeeoii/lominus/internal/indexing"
+	"github.com/beebeeoii/lominus/internal/hooks"
 	logs "github.com/beebeeoii/lominus/internal/log"
-	"github.com/beebeeoii/lominus/internal/notifications"
-	"github.com/beebeeoii/lominus/pkg/api"
-	"github.com/beebeeoii/lominus/pkg/constants"
+	"github.com/beebeeoii/lominus/internal/schedule"
+	"github.com/beebeeoii/lominus/internal/search"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
 	"github.com/beebeeoii/lominus/pkg/integrations/telegram"
 
 	"github.com/go-co-op/gocron"
@@ -24,10 +25,25 @@ import (
 var mainScheduler *gocron.Scheduler
 var mainJob *gocron.Job
 
-// Init initialises the cronjob with the desired frequency set by the user.
-// If frequency is unset, cronjob is not initialised.
+// activeSync struct describes the sync in progress. done is closed when the sync stops.
+type activeSync struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var syncMutex sync.Mutex
+var currentSync *activeSync
+var syncingListeners []func(bool)
+
+// hookRunner runs the user's hooks in the background, such that they do not hold up the syncs queueing them.
+var hookRunner = hooks.NewRunner()
+
+// Init initialises the cronjob with the schedule set by the user.
+// If automatic syncs are disabled, cronjob is not initialised.
 func Init() error {
 	mainScheduler = gocron.NewScheduler(time.Local)
+	// A scheduled run that is due while the previous one is still running is skipped.
+	mainScheduler.SingletonModeAll()
 
 	pref, err := appPref.GetPreferences()
 
@@ -35,11 +51,13 @@ func Init() error {
 		return err
 	}
 
-	if pref.Frequency == -1 {
+	if pref.Schedule.IsDisabled() {
 		return nil
 	}
 
-	job, err := createJob(pref.Directory, pref.Frequency)
+	// Interval schedules sync as soon as Lominus starts, as they always have.
+	startImmediately := pref.Schedule.Kind == schedule.KindEvery
+	job, err := createJob(pref.Directory, pref.Schedule, startImmediately)
 	if err != nil {
 		return err
 	}
@@ -50,15 +68,17 @@ func Init() error {
 	return err
 }
 
-// Rerun clears the job from the scheduler and reschedules the same job with the new frequency.
-func Rerun(rootSyncDirectory string, frequency int) error {
+// Reschedule clears the job from the scheduler and reschedules the same job with the new schedule,
+// without running it until it is due.
+func Reschedule(rootSyncDirectory string, syncSchedule schedule.Schedule) error {
 	mainScheduler.Clear()
+	mainJob = nil
 
-	if frequency == -1 {
+	if syncSchedule.IsDisabled() {
 		return nil
 	}
 
-	job, err := createJob(rootSyncDirectory, frequency)
+	job, err := createJob(rootSyncDirectory, syncSchedule, false)
 	if err != nil {
 		return err
 	}
@@ -69,181 +89,259 @@ func Rerun(rootSyncDirectory string, frequency int) error {
 	return nil
 }
 
+// SyncNow syncs the root sync directory in the background, regardless of the schedule and quiet hours.
+// The sync is recorded in the history as a manual run, and does not change when the next scheduled
+// run is due. If a sync is already in progress, it is joined instead.
+func SyncNow(rootSyncDirectory string) {
+	go runSync(rootSyncDirectory, appHistory.TRIGGER_MANUAL)
+}
+
 // GetNextRun returns the next time the cronjob would run.
+// It returns the zero time if automatic syncs are disabled.
 func GetNextRun() time.Time {
+	if mainJob == nil {
+		return time.Time{}
+	}
+
 	return mainJob.NextRun()
 }
 
 // GetLastRan returns the last time the cronjob ran.
+// It returns the zero time if automatic syncs are disabled.
 func GetLastRan() time.Time {
+	if mainJob == nil {
+		return time.Time{}
+	}
+
 	return mainJob.LastRun()
 }
 
-// createJob creates the cronjob that would run at the given frequency.
-// It returns a Job which can be used in the main scheduler.
-// This is where the bulk of the syncing logic lives.
-//
-// TODO Cleanup notifications - make it more user friendly. No point
-// putting technical logs in notifications.
-func createJob(rootSyncDirectory string, frequency int) (*gocron.Job, error) {
-	return mainScheduler.Every(frequency).Hours().Do(func() {
-		notifications.NotificationChannel <- notifications.Notification{Title: "Sync", Content: "Sync Started!"}
-
-		logs.Logger.Infof("job started: %s", time.Now().Format(time.RFC3339))
-
-		// If directory for file sync is not set, exit from job.
-		if rootSyncDirectory == "" {
-			logs.Logger.Infoln("Root sync directory not set. Exiting from cron job !")
-			return
-		}
+// IsSyncing returns whether a sync is in progress.
+func IsSyncing() bool {
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
 
-		canvasCredentials, credErr := appAuth.GetCanvasCredentials()
-		if credErr != nil {
-			logs.Logger.Warnln(credErr)
-		} else {
-			logs.Logger.Infoln("canvasCredentials access: successful")
-		}
+	return currentSync != nil
+}
 
-		telegramIds, tIdsErr := appInt.GetTelegramIds()
-		if tIdsErr != nil {
-			logs.Logger.Warnln(tIdsErr)
-		} else {
-			logs.Logger.Infoln("telegramIds access: successful")
-		}
+// CancelSync cancels the sync in progress, if any, and the hooks it queued, and waits for the sync to stop.
+func CancelSync() {
+	hookRunner.Cancel()
 
-		logs.Logger.Debugln("building - module request")
+	syncMutex.Lock()
+	if currentSync == nil {
+		syncMutex.Unlock()
+		return
+	}
 
-		canvasModules, canvasModErr := getModules(canvasCredentials.CanvasApiToken, constants.Canvas)
-		if canvasModErr != nil {
-			// TODO Somehow collate this error and display to user at the end
-			// notifications.NotificationChannel <- notifications.Notification{Title: "Sync", Content: canvasModErr.Error()}
-			logs.Logger.Warnln(canvasModErr)
-		}
+	logs.Logger.Infoln("cancelling sync in progress")
+	currentSync.cancel()
+	done := currentSync.done
+	syncMutex.Unlock()
 
-		logs.Logger.Debugln("building - index map")
-		currentFiles, currentFilesErr := indexing.Build(rootSyncDirectory)
-		if currentFilesErr != nil {
-			notifications.NotificationChannel <- notifications.Notification{Title: "Sync", Content: "Failed to get current downloaded files"}
-			logs.Logger.Errorln(currentFilesErr)
+	<-done
+}
+
+// SubscribeSyncing registers a listener that is called with true when a sync starts,
+// and with false when it stops.
+func SubscribeSyncing(listener func(bool)) {
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
+
+	syncingListeners = append(syncingListeners, listener)
+}
+
+// IsQuietHours returns whether it is currently within the user's quiet hours,
+// during which no scheduled sync or notification runs.
+func IsQuietHours() bool {
+	pref, prefErr := appPref.GetPreferences()
+	if prefErr != nil {
+		logs.Logger.Warnln(prefErr)
+		return false
+	}
+
+	return pref.QuietHours.Contains(time.Now())
+}
+
+// createJob creates the cronjob that would run according to the given schedule, and also immediately
+// if startImmediately is true. Runs are recorded in the history as scheduled runs, and are skipped during quiet hours.
+// It returns a Job which can be used in the main scheduler.
+func createJob(rootSyncDirectory string, syncSchedule schedule.Schedule, startImmediately bool) (*gocron.Job, error) {
+	var scheduler *gocron.Scheduler
+
+	switch syncSchedule.Kind {
+	case schedule.KindEvery:
+		scheduler = mainScheduler.Every(syncSchedule.Interval)
+	case schedule.KindCron:
+		scheduler = mainScheduler.Cron(syncSchedule.Cron)
+	case schedule.KindDaily:
+		scheduler = mainScheduler.Every(1).Day().At(syncSchedule.At())
+	default:
+		return nil, fmt.Errorf("unable to schedule %q", syncSchedule.String())
+	}
+
+	if startImmediately {
+		scheduler = scheduler.StartImmediately()
+	} else {
+		scheduler = scheduler.WaitForSchedule()
+	}
+
+	return scheduler.Do(func() {
+		if IsQuietHours() {
+			logs.Logger.Infoln("quiet hours - skipping scheduled sync")
 			return
 		}
 
-		lmsFiles := []api.File{}
-		for _, module := range canvasModules {
-			if !module.IsAccessible {
-				continue
-			}
-
-			moduleFolderReq, moduleFolderReqErr := api.BuildModuleFolderRequest(
-				canvasCredentials.CanvasApiToken,
-				module,
-			)
-
-			if moduleFolderReqErr != nil {
-				logs.Logger.Warnln(moduleFolderReqErr)
-			}
-
-			moduleFolder, moduleFolderErr := moduleFolderReq.GetModuleFolder()
-			if moduleFolderErr != nil {
-				logs.Logger.Warnln(moduleFolderErr)
-			}
-
-			foldersReq, foldersReqErr := api.BuildFoldersRequest(
-				canvasCredentials.CanvasApiToken,
-				constants.Canvas,
-				moduleFolder,
-			)
-			if foldersReqErr != nil {
-				logs.Logger.Warnln(foldersReqErr)
-			}
-
-			files, foldersErr := foldersReq.GetRootFiles()
-			if foldersErr != nil {
-				logs.Logger.Warnln(foldersErr)
-			}
-
-			lmsFiles = append(lmsFiles, files...)
-		}
+		runSync(rootSyncDirectory, appHistory.TRIGGER_SCHEDULED)
+	})
+}
 
-		nFilesToUpdate := 0
-		filesUpdated := []api.File{}
-
-		for _, file := range lmsFiles {
-			key := strings.ToLower(fmt.Sprintf("%s/%s", strings.Join(file.Ancestors, "/"), file.Name))
-			localLastUpdated := currentFiles[key].LastUpdated
-			platformLastUpdated := file.LastUpdated
-
-			if _, exists := currentFiles[key]; !exists || localLastUpdated.Before(platformLastUpdated) {
-				nFilesToUpdate += 1
-
-				logs.Logger.Debugf("downloading - %s [%s vs %s]", key, localLastUpdated.String(), platformLastUpdated.String())
-				fileDirSlice := append([]string{rootSyncDirectory}, file.Ancestors...)
-				filePath := filepath.Join(fileDirSlice...)
-				appFiles.EnsureDir(filePath)
-				downloadErr := file.Download(filePath)
-				if downloadErr != nil {
-					notifications.NotificationChannel <- notifications.Notification{Title: "Sync", Content: fmt.Sprintf("Unable to download file: %s", file.Name)}
-					logs.Logger.Warnln(downloadErr)
-					continue
-				}
-				filesUpdated = append(filesUpdated, file)
-			}
-		}
+// runSync carries out one sync of the root sync directory using the sync engine,
+// queues the user's hooks, updates the search index and records it in the history with the trigger.
+// Only one sync runs at a time: if a sync is already in progress, this run joins it instead.
+func runSync(rootSyncDirectory string, trigger string) {
+	logs.Logger.Infof("job started: %s", time.Now().Format(time.RFC3339))
 
-		if nFilesToUpdate > 0 && telegramIds.UserId != "" && telegramIds.BotId != "" {
-			nFilesUpdated := len(filesUpdated)
-			updatedFilesModulesNames := []string{}
-
-			// TODO Send one message per module instead of one message per file as there can be many files
-			for _, file := range filesUpdated {
-				message := telegram.GenerateFileUpdatedMessageFormat(file)
-				gradeMsgErr := telegram.SendMessage(telegramIds.BotId, telegramIds.UserId, message)
-
-				if gradeMsgErr != nil {
-					logs.Logger.Warnln(gradeMsgErr)
-					continue
-				}
-
-				updatedFilesModulesNames = append(updatedFilesModulesNames, fmt.Sprintf("[%s] %s ", file.Ancestors[0], file.Name))
-			}
-
-			var updatedFileNamesString string
-
-			if nFilesUpdated > 4 {
-				updatedFileNamesString = strings.Join(append(updatedFilesModulesNames[:3], "..."), "\n")
-			} else {
-				updatedFileNamesString = strings.Join(updatedFilesModulesNames, "\n")
-			}
-
-			notifications.NotificationChannel <- notifications.Notification{
-				Title:   fmt.Sprintf("Sync: %d/%d updated", nFilesUpdated, nFilesToUpdate),
-				Content: updatedFileNamesString,
-			}
-		} else {
-			notifications.NotificationChannel <- notifications.Notification{
-				Title:   "Sync",
-				Content: "Your files are up to date",
-			}
-		}
+	// If directory for file sync is not set, exit from job.
+	if rootSyncDirectory == "" {
+		logs.Logger.Infoln("Root sync directory not set. Exiting from cron job !")
+		return
+	}
+
+	ctx, started := startSync()
+	if !started {
+		logs.Logger.Infoln("sync already in progress - joining it")
+		return
+	}
+	defer finishSync()
+
+	syncer, syncerErr := appSync.NewDefaultSyncer(rootSyncDirectory)
+	if syncerErr != nil {
+		logs.Logger.Warnln(syncerErr)
+		return
+	}
+	logs.Logger.Infoln("canvasCredentials access: successful")
 
-		logs.Logger.Infof("job completed: %s", time.Now().Format(time.RFC3339))
+	syncer.Subscribe(notifyTelegram)
+	syncer.Subscribe(func(event appSync.Event) {
+		recordHistory(event, trigger)
 	})
+	syncer.Subscribe(func(event appSync.Event) {
+		updateSearchIndex(ctx, event, rootSyncDirectory)
+	})
+
+	userHooks, hooksErr := appHooks.GetHooks()
+	if hooksErr != nil {
+		logs.Logger.Warnln(hooksErr)
+	} else {
+		syncer.Subscribe(userHooks.Listener(hookRunner, rootSyncDirectory))
+	}
+	syncer.Run(ctx)
+
+	logs.Logger.Infof("job completed: %s", time.Now().Format(time.RFC3339))
 }
 
-// getModules is a helper function that retrieves Module objects based on the platform
-// passed in the arguments.
-func getModules(token string, platform constants.Platform) ([]api.Module, error) {
-	modules := []api.Module{}
+// startSync is a helper function that marks a sync as in progress, unless one already is.
+// It returns the context that is cancelled by CancelSync, and whether the sync was marked.
+func startSync() (context.Context, bool) {
+	syncMutex.Lock()
 
-	modulesRequest, modulesReqErr := api.BuildModulesRequest(token, platform)
-	if modulesReqErr != nil {
-		return modules, modulesReqErr
+	if currentSync != nil {
+		syncMutex.Unlock()
+		return nil, false
 	}
 
-	modules, modulesErr := modulesRequest.GetModules()
-	if modulesErr != nil {
-		return modules, modulesErr
+	ctx, cancel := context.WithCancel(context.Background())
+	currentSync = &activeSync{cancel: cancel, done: make(chan struct{})}
+	listeners := syncingListeners
+	syncMutex.Unlock()
+
+	notifySyncing(listeners, true)
+
+	return ctx, true
+}
+
+// finishSync is a helper function that marks the sync in progress as stopped.
+func finishSync() {
+	syncMutex.Lock()
+	stopped := currentSync
+	currentSync = nil
+	listeners := syncingListeners
+	syncMutex.Unlock()
+
+	stopped.cancel()
+	notifySyncing(listeners, false)
+	close(stopped.done)
+}
+
+// notifySyncing is a helper function that calls the syncing listeners.
+// It must be called without holding syncMutex, so that the listeners may call back into this package.
+func notifySyncing(listeners []func(bool), syncing bool) {
+	for _, listener := range listeners {
+		listener(syncing)
+	}
+}
+
+// recordHistory is a sync event listener that saves the outcome of the sync into the history.
+func recordHistory(event appSync.Event, trigger string) {
+	finished, ok := event.(appSync.SyncFinished)
+	if !ok {
+		return
 	}
 
-	return modules, nil
+	saveRunErr := appHistory.SaveRun(appHistory.NewRun(finished.Result, trigger, finished.Err))
+	if saveRunErr != nil {
+		logs.Logger.Errorln(saveRunErr)
+	}
+}
+
+// updateSearchIndex is a sync event listener that updates the search index with the files
+// added or changed in the root sync directory once the sync finishes. ctx is the context of the sync,
+// such that cancelling the sync stops the update too.
+func updateSearchIndex(ctx context.Context, event appSync.Event, rootSyncDirectory string) {
+	if _, ok := event.(appSync.SyncFinished); !ok {
+		return
+	}
+
+	_, updateErr := search.Update(ctx, rootSyncDirectory)
+	if errors.Is(updateErr, context.Canceled) {
+		logs.Logger.Infoln("search index update cancelled with the sync")
+	} else if updateErr != nil {
+		logs.Logger.Errorln(updateErr)
+	}
+}
+
+// notifyTelegram is a sync event listener that sends a Telegram message for every file
+// updated during the sync, if Telegram is integrated.
+func notifyTelegram(event appSync.Event) {
+	finished, ok := event.(appSync.SyncFinished)
+	if !ok || len(finished.Result.Downloaded) == 0 {
+		return
+	}
+
+	if IsQuietHours() {
+		logs.Logger.Infoln("quiet hours - skipping telegram notifications")
+		return
+	}
+
+	telegramIds, tIdsErr := appInt.GetTelegramIds()
+	if tIdsErr != nil {
+		logs.Logger.Warnln(tIdsErr)
+		return
+	}
+	logs.Logger.Infoln("telegramIds access: successful")
+
+	if telegramIds.UserId == "" || telegramIds.BotId == "" {
+		return
+	}
+
+	// TODO Send one message per module instead of one message per file as there can be many files
+	for _, entry := range finished.Result.Downloaded {
+		message := telegram.GenerateFileUpdatedMessageFormat(entry.File)
+		gradeMsgErr := telegram.SendMessage(telegramIds.BotId, telegramIds.UserId, message)
+
+		if gradeMsgErr != nil {
+			logs.Logger.Warnln(gradeMsgErr)
+		}
+	}
 }
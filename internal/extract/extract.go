@@ -0,0 +1,342 @@
+// Package extract provides primitives to extract downloaded archives, such as zip and tar.gz files,
+// into a folder next to them.
+package extract
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	logs "github.com/beebeeoii/lominus/internal/log"
+)
+
+// MAX_TOTAL_SIZE is the number of bytes an archive may extract to, guarding against archive bombs.
+const MAX_TOTAL_SIZE = 1 << 30
+
+// MAX_FILES is the number of files an archive may contain.
+const MAX_FILES = 10000
+
+// ARCHIVE_EXTENSIONS are the extensions of the archives that can be extracted, in lower case.
+var ARCHIVE_EXTENSIONS = []string{".zip", ".tar.gz", ".tgz"}
+
+// Manifest struct describes the files extracted from an archive, as paths relative to the folder
+// they were extracted into. It is used to remove the files that are no longer in an updated archive
+// without touching the files the user put in the folder.
+type Manifest struct {
+	Archive string
+	Files   []string
+}
+
+// ErrTooLarge is returned when an archive would extract to more than MAX_TOTAL_SIZE bytes or MAX_FILES files.
+var ErrTooLarge = errors.New("archive is too large to be extracted")
+
+// IsArchive checks whether the file is an archive that can be extracted, by its name.
+func IsArchive(fileName string) bool {
+	return getArchiveExt(fileName) != ""
+}
+
+// GetExtractDir returns the folder an archive is extracted into, which is next to it and named after it,
+// eg. CS2040/Week3_Materials.zip is extracted into CS2040/Week3_Materials.
+// If that folder exists but the archive was not extracted into it, such as a folder synced from Canvas
+// with the same name, the archive is extracted into CS2040/Week3_Materials (extracted) instead,
+// or CS2040/Week3_Materials (extracted 2) and so on if that is taken too.
+func GetExtractDir(archivePath string) string {
+	baseDir := archivePath[:len(archivePath)-len(getArchiveExt(archivePath))]
+
+	extractDir := baseDir
+	for n := 1; !isExtractDirOf(extractDir, archivePath); n++ {
+		if n == 1 {
+			extractDir = baseDir + " (extracted)"
+		} else {
+			extractDir = fmt.Sprintf("%s (extracted %d)", baseDir, n)
+		}
+	}
+
+	return extractDir
+}
+
+// Extract extracts the archive into the folder returned by GetExtractDir, replacing the files extracted
+// from it previously. Files extracted previously that are no longer in the archive are removed, while files
+// the user put in the folder are kept: entries that would replace them are skipped. Entries that would be
+// extracted outside the folder, such as ../../.bashrc, and entries that are not regular files or folders
+// are skipped too.
+// It returns the folder the archive was extracted into.
+func Extract(archivePath string) (string, error) {
+	if !IsArchive(archivePath) {
+		return "", fmt.Errorf("%s is not an archive", filepath.Base(archivePath))
+	}
+	extractDir := GetExtractDir(archivePath)
+
+	// The archive is extracted into a temporary folder first, such that a broken or oversized archive
+	// leaves the files extracted previously as they were.
+	tempDir, tempDirErr := os.MkdirTemp(filepath.Dir(archivePath), appConstants.EXTRACT_TEMP_DIR_PREFIX)
+	if tempDirErr != nil {
+		return "", tempDirErr
+	}
+	defer os.RemoveAll(tempDir)
+
+	var files []string
+	var extractErr error
+	if getArchiveExt(archivePath) == ".zip" {
+		files, extractErr = extractZip(archivePath, tempDir)
+	} else {
+		files, extractErr = extractTarGz(archivePath, tempDir)
+	}
+	if extractErr != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(archivePath), extractErr)
+	}
+
+	previous, _ := LoadManifest(extractDir)
+	if removeErr := removeStale(extractDir, previous.Files, files); removeErr != nil {
+		return "", removeErr
+	}
+
+	previouslyExtracted := map[string]bool{}
+	for _, file := range previous.Files {
+		previouslyExtracted[file] = true
+	}
+
+	extracted := []string{}
+	for _, file := range files {
+		targetPath := filepath.Join(extractDir, filepath.FromSlash(file))
+		if !previouslyExtracted[file] && appFiles.Exists(targetPath) {
+			logs.Logger.Warnf("%s - not extracted as it would replace %s", file, targetPath)
+			continue
+		}
+
+		if ensureDirErr := appFiles.EnsureDir(filepath.Dir(targetPath)); ensureDirErr != nil {
+			return "", ensureDirErr
+		}
+
+		if renameErr := os.Rename(filepath.Join(tempDir, filepath.FromSlash(file)), targetPath); renameErr != nil {
+			return "", renameErr
+		}
+		extracted = append(extracted, file)
+	}
+
+	return extractDir, saveManifest(extractDir, Manifest{Archive: filepath.Base(archivePath), Files: extracted})
+}
+
+// LoadManifest loads the manifest of the files extracted into the folder.
+func LoadManifest(extractDir string) (Manifest, error) {
+	manifest := Manifest{}
+
+	data, readErr := os.ReadFile(filepath.Join(extractDir, appConstants.EXTRACT_MANIFEST_NAME))
+	if readErr != nil {
+		return manifest, readErr
+	}
+
+	return manifest, json.Unmarshal(data, &manifest)
+}
+
+// isExtractDirOf is a helper function that checks whether the archive can be extracted into the folder,
+// which is when the folder does not exist or the archive was extracted into it before.
+func isExtractDirOf(extractDir string, archivePath string) bool {
+	if !appFiles.Exists(extractDir) {
+		return true
+	}
+
+	manifest, loadErr := LoadManifest(extractDir)
+	return loadErr == nil && manifest.Archive == filepath.Base(archivePath)
+}
+
+// saveManifest is a helper function that saves the manifest of the files extracted into the folder.
+func saveManifest(extractDir string, manifest Manifest) error {
+	data, marshalErr := json.MarshalIndent(manifest, "", "  ")
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	if ensureDirErr := appFiles.EnsureDir(extractDir); ensureDirErr != nil {
+		return ensureDirErr
+	}
+
+	return os.WriteFile(filepath.Join(extractDir, appConstants.EXTRACT_MANIFEST_NAME), data, 0644)
+}
+
+// removeStale is a helper function that removes the files extracted previously that are not extracted again,
+// together with the folders they leave empty.
+func removeStale(extractDir string, previousFiles []string, files []string) error {
+	extracted := map[string]bool{}
+	for _, file := range files {
+		extracted[file] = true
+	}
+
+	for _, file := range previousFiles {
+		if extracted[file] || !filepath.IsLocal(filepath.FromSlash(file)) {
+			continue
+		}
+
+		filePath := filepath.Join(extractDir, filepath.FromSlash(file))
+		removeErr := os.Remove(filePath)
+		if removeErr != nil && !errors.Is(removeErr, fs.ErrNotExist) {
+			return removeErr
+		}
+
+		// Folders that are not empty, such as ones holding the user's files, are kept.
+		for dir := filepath.Dir(filePath); dir != extractDir && strings.HasPrefix(dir, extractDir); dir = filepath.Dir(dir) {
+			if os.Remove(dir) != nil {
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractZip is a helper function that extracts the zip archive into dir.
+// It returns the paths of the files extracted, relative to dir and sorted.
+func extractZip(archivePath string, dir string) ([]string, error) {
+	reader, openErr := zip.OpenReader(archivePath)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer reader.Close()
+
+	extractor := newExtractor(dir)
+	for _, entry := range reader.File {
+		if !entry.Mode().IsRegular() {
+			continue
+		}
+
+		content, entryErr := entry.Open()
+		if entryErr != nil {
+			return nil, entryErr
+		}
+
+		writeErr := extractor.write(entry.Name, content)
+		content.Close()
+		if writeErr != nil {
+			return nil, writeErr
+		}
+	}
+
+	sort.Strings(extractor.files)
+	return extractor.files, nil
+}
+
+// extractTarGz is a helper function that extracts the tar.gz archive into dir.
+// It returns the paths of the files extracted, relative to dir and sorted.
+func extractTarGz(archivePath string, dir string) ([]string, error) {
+	archive, openErr := os.Open(archivePath)
+	if openErr != nil {
+		return nil, openErr
+	}
+	defer archive.Close()
+
+	gzipReader, gzipErr := gzip.NewReader(archive)
+	if gzipErr != nil {
+		return nil, gzipErr
+	}
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+	extractor := newExtractor(dir)
+	for {
+		header, nextErr := tarReader.Next()
+		if nextErr == io.EOF {
+			break
+		}
+		if nextErr != nil {
+			return nil, nextErr
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		if writeErr := extractor.write(header.Name, tarReader); writeErr != nil {
+			return nil, writeErr
+		}
+	}
+
+	sort.Strings(extractor.files)
+	return extractor.files, nil
+}
+
+// extractor struct keeps count of what was extracted from an archive, such that the limits apply
+// to the archive as a whole.
+type extractor struct {
+	dir     string
+	files   []string
+	written map[string]bool
+	size    int64
+}
+
+// newExtractor is a helper function that creates an extractor writing into dir.
+func newExtractor(dir string) *extractor {
+	return &extractor{dir: dir, files: []string{}, written: map[string]bool{}}
+}
+
+// write is a helper function that writes an entry of the archive into the folder, unless it would be
+// written outside the folder. Sizes written in the archive are not trusted: the entry is read until
+// MAX_TOTAL_SIZE is reached.
+func (extractor *extractor) write(name string, content io.Reader) error {
+	name = strings.ReplaceAll(name, "\\", "/")
+	localName := filepath.FromSlash(name)
+	if !filepath.IsLocal(localName) {
+		return nil
+	}
+
+	normalizedParts := []string{}
+	for _, part := range strings.Split(filepath.ToSlash(filepath.Clean(localName)), "/") {
+		normalizedParts = append(normalizedParts, appFiles.NormalizeName(part))
+	}
+	file := strings.Join(normalizedParts, "/")
+
+	if len(extractor.files) >= MAX_FILES {
+		return ErrTooLarge
+	}
+
+	filePath := filepath.Join(extractor.dir, filepath.FromSlash(file))
+	if ensureDirErr := appFiles.EnsureDir(filepath.Dir(filePath)); ensureDirErr != nil {
+		return ensureDirErr
+	}
+
+	out, createErr := os.Create(filePath)
+	if createErr != nil {
+		return createErr
+	}
+	defer out.Close()
+
+	remaining := MAX_TOTAL_SIZE - extractor.size
+	written, copyErr := io.Copy(out, io.LimitReader(content, remaining+1))
+	extractor.size += written
+	if copyErr != nil {
+		return copyErr
+	}
+	if written > remaining {
+		return ErrTooLarge
+	}
+
+	// An archive can contain the same file more than once, in which case the last one is kept.
+	if !extractor.written[file] {
+		extractor.written[file] = true
+		extractor.files = append(extractor.files, file)
+	}
+	return nil
+}
+
+// getArchiveExt is a helper function that returns the extension of the archive, in lower case,
+// or an empty string if the file is not an archive that can be extracted.
+func getArchiveExt(fileName string) string {
+	lowerName := strings.ToLower(fileName)
+	for _, ext := range ARCHIVE_EXTENSIONS {
+		if strings.HasSuffix(lowerName, ext) && len(lowerName) > len(ext) {
+			return ext
+		}
+	}
+
+	return ""
+}
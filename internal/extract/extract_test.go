@@ -0,0 +1,123 @@
+package extract
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = logrus.New()
+	logs.Logger.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+func TestExtractKeepsFilesItDidNotExtract(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "Week3.zip")
+
+	writeZip(t, archivePath, map[string]string{"Notes.pdf": "v1"})
+	extractDir, err := Extract(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if extractDir != filepath.Join(dir, "Week3") {
+		t.Fatalf("Extract() = %s, want the folder named after the archive", extractDir)
+	}
+
+	// The user adds a file with the same name as one in the next version of the archive.
+	writeFile(t, filepath.Join(extractDir, "Answers.pdf"), "my answers")
+	writeZip(t, archivePath, map[string]string{"Notes.pdf": "v2", "Answers.pdf": "answers"})
+
+	if _, err := Extract(archivePath); err != nil {
+		t.Fatal(err)
+	}
+
+	assertFile(t, filepath.Join(extractDir, "Notes.pdf"), "v2")
+	assertFile(t, filepath.Join(extractDir, "Answers.pdf"), "my answers")
+
+	manifest, loadErr := LoadManifest(extractDir)
+	if loadErr != nil || len(manifest.Files) != 1 || manifest.Files[0] != "Notes.pdf" {
+		t.Errorf("manifest = %+v (%v), want only the files extracted", manifest, loadErr)
+	}
+}
+
+func TestExtractIntoExistingFolder(t *testing.T) {
+	dir := t.TempDir()
+	archivePath := filepath.Join(dir, "Week3.zip")
+
+	writeFile(t, filepath.Join(dir, "Week3", "Notes.pdf"), "synced notes")
+	writeFile(t, filepath.Join(dir, "Week3 (extracted)", "Other.pdf"), "my file")
+	writeZip(t, archivePath, map[string]string{"Notes.pdf": "zipped notes"})
+
+	extractDir, err := Extract(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extractDir != filepath.Join(dir, "Week3 (extracted 2)") {
+		t.Fatalf("Extract() = %s, want the first folder that is not taken", extractDir)
+	}
+	assertFile(t, filepath.Join(dir, "Week3", "Notes.pdf"), "synced notes")
+	assertFile(t, filepath.Join(extractDir, "Notes.pdf"), "zipped notes")
+
+	if again := GetExtractDir(archivePath); again != extractDir {
+		t.Errorf("GetExtractDir() = %s after extracting, want %s", again, extractDir)
+	}
+}
+
+// writeZip is a helper function that writes a zip archive of the files, keyed by name, to archivePath.
+func writeZip(t *testing.T, archivePath string, files map[string]string) {
+	t.Helper()
+
+	archive, createErr := os.Create(archivePath)
+	if createErr != nil {
+		t.Fatal(createErr)
+	}
+	defer archive.Close()
+
+	writer := zip.NewWriter(archive)
+	for name, contents := range files {
+		fileWriter, err := writer.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fileWriter.Write([]byte(contents))
+	}
+
+	if closeErr := writer.Close(); closeErr != nil {
+		t.Fatal(closeErr)
+	}
+}
+
+// writeFile is a helper function that writes the contents to filePath, creating its folder.
+func writeFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertFile is a helper function that fails the test if the file at filePath does not have the contents.
+func assertFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	if string(data) != contents {
+		t.Errorf("%s = %q, want %q", filePath, data, contents)
+	}
+}
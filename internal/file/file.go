@@ -2,12 +2,14 @@
 package file
 
 import (
+	"crypto/sha256"
 	"encoding/gob"
+	"encoding/hex"
 	"fmt"
-	"net/url"
+	"io"
 	"os"
 	"path/filepath"
-	"strings"
+	"time"
 )
 
 // EncodeToFile takes in any type and encodes it into a file specified by fileName.
@@ -64,25 +66,46 @@ func Exists(name string) bool {
 // where X is a positive integer.
 // X increments itself starting from 1 until the there exists a
 // the new fileName does not exist in the directory.
-func AutoRename(filePath string) error {
-	FORMAT := "%s-old-v%d.%s"
+// Files without an extension are supported, eg. README becomes README-old-v1.
+// It returns the path the file was renamed to.
+func AutoRename(filePath string) (string, error) {
 	directory, fileNameWithExt := filepath.Split(filePath)
 
-	n := strings.LastIndexByte(fileNameWithExt, '.')
-	fileNameWOExt := fileNameWithExt[:n]
-	fileExt := fileNameWithExt[n+1:]
-
-	newFileName := fileNameWOExt
-
+	newFilePath := filePath
 	for x := 1; ; x++ {
-		newFileName = fmt.Sprintf(FORMAT, fileNameWOExt, x, fileExt)
+		newFilePath = filepath.Join(directory, AppendToName(fileNameWithExt, fmt.Sprintf("-old-v%d", x)))
 
-		if !Exists(filepath.Join(directory, newFileName)) {
+		if !Exists(newFilePath) {
 			break
 		}
 	}
 
-	return os.Rename(filePath, filepath.Join(directory, newFileName))
+	return newFilePath, os.Rename(filePath, newFilePath)
+}
+
+// Hash returns the hex encoded SHA-256 hash of the contents of the given file.
+func Hash(filePath string) (string, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, copyErr := io.Copy(hash, file); copyErr != nil {
+		return "", copyErr
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// ConflictName returns the path a locally modified file is renamed to when it conflicts with
+// an update, by appending " (conflict YYYY-MM-DD HHMMSS)" to its fileName.
+// Eg. Lecture1.pdf becomes Lecture1 (conflict 2022-01-31 134500).pdf.
+func ConflictName(filePath string, now time.Time) string {
+	directory, fileName := filepath.Split(filePath)
+
+	return filepath.Join(directory, AppendToName(fileName, fmt.Sprintf(" (conflict %s)", now.Format("2006-01-02 150405"))))
 }
 
 // EnsureDir is a helper function that ensures that the directory exists by creating them
@@ -98,41 +121,6 @@ func EnsureDir(dir string) error {
 	return nil
 }
 
-// CleanseFolderFileName is a helper function that ensures folders' and files' names are valid,
-// that they do not contain prohibited characters. However, some are still not caught for
-// unlikeliness and simplicity reasons.
-// The following are reserved file names for Windows that are uncaught:
-// CON, PRN, AUX, NUL, COM1, COM2, COM3, COM4, COM5, COM6, COM7, COM8, COM9, LPT1, LPT2, LPT3, LPT4, LPT5, LPT6, LPT7, LPT8, LPT9.
-// The following are non-printable characters that are uncaught:
-// ASCII 0-31.
-func CleanseFolderFileName(name string) string {
-	name = strings.Replace(name, "/", " ", -1)
-	name = strings.Replace(name, "\\", " ", -1)
-	name = strings.Replace(name, "<", " ", -1)
-	name = strings.Replace(name, ">", " ", -1)
-	name = strings.Replace(name, ":", " ", -1)
-	name = strings.Replace(name, "\"", " ", -1)
-	name = strings.Replace(name, "|", " ", -1)
-	name = strings.Replace(name, "?", " ", -1)
-	name = strings.Replace(name, "*", " ", -1)
-
-	// If there is an error, it would just mean that the "%XX" that appears
-	// in the name is legit, and not because of URL encoding.
-	unescapedName, err := url.QueryUnescape(name)
-
-	// If there is an error, unescapedName will be an empty string
-	if err == nil {
-		name = unescapedName
-	}
-
-	// Removes leading and trailing spaces (32) and periods (46)
-	name = strings.TrimFunc(name, func(r rune) bool {
-		return r == 32 || r == 46
-	})
-
-	return name
-}
-
 // FileNotFoundError struct is an error struct that contains the custom error that will be thrown when file is not found.
 type FileNotFoundError struct {
 	FileName string
@@ -0,0 +1,42 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNamesDerivedFromLongNames(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Date(2022, 1, 31, 13, 45, 0, 0, time.Local)
+
+	// A name of MAX_NAME_LENGTH bytes, such as one the user gave their file.
+	filePath := filepath.Join(directory, strings.Repeat("a", MAX_NAME_LENGTH-4)+".pdf")
+	if err := os.WriteFile(filePath, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	conflictPath := ConflictName(filePath, now)
+	if filepath.Dir(conflictPath) != directory || !strings.HasSuffix(conflictPath, " (conflict 2022-01-31 134500).pdf") {
+		t.Errorf("ConflictName() = %s, want the time before the extension in the same folder", conflictPath)
+	}
+	if err := os.Rename(filePath, conflictPath); err != nil {
+		t.Fatalf("unable to rename to the conflict name: %v", err)
+	}
+
+	renamedPath, renameErr := AutoRename(conflictPath)
+	if renameErr != nil {
+		t.Fatalf("AutoRename() returned error: %v", renameErr)
+	}
+	if !strings.HasSuffix(renamedPath, "-old-v1.pdf") {
+		t.Errorf("AutoRename() = %s, want -old-v1 before the extension", renamedPath)
+	}
+
+	// Names normalized from the LMS leave room for the suffixes.
+	normalized := NormalizeName(strings.Repeat("b", 300) + ".pdf")
+	if len(ConflictName(normalized, now)) > MAX_NAME_LENGTH || strings.Count(ConflictName(normalized, now), "b") != len(normalized)-4 {
+		t.Errorf("ConflictName(%q) = %q, want the name kept whole", normalized, ConflictName(normalized, now))
+	}
+}
@@ -0,0 +1,135 @@
+// Package file provides util primitives to file operations.
+package file
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MAX_NAME_LENGTH is the length in bytes a file or folder name is limited to by most file systems.
+const MAX_NAME_LENGTH = 255
+
+// MAX_SUFFIX_LENGTH is the length in bytes kept free in the names of synced files for the file Id that
+// tells apart files with the same name, and for what Lominus appends to names, such as
+// " (conflict 2022-01-31 134500)", the time of a version or the random part of a download in progress.
+const MAX_SUFFIX_LENGTH = 56
+
+// MAX_PATH_LENGTH is the length in bytes absolute paths of synced files are kept within. It is below
+// the limit of 260 characters on Windows, leaving room for the trash and versions folders.
+const MAX_PATH_LENGTH = 240
+
+// ILLEGAL_CHARACTERS are the characters that cannot appear in a file or folder name on at least one platform.
+const ILLEGAL_CHARACTERS = "/\\<>:\"|?*"
+
+// RESERVED_NAMES are the names Windows reserves for devices, regardless of case and extension.
+var RESERVED_NAMES = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM0": true, "COM1": true, "COM2": true, "COM3": true, "COM4": true,
+	"COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT0": true, "LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true,
+	"LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// NormalizeName maps the name of a folder or file on the LMS to a name that is valid on every platform.
+// The same name is always mapped to the same result:
+//   - URL escapes, eg. %20, are decoded. Plus signs are kept as they are.
+//   - The name is normalized to Unicode NFC, such that it looks the same on macOS, Windows and Linux.
+//   - Illegal and control characters are replaced with spaces.
+//   - Leading and trailing spaces and periods are removed.
+//   - Reserved names are escaped with an underscore, eg. CON.txt becomes CON_.txt.
+//   - Names longer than MAX_NAME_LENGTH - MAX_SUFFIX_LENGTH bytes are truncated, keeping their extension.
+//
+// The original name is kept in the index, as the mapping cannot always be reversed from the result.
+func NormalizeName(name string) string {
+	// If there is an error, it would just mean that the "%XX" that appears
+	// in the name is legit, and not because of URL encoding.
+	if unescapedName, err := url.PathUnescape(name); err == nil {
+		name = unescapedName
+	}
+
+	name = norm.NFC.String(name)
+
+	name = strings.Map(func(r rune) rune {
+		if r < 32 || r == 127 || strings.ContainsRune(ILLEGAL_CHARACTERS, r) {
+			return ' '
+		}
+		return r
+	}, name)
+
+	name = trimName(name)
+	if name == "" {
+		return "_"
+	}
+
+	name = escapeReservedName(name)
+
+	return TruncateName(name, MAX_NAME_LENGTH-MAX_SUFFIX_LENGTH)
+}
+
+// AppendToName appends suffix to the name before its extension, eg. Lecture1.pdf becomes Lecture1-old-v1.pdf.
+// The name is truncated such that the result is at most MAX_NAME_LENGTH bytes.
+func AppendToName(name string, suffix string) string {
+	extension := filepath.Ext(name)
+	base := strings.TrimSuffix(name, extension)
+
+	if len(base)+len(suffix)+len(extension) > MAX_NAME_LENGTH {
+		base = TruncateName(base, MAX_NAME_LENGTH-len(suffix)-len(extension))
+	}
+
+	return base + suffix + extension
+}
+
+// TruncateName shortens name to at most maxBytes bytes without splitting a character.
+// The extension is kept unless it is too long to leave room for the rest of the name.
+func TruncateName(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+
+	extension := filepath.Ext(name)
+	if len(extension) >= maxBytes/2 {
+		extension = ""
+	}
+
+	base := strings.TrimSuffix(name, extension)
+	return trimName(truncateBytes(base, maxBytes-len(extension))) + extension
+}
+
+// truncateBytes is a helper function that shortens s to at most maxBytes bytes without splitting a character.
+func truncateBytes(s string, maxBytes int) string {
+	if maxBytes <= 0 {
+		return ""
+	}
+	if len(s) <= maxBytes {
+		return s
+	}
+
+	for maxBytes > 0 && !utf8.RuneStart(s[maxBytes]) {
+		maxBytes--
+	}
+
+	return s[:maxBytes]
+}
+
+// trimName is a helper function that removes leading and trailing spaces and periods,
+// which Windows does not allow.
+func trimName(name string) string {
+	return strings.TrimFunc(name, func(r rune) bool {
+		return r == ' ' || r == '.'
+	})
+}
+
+// escapeReservedName is a helper function that appends an underscore to the part of the name
+// before its first period if it is reserved, eg. NUL.tar.gz becomes NUL_.tar.gz.
+func escapeReservedName(name string) string {
+	stem, _, _ := strings.Cut(name, ".")
+	if !RESERVED_NAMES[strings.ToUpper(strings.TrimRight(stem, " "))] {
+		return name
+	}
+
+	return stem + "_" + name[len(stem):]
+}
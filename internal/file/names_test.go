@@ -0,0 +1,141 @@
+package file
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		// Reserved names
+		{"CON", "CON_"},
+		{"nul.txt", "nul_.txt"},
+		{"NUL.tar.gz", "NUL_.tar.gz"},
+		{"Com1.pdf", "Com1_.pdf"},
+		{"console.txt", "console.txt"},
+		{"LPT10.txt", "LPT10.txt"},
+
+		// Unicode normalization
+		{"Caf\u00e9.pdf", "Caf\u00e9.pdf"},
+		{"Cafe\u0301.pdf", "Caf\u00e9.pdf"},
+		{"\u1100\u1161\u11a8.pdf", "\uac01.pdf"},
+
+		// URL escapes and plus signs
+		{"Lecture%201.pdf", "Lecture 1.pdf"},
+		{"C++ notes.pdf", "C++ notes.pdf"},
+		{"a+b%2Bc.pdf", "a+b+c.pdf"},
+		{"100%.pdf", "100%.pdf"},
+		{"Week%2F1.pdf", "Week 1.pdf"},
+		{"Caf%C3%A9.pdf", "Caf\u00e9.pdf"},
+
+		// Illegal and control characters
+		{"a:b?.pdf", "a b .pdf"},
+		{"Week\t1\n.pdf", "Week 1 .pdf"},
+		{"bell\x07\x7f.pdf", "bell  .pdf"},
+		{`x<y>"z"|w*.txt`, "x y  z  w .txt"},
+
+		// Leading and trailing spaces and periods
+		{"notes. ", "notes"},
+		{" .hidden", "hidden"},
+		{"Slides...", "Slides"},
+		{"...", "_"},
+		{"", "_"},
+		{"   ", "_"},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeName(test.name); got != test.want {
+			t.Errorf("NormalizeName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestNormalizeNameIsIdempotent(t *testing.T) {
+	names := []string{"CON", "nul.txt", "Cafe\u0301.pdf", "Lecture%201.pdf", "a:b?.pdf", "notes. ", strings.Repeat("a", 300) + ".pdf"}
+
+	for _, name := range names {
+		normalized := NormalizeName(name)
+		if again := NormalizeName(normalized); again != normalized {
+			t.Errorf("NormalizeName(%q) = %q, want it unchanged", normalized, again)
+		}
+	}
+}
+
+func TestNormalizeNameTruncatesLongNames(t *testing.T) {
+	tests := []string{
+		strings.Repeat("a", 300) + ".pdf",
+		strings.Repeat("é", 200) + ".pdf",
+		strings.Repeat("日", 100) + ".pdf",
+	}
+
+	for _, name := range tests {
+		got := NormalizeName(name)
+		if len(got) > MAX_NAME_LENGTH-MAX_SUFFIX_LENGTH || !strings.HasSuffix(got, ".pdf") || !utf8.ValidString(got) {
+			t.Errorf("NormalizeName(%q) = %q (%d bytes), want a valid name of at most %d bytes ending with .pdf", name, got, len(got), MAX_NAME_LENGTH-MAX_SUFFIX_LENGTH)
+		}
+	}
+}
+
+func TestTruncateName(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		want     string
+	}{
+		{"Lecture.pdf", 100, "Lecture.pdf"},
+		{"Lecture.pdf", 11, "Lecture.pdf"},
+		{"abcdefgh.pdf", 10, "abcdef.pdf"},
+		{"abc def x.pdf", 12, "abc def.pdf"},
+		{"abc.def.pdf", 10, "abc.de.pdf"},
+
+		// Multibyte characters are not split.
+		{"日本語.pdf", 11, "日本.pdf"},
+		{"日本語.pdf", 12, "日本.pdf"},
+		{"éééé.pdf", 11, "ééé.pdf"},
+		{"\U0001F600\U0001F600.pdf", 11, "\U0001F600.pdf"},
+
+		// Extensions too long to leave room for the rest of the name are not kept.
+		{"abcdefgh.pdf", 8, "abcdefgh"},
+		{"a.verylongextension", 8, "a.verylo"},
+		{"notes.extension", 10, "notes.exte"},
+	}
+
+	for _, test := range tests {
+		got := TruncateName(test.name, test.maxBytes)
+		if got != test.want {
+			t.Errorf("TruncateName(%q, %d) = %q, want %q", test.name, test.maxBytes, got, test.want)
+		}
+
+		if len(got) > test.maxBytes || !utf8.ValidString(got) {
+			t.Errorf("TruncateName(%q, %d) = %q, want a valid name of at most %d bytes", test.name, test.maxBytes, got, test.maxBytes)
+		}
+	}
+}
+
+func TestAppendToName(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix string
+		want   string
+	}{
+		{"Lecture1.pdf", "-old-v1", "Lecture1-old-v1.pdf"},
+		{"README", " (conflict 2022-01-31 134500)", "README (conflict 2022-01-31 134500)"},
+		{strings.Repeat("a", 251) + ".pdf", "-old-v1", strings.Repeat("a", 244) + "-old-v1.pdf"},
+		{strings.Repeat("日", 83) + ".pdf", ".20220131-134500", strings.Repeat("日", 78) + ".20220131-134500.pdf"},
+	}
+
+	for _, test := range tests {
+		got := AppendToName(test.name, test.suffix)
+		if got != test.want {
+			t.Errorf("AppendToName(%q, %q) = %q, want %q", test.name, test.suffix, got, test.want)
+		}
+
+		if len(got) > MAX_NAME_LENGTH || !utf8.ValidString(got) {
+			t.Errorf("AppendToName(%q, %q) = %q, want a valid name of at most %d bytes", test.name, test.suffix, got, MAX_NAME_LENGTH)
+		}
+	}
+}
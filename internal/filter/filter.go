@@ -0,0 +1,186 @@
+// Package filter provides primitives to decide which files are skipped during syncs
+// based on rules configured by the user.
+package filter
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beebeeoii/lominus/internal/section"
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+// Action describes what happens to a file when a Rule matches it.
+type Action string
+
+// Kind describes which property of a file a Rule is matched against.
+type Kind string
+
+const (
+	Include Action = "include"
+	Exclude Action = "exclude"
+)
+
+const (
+	// Glob matches the path of the file relative to the root sync directory,
+	// eg. CS2040/Lectures/*.mp4. Patterns without a "/" are matched against
+	// the file name only.
+	Glob Kind = "glob"
+	// Ext matches the extension of the file, case insensitive, eg. mp4.
+	Ext Kind = "ext"
+	// Larger matches files larger than the given size, eg. 100MB.
+	Larger Kind = "larger"
+	// Before matches files last modified before the given date, eg. 2024-01-01.
+	Before Kind = "before"
+)
+
+const DATE_FORMAT = "2006-01-02"
+
+// Rule struct describes a single include/exclude rule.
+type Rule struct {
+	Action Action
+	Kind   Kind
+	Value  string
+
+	size int64
+	date time.Time
+}
+
+// Rules struct contains the rules applied to all modules and the rules applied
+// to specific modules only, keyed by module code.
+type Rules struct {
+	section.Sections[Rule]
+}
+
+// Parse parses the text representation of Rules. Each non-empty line is either:
+//   - a comment starting with "#",
+//   - a section header "[MODULECODE]" after which rules apply to that module only, or
+//   - a rule in the form "<include|exclude> <glob|ext|larger|before> <value>".
+//
+// Rules before the first section header apply to all modules.
+func Parse(text string) (Rules, error) {
+	sections, err := section.Parse(text, parseRule)
+	return Rules{sections}, err
+}
+
+// Skips checks the rules of the file's module followed by the global rules
+// and returns whether the file should be skipped, together with the rule that decided it.
+// The first matching rule wins. Files that do not match any rule are not skipped.
+func (rules Rules) Skips(file api.File) (bool, Rule) {
+	moduleCode := ""
+	if len(file.Ancestors) > 0 {
+		moduleCode = file.Ancestors[0]
+	}
+
+	for _, rule := range rules.ForModule(moduleCode) {
+		if rule.Matches(file) {
+			return rule.Action == Exclude, rule
+		}
+	}
+
+	return false, Rule{}
+}
+
+// Matches checks whether the file matches the rule, regardless of its Action.
+func (rule Rule) Matches(file api.File) bool {
+	switch rule.Kind {
+	case Glob:
+		target := file.Name
+		if strings.Contains(rule.Value, "/") {
+			target = path.Join(append(append([]string{}, file.Ancestors...), file.Name)...)
+		}
+
+		matched, _ := path.Match(rule.Value, target)
+		return matched
+	case Ext:
+		return strings.EqualFold(strings.TrimPrefix(path.Ext(file.Name), "."), rule.Value)
+	case Larger:
+		return file.Size > rule.size
+	case Before:
+		return file.LastUpdated.Before(rule.date)
+	}
+
+	return false
+}
+
+// String returns the text representation of the rule, as accepted by Parse.
+func (rule Rule) String() string {
+	return fmt.Sprintf("%s %s %s", rule.Action, rule.Kind, rule.Value)
+}
+
+// parseRule is a helper function that parses a single rule line.
+func parseRule(line string) (Rule, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return Rule{}, fmt.Errorf("expected \"<include|exclude> <kind> <value>\", got %q", line)
+	}
+
+	rule := Rule{
+		Action: Action(strings.ToLower(fields[0])),
+		Kind:   Kind(strings.ToLower(fields[1])),
+		Value:  strings.Join(fields[2:], " "),
+	}
+
+	if rule.Action != Include && rule.Action != Exclude {
+		return rule, fmt.Errorf("invalid action %q - must be include or exclude", fields[0])
+	}
+
+	switch rule.Kind {
+	case Glob:
+		if _, err := path.Match(rule.Value, ""); err != nil {
+			return rule, fmt.Errorf("invalid glob %q", rule.Value)
+		}
+	case Ext:
+		rule.Value = strings.TrimPrefix(rule.Value, ".")
+	case Larger:
+		size, err := parseSize(rule.Value)
+		if err != nil {
+			return rule, err
+		}
+		rule.size = size
+	case Before:
+		date, err := time.ParseInLocation(DATE_FORMAT, rule.Value, time.Local)
+		if err != nil {
+			return rule, fmt.Errorf("invalid date %q - must be YYYY-MM-DD", rule.Value)
+		}
+		rule.date = date
+	default:
+		return rule, fmt.Errorf("invalid kind %q - must be glob, ext, larger or before", fields[1])
+	}
+
+	return rule, nil
+}
+
+// parseSize is a helper function that parses human readable sizes such as 500KB,
+// 100MB or 1.5GB into number of bytes. Sizes without a unit are in bytes.
+func parseSize(value string) (int64, error) {
+	units := []struct {
+		suffix     string
+		multiplier float64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	upper := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1.0
+	for _, unit := range units {
+		if strings.HasSuffix(upper, unit.suffix) {
+			upper = strings.TrimSpace(strings.TrimSuffix(upper, unit.suffix))
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	n, err := strconv.ParseFloat(upper, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q - eg. 100MB", value)
+	}
+
+	return int64(n * multiplier), nil
+}
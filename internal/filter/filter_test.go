@@ -0,0 +1,116 @@
+package filter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+func TestSkips(t *testing.T) {
+	rules, parseErr := Parse(`
+# Global rules apply after the rules of the module.
+exclude ext mp4
+exclude larger 100MB
+exclude before 2024-01-01
+
+[cs2030s]
+include glob CS2030S/Recordings/*.mp4
+exclude glob *.pptx
+`)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	recent := time.Date(2024, 3, 1, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		name string
+		file api.File
+		want bool
+	}{
+		{
+			name: "no rule matches",
+			file: api.File{Name: "Lecture.pdf", Ancestors: []string{"CS2040"}, LastUpdated: recent},
+			want: false,
+		},
+		{
+			name: "extension, case insensitive",
+			file: api.File{Name: "Lecture.MP4", Ancestors: []string{"CS2040"}, LastUpdated: recent},
+			want: true,
+		},
+		{
+			name: "larger than",
+			file: api.File{Name: "Dataset.zip", Ancestors: []string{"CS2040"}, Size: 101 << 20, LastUpdated: recent},
+			want: true,
+		},
+		{
+			name: "not larger than",
+			file: api.File{Name: "Dataset.zip", Ancestors: []string{"CS2040"}, Size: 100 << 20, LastUpdated: recent},
+			want: false,
+		},
+		{
+			name: "before",
+			file: api.File{Name: "Old.pdf", Ancestors: []string{"CS2040"}, LastUpdated: recent.AddDate(-1, 0, 0)},
+			want: true,
+		},
+		{
+			name: "module rule matched before the global rules",
+			file: api.File{Name: "Week1.mp4", Ancestors: []string{"CS2030S", "Recordings"}, LastUpdated: recent},
+			want: false,
+		},
+		{
+			name: "module glob without a folder matches the name",
+			file: api.File{Name: "Slides.pptx", Ancestors: []string{"CS2030S", "Lectures"}, LastUpdated: recent},
+			want: true,
+		},
+		{
+			name: "module rules do not apply to other modules",
+			file: api.File{Name: "Slides.pptx", Ancestors: []string{"CS2040"}, LastUpdated: recent},
+			want: false,
+		},
+		{
+			name: "module glob with a folder does not match other folders",
+			file: api.File{Name: "Week1.mp4", Ancestors: []string{"CS2030S", "Lectures"}, LastUpdated: recent},
+			want: true,
+		},
+	}
+
+	for _, test := range tests {
+		if got, rule := rules.Skips(test.file); got != test.want {
+			t.Errorf("%s: Skips() = %t (%s), want %t", test.name, got, rule, test.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"exclude ext",
+		"skip ext mp4",
+		"exclude size 100MB",
+		"exclude larger lots",
+		"exclude before 01/01/2024",
+		"exclude glob [",
+	}
+
+	for _, text := range tests {
+		if _, err := Parse("# comment\n" + text); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+			t.Errorf("Parse(%q) = %v, want an error on line 2", text, err)
+		}
+	}
+}
+
+func TestRuleStringRoundTrips(t *testing.T) {
+	rules, parseErr := Parse("include glob CS2040/Lectures/*.pdf\nexclude larger 1.5GB")
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	for _, rule := range rules.Global {
+		again, err := parseRule(rule.String())
+		if err != nil || again != rule {
+			t.Errorf("parseRule(%q) = %+v, %v, want %+v", rule.String(), again, err, rule)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+// Package hooks provides primitives to run commands configured by the user when
+// files are synced, such as converting slides to PDF.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/section"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
+)
+
+// Event describes when a Hook runs.
+type Event string
+
+const (
+	// Downloaded runs after a file new to the local desktop is downloaded.
+	Downloaded Event = "downloaded"
+	// Updated runs after a newer version of a file is downloaded.
+	Updated Event = "updated"
+	// Finished runs once after every sync, or after the syncs that changed files of the module
+	// for hooks under a module section.
+	Finished Event = "finished"
+)
+
+// DEFAULT_TIMEOUT is how long a command may run before it is stopped, unless its Hook sets otherwise.
+const DEFAULT_TIMEOUT = time.Minute
+
+// WAIT_DELAY is how long the output of a stopped command is still read for.
+const WAIT_DELAY = 5 * time.Second
+
+// ENV_PREFIX is the prefix of the environment variables commands receive, eg. LOMINUS_FILE.
+const ENV_PREFIX = "LOMINUS_"
+
+// Hook struct describes a command to run on an Event.
+// Glob matches the path of the file relative to the root sync directory, eg. CS2040/Tutorials/*.pptx.
+// Patterns without a "/" are matched against the file name only. An empty Glob matches every file.
+type Hook struct {
+	Event   Event
+	Glob    string
+	Timeout time.Duration
+	Command string
+}
+
+// Hooks struct contains the hooks run for all modules and the hooks run for specific modules only,
+// keyed by module code.
+type Hooks struct {
+	section.Sections[Hook]
+}
+
+// Parse parses the text representation of Hooks. Each non-empty line is either:
+//   - a comment starting with "#",
+//   - a section header "[MODULECODE]" after which hooks run for that module only, or
+//   - a hook in the form "on <downloaded|updated|finished> [glob <pattern>] [timeout <duration>] do <command>".
+//
+// Hooks before the first section header run for all modules.
+func Parse(text string) (Hooks, error) {
+	sections, err := section.Parse(text, parseHook)
+	return Hooks{sections}, err
+}
+
+// Listener returns a sync event listener that queues the hooks matching every event of the sync
+// of rootSyncDirectory on runner, such that the commands run alongside the sync instead of holding it up.
+func (hooks Hooks) Listener(runner *Runner, rootSyncDirectory string) func(appSync.Event) {
+	return func(event appSync.Event) {
+		switch event := event.(type) {
+		case appSync.FileDownloaded:
+			hookEvent := Downloaded
+			if event.Entry.Action == appSync.ActionUpdate {
+				hookEvent = Updated
+			}
+
+			env := getFileEnv(hookEvent, rootSyncDirectory, event)
+			for _, hook := range hooks.forFile(hookEvent, event.Entry) {
+				runner.Queue(hook, rootSyncDirectory, env)
+			}
+		case appSync.SyncFinished:
+			env := getSyncEnv(rootSyncDirectory, event)
+			for _, hook := range hooks.forSync(event.Result) {
+				runner.Queue(hook, rootSyncDirectory, env)
+			}
+		}
+	}
+}
+
+// Matches checks whether the hook runs on the event for the file described by the plan entry.
+func (hook Hook) Matches(event Event, entry appSync.PlanEntry) bool {
+	if hook.Event != event {
+		return false
+	}
+
+	if hook.Glob == "" {
+		return true
+	}
+
+	target := entry.File.Name
+	if strings.Contains(hook.Glob, "/") {
+		target = entry.Key
+	}
+
+	matched, _ := path.Match(hook.Glob, target)
+	return matched
+}
+
+// Run runs the command of the hook in the shell of the platform, from rootSyncDirectory and with env
+// added to its environment. The command is stopped if it runs longer than the hook's Timeout, or if
+// ctx is cancelled. Its output is written to the log.
+func (hook Hook) Run(ctx context.Context, rootSyncDirectory string, env []string) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DEFAULT_TIMEOUT
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := getShellCommand(timeoutCtx, hook.Command)
+	cmd.Dir = rootSyncDirectory
+	cmd.Env = append(os.Environ(), env...)
+	// Processes started by the command may keep its output open after it is stopped.
+	cmd.WaitDelay = WAIT_DELAY
+	setProcessGroup(cmd)
+
+	logs.Logger.Infof("hook started - %s", hook.String())
+	output, runErr := cmd.CombinedOutput()
+	if len(output) > 0 {
+		logs.Logger.Infof("hook output - %s\n%s", hook.Command, strings.TrimRight(string(output), "\n"))
+	}
+
+	if ctx.Err() != nil {
+		runErr = fmt.Errorf("hook stopped as it was cancelled - %s", hook.Command)
+	} else if errors.Is(timeoutCtx.Err(), context.DeadlineExceeded) {
+		runErr = fmt.Errorf("hook timed out after %s - %s", timeout, hook.Command)
+	}
+
+	if runErr != nil {
+		logs.Logger.Warnln(runErr)
+		return runErr
+	}
+
+	logs.Logger.Infof("hook completed - %s", hook.Command)
+	return nil
+}
+
+// String returns the text representation of the hook, as accepted by Parse.
+func (hook Hook) String() string {
+	fields := []string{"on", string(hook.Event)}
+	if hook.Glob != "" {
+		fields = append(fields, "glob", hook.Glob)
+	}
+	if hook.Timeout > 0 {
+		fields = append(fields, "timeout", hook.Timeout.String())
+	}
+
+	return strings.Join(append(fields, "do", hook.Command), " ")
+}
+
+// forFile is a helper function that returns the hooks of the file's module followed by the global hooks
+// that run on the event for the file described by the plan entry.
+func (hooks Hooks) forFile(event Event, entry appSync.PlanEntry) []Hook {
+	matching := []Hook{}
+	for _, hook := range hooks.ForModule(getModule(entry)) {
+		if hook.Matches(event, entry) {
+			matching = append(matching, hook)
+		}
+	}
+
+	return matching
+}
+
+// forSync is a helper function that returns the hooks that run after the sync with the result.
+// Global hooks run after every sync. Hooks under a module section run after them, only if the sync
+// downloaded or trashed files of the module, and in the order of the module codes.
+func (hooks Hooks) forSync(result appSync.Result) []Hook {
+	matching := []Hook{}
+
+	for _, hook := range hooks.Global {
+		if hook.Event == Finished {
+			matching = append(matching, hook)
+		}
+	}
+
+	changed := map[string]bool{}
+	for _, entry := range append(append([]appSync.PlanEntry{}, result.Downloaded...), result.Trashed...) {
+		changed[strings.ToUpper(getModule(entry))] = true
+	}
+
+	modules := []string{}
+	for module := range hooks.Modules {
+		if changed[module] {
+			modules = append(modules, module)
+		}
+	}
+	sort.Strings(modules)
+
+	for _, module := range modules {
+		for _, hook := range hooks.Modules[module] {
+			if hook.Event == Finished {
+				matching = append(matching, hook)
+			}
+		}
+	}
+
+	return matching
+}
+
+// getModule is a helper function that returns the module code of the file described by the plan entry,
+// or the folder of the module when the file is not on the LMS anymore.
+func getModule(entry appSync.PlanEntry) string {
+	if len(entry.File.Ancestors) > 0 {
+		return entry.File.Ancestors[0]
+	}
+
+	module, _, _ := strings.Cut(entry.Key, "/")
+	return module
+}
+
+// parseHook is a helper function that parses a single hook line.
+func parseHook(line string) (Hook, error) {
+	definition, command, found := strings.Cut(line, " do ")
+	command = strings.TrimSpace(command)
+	if !found || command == "" {
+		return Hook{}, fmt.Errorf("expected \"on <event> [glob <pattern>] [timeout <duration>] do <command>\", got %q", line)
+	}
+
+	fields := strings.Fields(definition)
+	if len(fields) < 2 || strings.ToLower(fields[0]) != "on" {
+		return Hook{}, fmt.Errorf("expected \"on <event> [glob <pattern>] [timeout <duration>] do <command>\", got %q", line)
+	}
+
+	hook := Hook{Event: Event(strings.ToLower(fields[1])), Command: command}
+	if hook.Event != Downloaded && hook.Event != Updated && hook.Event != Finished {
+		return hook, fmt.Errorf("invalid event %q - must be downloaded, updated or finished", fields[1])
+	}
+
+	options := fields[2:]
+	for i := 0; i < len(options); i += 2 {
+		if i+1 >= len(options) {
+			return hook, fmt.Errorf("missing value for %q", options[i])
+		}
+
+		switch strings.ToLower(options[i]) {
+		case "glob":
+			if hook.Event == Finished {
+				return hook, fmt.Errorf("glob cannot be used with finished")
+			}
+			if _, err := path.Match(options[i+1], ""); err != nil {
+				return hook, fmt.Errorf("invalid glob %q", options[i+1])
+			}
+			hook.Glob = options[i+1]
+		case "timeout":
+			timeout, err := time.ParseDuration(options[i+1])
+			if err != nil || timeout <= 0 {
+				return hook, fmt.Errorf("invalid timeout %q - eg. 30s or 5m", options[i+1])
+			}
+			hook.Timeout = timeout
+		default:
+			return hook, fmt.Errorf("invalid option %q - must be glob or timeout", options[i])
+		}
+	}
+
+	return hook, nil
+}
+
+// getShellCommand is a helper function that builds the command running the given command line
+// in the shell of the platform.
+func getShellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
+
+// getFileEnv is a helper function that returns the environment variables describing the downloaded file.
+func getFileEnv(event Event, rootSyncDirectory string, downloaded appSync.FileDownloaded) []string {
+	file := downloaded.Entry.File
+
+	return []string{
+		ENV_PREFIX + "EVENT=" + string(event),
+		ENV_PREFIX + "ROOT=" + rootSyncDirectory,
+		ENV_PREFIX + "FILE=" + downloaded.Path,
+		ENV_PREFIX + "DIR=" + filepath.Dir(downloaded.Path),
+		ENV_PREFIX + "KEY=" + downloaded.Entry.Key,
+		ENV_PREFIX + "MODULE=" + getModule(downloaded.Entry),
+		ENV_PREFIX + "FILE_ID=" + file.Id,
+		ENV_PREFIX + "FILE_NAME=" + file.Name,
+		ENV_PREFIX + "ORIGINAL_NAME=" + file.OriginalName,
+		ENV_PREFIX + "LAST_UPDATED=" + file.LastUpdated.Format(time.RFC3339),
+		ENV_PREFIX + "SIZE=" + strconv.FormatInt(file.Size, 10),
+	}
+}
+
+// getSyncEnv is a helper function that returns the environment variables describing the finished sync.
+func getSyncEnv(rootSyncDirectory string, finished appSync.SyncFinished) []string {
+	status := "ok"
+	if finished.Err != nil {
+		status = "failed"
+	}
+
+	return []string{
+		ENV_PREFIX + "EVENT=" + string(Finished),
+		ENV_PREFIX + "ROOT=" + rootSyncDirectory,
+		ENV_PREFIX + "STATUS=" + status,
+		ENV_PREFIX + "DOWNLOADED=" + strconv.Itoa(len(finished.Result.Downloaded)),
+		ENV_PREFIX + "FAILED=" + strconv.Itoa(len(finished.Result.Failed)),
+	}
+}
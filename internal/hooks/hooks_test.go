@@ -0,0 +1,197 @@
+//go:build !windows
+
+package hooks
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logs "github.com/beebeeoii/lominus/internal/log"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
+	"github.com/beebeeoii/lominus/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	logs.Logger = logrus.New()
+	logs.Logger.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+func TestParse(t *testing.T) {
+	hooks, parseErr := Parse(`
+# Convert all slides
+on downloaded glob *.pptx timeout 2m do soffice --convert-to pdf "$LOMINUS_FILE"
+on finished do echo done
+
+[cs2040]
+on updated glob CS2040/Lectures/* do echo updated
+`)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	want := []Hook{
+		{Event: Downloaded, Glob: "*.pptx", Timeout: 2 * time.Minute, Command: `soffice --convert-to pdf "$LOMINUS_FILE"`},
+		{Event: Finished, Command: "echo done"},
+	}
+	if !reflect.DeepEqual(hooks.Global, want) {
+		t.Errorf("Parse() = %+v, want %+v", hooks.Global, want)
+	}
+
+	for _, hook := range append(hooks.Global, hooks.ForModule("Cs2040")...) {
+		if again, err := parseHook(hook.String()); err != nil || again != hook {
+			t.Errorf("parseHook(%q) = %+v, %v, want %+v", hook.String(), again, err, hook)
+		}
+	}
+	if len(hooks.ForModule("Cs2040")) != 3 {
+		t.Errorf("ForModule(Cs2040) = %+v, want the module's hook and the global hooks", hooks.ForModule("Cs2040"))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"on downloaded",
+		"on downloaded do ",
+		"when downloaded do echo",
+		"on deleted do echo",
+		"on finished glob *.pdf do echo",
+		"on downloaded glob [ do echo",
+		"on downloaded timeout soon do echo",
+		"on downloaded timeout do echo",
+		"on downloaded size 1MB do echo",
+	}
+
+	for _, text := range tests {
+		if got, err := parseHook(text); err == nil {
+			t.Errorf("parseHook(%q) = %+v, want an error", text, got)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	entry := appSync.PlanEntry{
+		Key:  "CS2040/Tutorials/Tut1.pptx",
+		File: api.File{Name: "Tut1.pptx", Ancestors: []string{"CS2040", "Tutorials"}},
+	}
+
+	tests := []struct {
+		hook  Hook
+		event Event
+		want  bool
+	}{
+		{Hook{Event: Downloaded}, Downloaded, true},
+		{Hook{Event: Downloaded}, Updated, false},
+		{Hook{Event: Downloaded, Glob: "*.pptx"}, Downloaded, true},
+		{Hook{Event: Downloaded, Glob: "*.pdf"}, Downloaded, false},
+		{Hook{Event: Downloaded, Glob: "CS2040/Tutorials/*"}, Downloaded, true},
+		{Hook{Event: Downloaded, Glob: "CS2040/*.pptx"}, Downloaded, false},
+	}
+
+	for _, test := range tests {
+		if got := test.hook.Matches(test.event, entry); got != test.want {
+			t.Errorf("Hook(%s).Matches(%s) = %t, want %t", test.hook.String(), test.event, got, test.want)
+		}
+	}
+}
+
+func TestListenerRunsHooksOfChangedModules(t *testing.T) {
+	root := t.TempDir()
+	hooks, parseErr := Parse(`
+on downloaded do echo "$LOMINUS_KEY" >> downloaded.txt
+on finished do echo global >> finished.txt
+
+[cs2040]
+on finished do echo CS2040 >> finished.txt
+
+[cs2030s]
+on finished do echo CS2030S >> finished.txt
+`)
+	if parseErr != nil {
+		t.Fatal(parseErr)
+	}
+
+	entry := appSync.PlanEntry{
+		Action: appSync.ActionNew,
+		Key:    "CS2040/Lecture1.pdf",
+		File:   api.File{Name: "Lecture1.pdf", Ancestors: []string{"CS2040"}},
+	}
+
+	runner := NewRunner()
+	listener := hooks.Listener(runner, root)
+	listener(appSync.FileDownloaded{Entry: entry, Path: filepath.Join(root, "CS2040", "Lecture1.pdf")})
+	listener(appSync.SyncFinished{Result: appSync.Result{Downloaded: []appSync.PlanEntry{entry}}})
+	runner.Wait()
+
+	assertContents(t, filepath.Join(root, "downloaded.txt"), "CS2040/Lecture1.pdf\n")
+	assertContents(t, filepath.Join(root, "finished.txt"), "global\nCS2040\n")
+}
+
+func TestRunnerDoesNotWaitForCommands(t *testing.T) {
+	root := t.TempDir()
+	runner := NewRunner()
+
+	started := time.Now()
+	runner.Queue(Hook{Event: Finished, Command: "sleep 1; echo slow >> out.txt"}, root, nil)
+	runner.Queue(Hook{Event: Finished, Command: "echo fast >> out.txt"}, root, nil)
+	if elapsed := time.Since(started); elapsed > 500*time.Millisecond {
+		t.Errorf("Queue() took %s, want it not to wait for the command", elapsed)
+	}
+
+	runner.Wait()
+	assertContents(t, filepath.Join(root, "out.txt"), "slow\nfast\n")
+}
+
+func TestRunnerCancel(t *testing.T) {
+	root := t.TempDir()
+	runner := NewRunner()
+
+	runner.Queue(Hook{Event: Finished, Command: "sleep 10"}, root, nil)
+	runner.Queue(Hook{Event: Finished, Command: "echo skipped >> out.txt"}, root, nil)
+	time.Sleep(100 * time.Millisecond)
+
+	started := time.Now()
+	runner.Cancel()
+	runner.Wait()
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Cancel() stopped the command after %s, want it stopped at once", elapsed)
+	}
+	if _, err := os.Stat(filepath.Join(root, "out.txt")); err == nil {
+		t.Errorf("the command queued before Cancel() ran, want it skipped")
+	}
+
+	// Hooks queued afterwards run as usual.
+	runner.Queue(Hook{Event: Finished, Command: "echo ran >> out.txt"}, root, nil)
+	runner.Wait()
+	assertContents(t, filepath.Join(root, "out.txt"), "ran\n")
+}
+
+func TestRunTimeout(t *testing.T) {
+	hook := Hook{Event: Finished, Timeout: 100 * time.Millisecond, Command: "sleep 10"}
+
+	runErr := hook.Run(context.Background(), t.TempDir(), nil)
+	if runErr == nil || !strings.Contains(runErr.Error(), "timed out") {
+		t.Errorf("Run() = %v, want it to time out", runErr)
+	}
+}
+
+// assertContents is a helper function that fails the test if the file at filePath does not have the contents.
+func assertContents(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	if string(data) != contents {
+		t.Errorf("%s = %q, want %q", filePath, data, contents)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package hooks
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a helper function that does nothing on Windows, where only the command itself
+// is stopped. The processes it started are given up on after WAIT_DELAY.
+func setProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,83 @@
+package hooks
+
+import (
+	"context"
+	"sync"
+
+	logs "github.com/beebeeoii/lominus/internal/log"
+)
+
+// MAX_QUEUED is how many commands may wait to run. Hooks queued while that many are waiting are skipped.
+const MAX_QUEUED = 100
+
+// Runner struct runs the commands of queued hooks in the background, one at a time and in the order
+// they are queued, such that a slow command does not hold up the sync that queued it.
+type Runner struct {
+	jobs    chan job
+	pending sync.WaitGroup
+
+	mutex  sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// job struct is the datapack containing a queued hook and how to run it.
+type job struct {
+	ctx               context.Context
+	hook              Hook
+	rootSyncDirectory string
+	env               []string
+}
+
+// NewRunner returns a Runner whose commands start running as soon as they are queued.
+func NewRunner() *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	runner := &Runner{jobs: make(chan job, MAX_QUEUED), ctx: ctx, cancel: cancel}
+
+	go runner.work()
+
+	return runner
+}
+
+// Queue queues the hook to run from rootSyncDirectory with env added to its environment, and returns
+// without waiting for it. The hook is skipped if MAX_QUEUED commands are already waiting.
+func (runner *Runner) Queue(hook Hook, rootSyncDirectory string, env []string) {
+	runner.mutex.Lock()
+	ctx := runner.ctx
+	runner.mutex.Unlock()
+
+	runner.pending.Add(1)
+	select {
+	case runner.jobs <- job{ctx: ctx, hook: hook, rootSyncDirectory: rootSyncDirectory, env: env}:
+	default:
+		runner.pending.Done()
+		logs.Logger.Warnf("hook skipped as %d commands are waiting to run - %s", MAX_QUEUED, hook.String())
+	}
+}
+
+// Cancel stops the command running and skips the commands waiting to run. Hooks queued afterwards run as usual.
+func (runner *Runner) Cancel() {
+	runner.mutex.Lock()
+	defer runner.mutex.Unlock()
+
+	runner.cancel()
+	runner.ctx, runner.cancel = context.WithCancel(context.Background())
+}
+
+// Wait waits for the commands queued so far to run, or to be skipped.
+func (runner *Runner) Wait() {
+	runner.pending.Wait()
+}
+
+// work is a helper function that runs the queued commands until the program exits.
+func (runner *Runner) work() {
+	for job := range runner.jobs {
+		if job.ctx.Err() != nil {
+			logs.Logger.Infof("hook skipped as it was cancelled - %s", job.hook.String())
+		} else {
+			job.hook.Run(job.ctx, job.rootSyncDirectory, job.env)
+		}
+
+		runner.pending.Done()
+	}
+}
@@ -2,38 +2,51 @@
 package indexing
 
 import (
-	"encoding/csv"
-	"io"
+	"encoding/json"
 	"os"
 	"path/filepath"
-	"strconv"
 	"strings"
 
-	appDir "github.com/beebeeoii/lominus/internal/app/dir"
+	"github.com/beebeeoii/lominus/internal/app"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
 	logs "github.com/beebeeoii/lominus/internal/log"
 	"github.com/beebeeoii/lominus/pkg/api"
+	"github.com/boltdb/bolt"
 )
 
 // IndexMap struct contains an array of IndexMapEntry.
-// It is used to create a .csv IndexMap file for file comparison during syncs.
+// It is persisted after every sync to track downloaded files by their Id, such that
+// files renamed or moved remotely can be renamed or moved locally instead of being downloaded again.
 type IndexMap struct {
 	Entries []IndexMapEntry
 }
 
-// IndexMapEntry struct contains the file Id, name and last updated (unix).
+// IndexMapEntry struct contains the file Id, name, path, last updated (unix) and hash.
+// LastUpdated is the time the file was last modified on the LMS when it was downloaded.
+// Path is the path of the file relative to the root sync directory, eg. CS2040/Lectures/Lecture1.pdf.
+// OriginalName is the name of the file on the LMS, which FileName is derived from.
+// Hash is the hash of the file's contents when it was downloaded, used to detect local modifications.
 // These are the data used for file comparison during syncs.
 type IndexMapEntry struct {
-	Id          string
-	FileName    string
-	LastUpdated int64
+	Id           string
+	FileName     string
+	OriginalName string
+	Path         string
+	LastUpdated  int64
+	Hash         string
 }
 
-const INDEX_MAP_FILE_NAME = "index_map.csv"
+const INDEX_MAP_BUCKET_NAME = "Index"
+
+func init() {
+	app.RegisterBuckets(INDEX_MAP_BUCKET_NAME)
+}
 
 // Build is used to create a map of the current files on the local desktop.
 // The built map will be used to compare with the IndexMap to determine whether a file
 // needs to be downloaded or updated.
 // The map's key format is as follows: the/ancestors/of/the/file/fileName.pdf
+// Folders used by Lominus itself, such as the trash and versions folders, are not included.
 func Build(dir string) (map[string]api.File, error) {
 	filesMap := make(map[string]api.File)
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
@@ -45,6 +58,10 @@ func Build(dir string) (map[string]api.File, error) {
 			return nil
 		}
 
+		if info.IsDir() && isReservedDir(info.Name()) {
+			return filepath.SkipDir
+		}
+
 		if !info.IsDir() {
 			ancestors := strings.Split(path[len(dir)+1:], string(os.PathSeparator))
 			key := strings.ToLower(strings.Join(ancestors, "/"))
@@ -60,63 +77,77 @@ func Build(dir string) (map[string]api.File, error) {
 	return filesMap, err
 }
 
-// CreateIndexMap writes the IndexMap to a csv file for which can be loaded for the next sync.
-func CreateIndexMap(indexMap IndexMap) error {
-	logs.Logger.Infof("Creating index map: %s", INDEX_MAP_FILE_NAME)
+// isReservedDir is a helper function that checks whether the folder is used by Lominus itself
+// and should not be synced.
+func isReservedDir(name string) bool {
+	return name == appConstants.TRASH_DIR_NAME || name == appConstants.VERSIONS_DIR_NAME
+}
 
-	indexMapPath, getIndexMapPathErr := getIndexMapPath()
-	if getIndexMapPathErr != nil {
-		return getIndexMapPathErr
-	}
+// SaveIndexMap persists the IndexMap which can be loaded for the next sync.
+// Existing entries are replaced.
+func SaveIndexMap(indexMap IndexMap) error {
+	logs.Logger.Debugf("saving index map: %d entries", len(indexMap.Entries))
+	dbInstance := app.GetDBInstance()
 
-	indexMapFile, _ := os.Create(indexMapPath)
-	w := csv.NewWriter(indexMapFile)
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte(INDEX_MAP_BUCKET_NAME)) != nil {
+			if deleteErr := tx.DeleteBucket([]byte(INDEX_MAP_BUCKET_NAME)); deleteErr != nil {
+				return deleteErr
+			}
+		}
 
-	for _, entry := range indexMap.Entries {
-		err := w.Write([]string{entry.Id, entry.FileName, strconv.FormatInt(entry.LastUpdated, 10)}) //{[id], [fileName], [lastUpdated]}
-		if err != nil {
-			return err
+		indexBucket, createErr := tx.CreateBucket([]byte(INDEX_MAP_BUCKET_NAME))
+		if createErr != nil {
+			return createErr
+		}
+
+		for _, entry := range indexMap.Entries {
+			value, marshalErr := json.Marshal(entry)
+			if marshalErr != nil {
+				return marshalErr
+			}
+
+			if putErr := indexBucket.Put([]byte(entry.Id), value); putErr != nil {
+				return putErr
+			}
 		}
-	}
-	w.Flush()
 
-	logs.Logger.Infoln("Index map created successfully.")
-	return nil
+		return nil
+	})
 }
 
-// LoadIndexMap loads the IndexMap csv file back to a map of IndexMapEntry, with the key being the file Id.
-func LoadIndexMap(file io.Reader) (map[string]IndexMapEntry, error) {
-	logs.Logger.Infof("Loading index map: %s", INDEX_MAP_FILE_NAME)
-	r := csv.NewReader(file)
+// LoadIndexMap loads the persisted IndexMap back to a map of IndexMapEntry, with the key being the file Id.
+func LoadIndexMap() (map[string]IndexMapEntry, error) {
 	indexMap := map[string]IndexMapEntry{}
+	dbInstance := app.GetDBInstance()
 
-	for {
-		record, err := r.Read() //record: {[id], [fileName], [lastUpdated]}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return indexMap, err
+	err := dbInstance.View(func(tx *bolt.Tx) error {
+		indexBucket := tx.Bucket([]byte(INDEX_MAP_BUCKET_NAME))
+		if indexBucket == nil {
+			return nil
 		}
 
-		id := record[0]
-		fileName := record[1]
-		lastUpdated, _ := strconv.ParseInt(record[2], 10, 64)
-		indexMap[id] = IndexMapEntry{Id: id, FileName: fileName, LastUpdated: lastUpdated}
-	}
-	return indexMap, nil
+		return indexBucket.ForEach(func(k, v []byte) error {
+			entry := IndexMapEntry{}
+			if unmarshalErr := json.Unmarshal(v, &entry); unmarshalErr != nil {
+				return unmarshalErr
+			}
+
+			indexMap[string(k)] = entry
+			return nil
+		})
+	})
+
+	return indexMap, err
 }
 
-// getIndexMapPath returns the file path to the IndexMap csv file.
-func getIndexMapPath() (string, error) {
-	var indexMapPath string
+// ToIndexMap converts a map of IndexMapEntry, keyed by the file Id, back to an IndexMap.
+func ToIndexMap(entries map[string]IndexMapEntry) IndexMap {
+	indexMap := IndexMap{Entries: []IndexMapEntry{}}
 
-	baseDir, retrieveBaseDirErr := appDir.GetBaseDir()
-	if retrieveBaseDirErr != nil {
-		return indexMapPath, retrieveBaseDirErr
+	for _, entry := range entries {
+		indexMap.Entries = append(indexMap.Entries, entry)
 	}
 
-	indexMapPath = filepath.Join(baseDir, INDEX_MAP_FILE_NAME)
-
-	return indexMapPath, nil
+	return indexMap
 }
@@ -0,0 +1,229 @@
+// Package schedule provides primitives to describe and validate when automatic syncs run.
+//
+// A schedule is written as one of:
+//
+//	disabled
+//	every <duration>      eg. every 30m, every 2h
+//	cron <expression>     eg. cron 0 9 * * 1-5
+//	daily <times>         eg. daily 08:00;18:30
+//
+// Quiet hours are written as <start>-<end>, eg. 23:00-07:00.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Kind describes how a schedule decides when syncs run.
+type Kind string
+
+// Schedule kinds
+const (
+	KindDisabled Kind = "disabled"
+	KindEvery    Kind = "every"
+	KindCron     Kind = "cron"
+	KindDaily    Kind = "daily"
+)
+
+// TIME_FORMAT is the format of the times of daily schedules and quiet hours.
+const TIME_FORMAT = "15:04"
+
+// MIN_INTERVAL is the shortest interval allowed between syncs, to avoid overloading the LMS.
+const MIN_INTERVAL = 5 * time.Minute
+
+// Schedule struct describes when automatic syncs run.
+// Interval is only used by KindEvery, Cron by KindCron and Times by KindDaily.
+type Schedule struct {
+	Kind     Kind
+	Interval time.Duration
+	Cron     string
+	Times    []string
+}
+
+// QuietHours struct describes the time of the day during which no sync or notification runs.
+// Start may be after End, in which case the quiet hours span midnight.
+// QuietHours with empty Start and End are not applied.
+type QuietHours struct {
+	Start string
+	End   string
+}
+
+// Disabled is the Schedule of users who only sync manually.
+var Disabled = Schedule{Kind: KindDisabled}
+
+// FromFrequency returns the Schedule equivalent to the legacy sync frequency in hours,
+// where -1 means that automatic syncs are disabled.
+func FromFrequency(frequency int) Schedule {
+	if frequency <= 0 {
+		return Disabled
+	}
+
+	return Schedule{Kind: KindEvery, Interval: time.Duration(frequency) * time.Hour}
+}
+
+// Parse parses and validates the text representation of a Schedule.
+func Parse(text string) (Schedule, error) {
+	kind, value, _ := strings.Cut(strings.TrimSpace(text), " ")
+	value = strings.TrimSpace(value)
+
+	switch Kind(strings.ToLower(kind)) {
+	case KindDisabled, "":
+		return Disabled, nil
+	case KindEvery:
+		interval, durationErr := time.ParseDuration(value)
+		if durationErr != nil {
+			return Schedule{}, fmt.Errorf("invalid interval %q, use eg. 30m or 2h", value)
+		}
+
+		if interval < MIN_INTERVAL {
+			return Schedule{}, fmt.Errorf("interval %s is shorter than %s", interval, MIN_INTERVAL)
+		}
+
+		if interval%time.Minute != 0 {
+			return Schedule{}, fmt.Errorf("interval %s is not in whole minutes", interval)
+		}
+
+		return Schedule{Kind: KindEvery, Interval: interval}, nil
+	case KindCron:
+		if _, cronErr := cron.ParseStandard(value); cronErr != nil {
+			return Schedule{}, fmt.Errorf("invalid cron expression %q, %s", value, cronErr.Error())
+		}
+
+		if strings.HasPrefix(value, "TZ=") || strings.HasPrefix(value, "CRON_TZ=") {
+			return Schedule{}, fmt.Errorf("time zones are not supported in cron expressions")
+		}
+
+		if strings.HasPrefix(value, "@every") {
+			return Schedule{}, fmt.Errorf("use the every schedule for intervals")
+		}
+
+		return Schedule{Kind: KindCron, Cron: value}, nil
+	case KindDaily:
+		times := []string{}
+		for _, t := range strings.Split(value, ";") {
+			t = strings.TrimSpace(t)
+			if t == "" {
+				continue
+			}
+
+			if _, timeErr := time.Parse(TIME_FORMAT, t); timeErr != nil {
+				return Schedule{}, fmt.Errorf("invalid time %q, use eg. 08:00", t)
+			}
+			times = append(times, t)
+		}
+
+		if len(times) == 0 {
+			return Schedule{}, fmt.Errorf("no time given, use eg. 08:00;18:30")
+		}
+
+		return Schedule{Kind: KindDaily, Times: times}, nil
+	default:
+		return Schedule{}, fmt.Errorf("unknown schedule %q, use disabled, every, cron or daily", kind)
+	}
+}
+
+// IsDisabled returns whether automatic syncs are disabled.
+func (schedule Schedule) IsDisabled() bool {
+	return schedule.Kind == KindDisabled || schedule.Kind == ""
+}
+
+// At returns the times of a daily schedule in the format accepted by gocron.
+func (schedule Schedule) At() string {
+	return strings.Join(schedule.Times, ";")
+}
+
+// String returns the text representation of the Schedule, which can be parsed by Parse.
+func (schedule Schedule) String() string {
+	switch schedule.Kind {
+	case KindEvery:
+		return fmt.Sprintf("%s %s", KindEvery, formatInterval(schedule.Interval))
+	case KindCron:
+		return fmt.Sprintf("%s %s", KindCron, schedule.Cron)
+	case KindDaily:
+		return fmt.Sprintf("%s %s", KindDaily, schedule.At())
+	default:
+		return string(KindDisabled)
+	}
+}
+
+// ParseQuietHours parses and validates the text representation of QuietHours.
+// An empty text means that there are no quiet hours.
+func ParseQuietHours(text string) (QuietHours, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return QuietHours{}, nil
+	}
+
+	start, end, found := strings.Cut(text, "-")
+	if !found {
+		return QuietHours{}, fmt.Errorf("invalid quiet hours %q, use eg. 23:00-07:00", text)
+	}
+
+	quietHours := QuietHours{Start: strings.TrimSpace(start), End: strings.TrimSpace(end)}
+	for _, t := range []string{quietHours.Start, quietHours.End} {
+		if _, timeErr := time.Parse(TIME_FORMAT, t); timeErr != nil {
+			return QuietHours{}, fmt.Errorf("invalid time %q, use eg. 23:00", t)
+		}
+	}
+
+	if quietHours.Start == quietHours.End {
+		return QuietHours{}, fmt.Errorf("quiet hours start and end at the same time")
+	}
+
+	return quietHours, nil
+}
+
+// IsZero returns whether there are no quiet hours.
+func (quietHours QuietHours) IsZero() bool {
+	return quietHours.Start == "" && quietHours.End == ""
+}
+
+// Contains returns whether t falls within the quiet hours, in its own location.
+func (quietHours QuietHours) Contains(t time.Time) bool {
+	if quietHours.IsZero() {
+		return false
+	}
+
+	start, startErr := time.Parse(TIME_FORMAT, quietHours.Start)
+	end, endErr := time.Parse(TIME_FORMAT, quietHours.End)
+	if startErr != nil || endErr != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+
+	if startMinute < endMinute {
+		return minute >= startMinute && minute < endMinute
+	}
+
+	return minute >= startMinute || minute < endMinute
+}
+
+// String returns the text representation of the QuietHours, which can be parsed by ParseQuietHours.
+func (quietHours QuietHours) String() string {
+	if quietHours.IsZero() {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-%s", quietHours.Start, quietHours.End)
+}
+
+// formatInterval is a helper function that formats the interval without trailing zero units,
+// eg. 2h instead of 2h0m0s.
+func formatInterval(interval time.Duration) string {
+	text := interval.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+
+	return text
+}
@@ -0,0 +1,111 @@
+package schedule
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		text string
+		want Schedule
+	}{
+		{"", Disabled},
+		{"disabled", Disabled},
+		{"every 30m", Schedule{Kind: KindEvery, Interval: 30 * time.Minute}},
+		{"EVERY 2h", Schedule{Kind: KindEvery, Interval: 2 * time.Hour}},
+		{"cron 0 9 * * 1-5", Schedule{Kind: KindCron, Cron: "0 9 * * 1-5"}},
+		{"daily 08:00; 18:30;", Schedule{Kind: KindDaily, Times: []string{"08:00", "18:30"}}},
+	}
+
+	for _, test := range tests {
+		got, err := Parse(test.text)
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) = %+v, %v, want %+v", test.text, got, err, test.want)
+		}
+
+		if again, err := Parse(got.String()); err != nil || !reflect.DeepEqual(again, got) {
+			t.Errorf("Parse(%q) = %+v, %v, want it to round trip", got.String(), again, err)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"every",
+		"every soon",
+		"every 1m",
+		"every 5m30s",
+		"cron * *",
+		"cron CRON_TZ=Asia/Singapore 0 9 * * *",
+		"cron @every 1h",
+		"daily",
+		"daily 25:00",
+		"hourly",
+	}
+
+	for _, text := range tests {
+		if got, err := Parse(text); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", text, got)
+		}
+	}
+}
+
+func TestFromFrequency(t *testing.T) {
+	if got := FromFrequency(-1); !got.IsDisabled() {
+		t.Errorf("FromFrequency(-1) = %+v, want it disabled", got)
+	}
+
+	if got := FromFrequency(2); got.String() != "every 2h" {
+		t.Errorf("FromFrequency(2) = %s, want every 2h", got)
+	}
+}
+
+func TestQuietHoursContains(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 3, 1, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		quietHours string
+		t          time.Time
+		want       bool
+	}{
+		// Quiet hours within a day
+		{"13:00-14:00", at(12, 59), false},
+		{"13:00-14:00", at(13, 0), true},
+		{"13:00-14:00", at(13, 59), true},
+		{"13:00-14:00", at(14, 0), false},
+
+		// Quiet hours across midnight
+		{"23:00-07:00", at(22, 59), false},
+		{"23:00-07:00", at(23, 0), true},
+		{"23:00-07:00", at(0, 0), true},
+		{"23:00-07:00", at(6, 59), true},
+		{"23:00-07:00", at(7, 0), false},
+		{"23:00-07:00", at(12, 0), false},
+
+		// No quiet hours
+		{"", at(0, 0), false},
+	}
+
+	for _, test := range tests {
+		quietHours, err := ParseQuietHours(test.quietHours)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := quietHours.Contains(test.t); got != test.want {
+			t.Errorf("QuietHours(%q).Contains(%s) = %t, want %t", test.quietHours, test.t.Format(TIME_FORMAT), got, test.want)
+		}
+	}
+}
+
+func TestParseQuietHoursErrors(t *testing.T) {
+	for _, text := range []string{"23:00", "23:00-", "11pm-7am", "07:00-07:00"} {
+		if got, err := ParseQuietHours(text); err == nil {
+			t.Errorf("ParseQuietHours(%q) = %+v, want an error", text, got)
+		}
+	}
+}
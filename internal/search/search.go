@@ -0,0 +1,520 @@
+// Package search provides primitives to index the text of synced files and to search them.
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/beebeeoii/lominus/internal/app"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/boltdb/bolt"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Document struct describes a file in the search index.
+// Path is the path of the file relative to the root sync directory, eg. CS2040/Lectures/Lecture1.pdf.
+// ModTime and Size are those of the file when it was indexed, used to tell whether it changed since.
+// Terms are the terms of the file, used to remove them from the index. The text of the file is not stored:
+// Text is only set for files indexed before it was dropped from the index, which are indexed again.
+type Document struct {
+	Path    string
+	Module  string
+	ModTime time.Time
+	Size    int64
+	Terms   []string
+	Text    string `json:",omitempty"`
+}
+
+// Hit struct describes a file matching a query.
+// Offset is the byte offset of the first match in the lower case text of the file, and Score the number of matches.
+// Snippet is the text around the first match, which is loaded by LoadSnippet.
+type Hit struct {
+	Module  string
+	Path    string
+	Offset  int
+	Score   int
+	Snippet string
+}
+
+// DOCUMENTS_BUCKET_NAME is the name of the bucket the documents are stored in, keyed by their path.
+const DOCUMENTS_BUCKET_NAME = "SearchDocuments"
+
+// TERMS_BUCKET_NAME is the name of the bucket the terms are stored in, keyed by the term and the path
+// of a document containing it, separated by TERM_SEPARATOR. The value is the number of times the term
+// occurs in the document followed by the byte offset of its first occurrence, as uvarints.
+const TERMS_BUCKET_NAME = "SearchTerms"
+
+func init() {
+	app.RegisterBuckets(DOCUMENTS_BUCKET_NAME, TERMS_BUCKET_NAME)
+}
+
+const TERM_SEPARATOR = "\x00"
+
+// MAX_TEXT_LENGTH is the number of bytes of the text of every file that is indexed.
+const MAX_TEXT_LENGTH = 1 << 20
+
+// MIN_TERM_LENGTH is the length in characters of the shortest term that is indexed.
+const MIN_TERM_LENGTH = 2
+
+// SNIPPET_LENGTH is the number of characters shown before and after the first match.
+const SNIPPET_LENGTH = 60
+
+// BATCH_SIZE is the number of files written to the index in a single transaction.
+const BATCH_SIZE = 50
+
+// termStats struct describes the occurrences of a term in a document.
+type termStats struct {
+	count  int
+	offset int
+}
+
+// pendingDocument struct is the datapack containing a document to write to the index, and the same file
+// when it was last indexed, if any.
+type pendingDocument struct {
+	document Document
+	previous Document
+	stats    map[string]termStats
+}
+
+// Update brings the search index up to date with the files in the root sync directory.
+// Only the files added or changed since they were last indexed are read, and the files that
+// are gone are removed from the index. Hidden files and folders, such as the trash and versions
+// folders, and the temporary files of downloads and extractions are not indexed.
+// Files are written to the index BATCH_SIZE at a time. Cancelling ctx stops the update after the files
+// indexed so far are written. It returns the number of files indexed.
+func Update(ctx context.Context, rootSyncDirectory string) (int, error) {
+	documents, loadErr := loadDocuments()
+	if loadErr != nil {
+		return 0, loadErr
+	}
+
+	found := map[string]bool{}
+	pending := []pendingDocument{}
+	indexed := 0
+
+	walkErr := filepath.Walk(rootSyncDirectory, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+
+		if filePath == rootSyncDirectory {
+			return nil
+		}
+
+		if strings.HasPrefix(info.Name(), ".") || isTempFile(info.Name()) {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		// Files directly in the root sync directory do not belong to a module.
+		if info.IsDir() || filepath.Dir(filePath) == rootSyncDirectory || !CanExtractText(info.Name()) {
+			return nil
+		}
+
+		key := filepath.ToSlash(filePath[len(rootSyncDirectory)+1:])
+		found[key] = true
+
+		previous, exists := documents[key]
+		if exists && previous.Text == "" && previous.ModTime.Equal(info.ModTime()) && previous.Size == info.Size() {
+			return nil
+		}
+
+		text, extractErr := ExtractText(filePath)
+		if extractErr != nil {
+			// The file is still indexed, such that it is not read again until it changes.
+			logs.Logger.Debugf("unable to extract text - %s: %s", key, extractErr.Error())
+		}
+
+		terms, stats := getTermStats(text)
+		pending = append(pending, pendingDocument{
+			document: Document{
+				Path:    key,
+				Module:  strings.Split(key, "/")[0],
+				ModTime: info.ModTime(),
+				Size:    info.Size(),
+				Terms:   terms,
+			},
+			previous: previous,
+			stats:    stats,
+		})
+
+		if len(pending) < BATCH_SIZE {
+			return nil
+		}
+
+		if putErr := putDocuments(pending); putErr != nil {
+			return putErr
+		}
+		indexed += len(pending)
+		pending = pending[:0]
+
+		return nil
+	})
+
+	if putErr := putDocuments(pending); putErr != nil {
+		return indexed, putErr
+	}
+	indexed += len(pending)
+
+	if walkErr != nil {
+		return indexed, walkErr
+	}
+
+	gone := []Document{}
+	for key, document := range documents {
+		if !found[key] {
+			gone = append(gone, document)
+		}
+	}
+
+	if deleteErr := deleteDocuments(gone); deleteErr != nil {
+		return indexed, deleteErr
+	}
+
+	logs.Logger.Debugf("search index updated: %d files indexed, %d files in total", indexed, len(found))
+	return indexed, nil
+}
+
+// LoadSnippet sets the Snippet of the hit to the text around its first match, read from the file
+// in the root sync directory. The Snippet is left empty if the text of the file cannot be extracted.
+func LoadSnippet(rootSyncDirectory string, hit *Hit) {
+	text, extractErr := ExtractText(filepath.Join(rootSyncDirectory, filepath.FromSlash(hit.Path)))
+	if extractErr != nil {
+		logs.Logger.Debugf("unable to extract text - %s: %s", hit.Path, extractErr.Error())
+		return
+	}
+
+	hit.Snippet = getSnippet(strings.ToLower(norm.NFC.String(text)), text, hit.Offset)
+}
+
+// Query returns the files containing every word of the query, most matches first, up to limit files.
+// Words match the beginning of words in the files regardless of case, eg. "dijk" matches "Dijkstra".
+// A limit that is not positive returns every file. The Snippet of the hits is left to LoadSnippet.
+func Query(query string, limit int) ([]Hit, error) {
+	hits := []Hit{}
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return hits, nil
+	}
+
+	dbInstance := app.GetDBInstance()
+	err := dbInstance.View(func(tx *bolt.Tx) error {
+		termsBucket := tx.Bucket([]byte(TERMS_BUCKET_NAME))
+		if termsBucket == nil {
+			return nil
+		}
+
+		var matches map[string]termStats
+		for _, term := range terms {
+			termMatches := getTermMatches(termsBucket, term)
+			if matches == nil {
+				matches = termMatches
+				continue
+			}
+
+			for key, stats := range matches {
+				termMatch, found := termMatches[key]
+				if !found {
+					delete(matches, key)
+					continue
+				}
+
+				matches[key] = termStats{count: stats.count + termMatch.count, offset: min(stats.offset, termMatch.offset)}
+			}
+		}
+
+		for key, stats := range matches {
+			hits = append(hits, Hit{
+				Module: strings.Split(key, "/")[0],
+				Path:   key,
+				Offset: stats.offset,
+				Score:  stats.count,
+			})
+		}
+
+		return nil
+	})
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Path < hits[j].Path
+	})
+
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+
+	return hits, err
+}
+
+// Clear removes every file from the search index, such that the next Update indexes every file again.
+func Clear() error {
+	dbInstance := app.GetDBInstance()
+
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		for _, bucketName := range []string{DOCUMENTS_BUCKET_NAME, TERMS_BUCKET_NAME} {
+			if tx.Bucket([]byte(bucketName)) == nil {
+				continue
+			}
+
+			if deleteErr := tx.DeleteBucket([]byte(bucketName)); deleteErr != nil {
+				return deleteErr
+			}
+		}
+
+		return nil
+	})
+}
+
+// loadDocuments is a helper function that loads the documents in the index, keyed by their path.
+func loadDocuments() (map[string]Document, error) {
+	documents := map[string]Document{}
+	dbInstance := app.GetDBInstance()
+
+	err := dbInstance.View(func(tx *bolt.Tx) error {
+		documentsBucket := tx.Bucket([]byte(DOCUMENTS_BUCKET_NAME))
+		if documentsBucket == nil {
+			return nil
+		}
+
+		return documentsBucket.ForEach(func(k, v []byte) error {
+			document := Document{}
+			if unmarshalErr := json.Unmarshal(v, &document); unmarshalErr != nil {
+				return unmarshalErr
+			}
+
+			documents[string(k)] = document
+			return nil
+		})
+	})
+
+	return documents, err
+}
+
+// putDocuments is a helper function that adds the documents to the index in a single transaction,
+// replacing the same files when they were last indexed, if any.
+func putDocuments(pending []pendingDocument) error {
+	if len(pending) == 0 {
+		return nil
+	}
+
+	dbInstance := app.GetDBInstance()
+
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		documentsBucket, documentsBucketErr := tx.CreateBucketIfNotExists([]byte(DOCUMENTS_BUCKET_NAME))
+		if documentsBucketErr != nil {
+			return documentsBucketErr
+		}
+
+		termsBucket, termsBucketErr := tx.CreateBucketIfNotExists([]byte(TERMS_BUCKET_NAME))
+		if termsBucketErr != nil {
+			return termsBucketErr
+		}
+
+		for _, p := range pending {
+			if deleteErr := deleteTerms(termsBucket, p.previous); deleteErr != nil {
+				return deleteErr
+			}
+
+			for _, term := range p.document.Terms {
+				value := binary.AppendUvarint(nil, uint64(p.stats[term].count))
+				value = binary.AppendUvarint(value, uint64(p.stats[term].offset))
+				if putErr := termsBucket.Put(getTermKey(term, p.document.Path), value); putErr != nil {
+					return putErr
+				}
+			}
+
+			value, marshalErr := json.Marshal(p.document)
+			if marshalErr != nil {
+				return marshalErr
+			}
+
+			if putErr := documentsBucket.Put([]byte(p.document.Path), value); putErr != nil {
+				return putErr
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteDocuments is a helper function that removes the documents from the index in a single transaction.
+func deleteDocuments(documents []Document) error {
+	if len(documents) == 0 {
+		return nil
+	}
+
+	dbInstance := app.GetDBInstance()
+
+	return dbInstance.Update(func(tx *bolt.Tx) error {
+		documentsBucket := tx.Bucket([]byte(DOCUMENTS_BUCKET_NAME))
+		termsBucket := tx.Bucket([]byte(TERMS_BUCKET_NAME))
+		if documentsBucket == nil || termsBucket == nil {
+			return nil
+		}
+
+		for _, document := range documents {
+			if deleteErr := deleteTerms(termsBucket, document); deleteErr != nil {
+				return deleteErr
+			}
+
+			if deleteErr := documentsBucket.Delete([]byte(document.Path)); deleteErr != nil {
+				return deleteErr
+			}
+		}
+
+		return nil
+	})
+}
+
+// deleteTerms is a helper function that removes the terms of the document from the index.
+func deleteTerms(termsBucket *bolt.Bucket, document Document) error {
+	terms := document.Terms
+	if document.Text != "" {
+		terms, _ = getTermStats(document.Text)
+	}
+
+	for _, term := range terms {
+		if deleteErr := termsBucket.Delete(getTermKey(term, document.Path)); deleteErr != nil {
+			return deleteErr
+		}
+	}
+
+	return nil
+}
+
+// getTermMatches is a helper function that returns the occurrences of the terms that start with the
+// given term, keyed by the paths of the documents containing them.
+func getTermMatches(termsBucket *bolt.Bucket, term string) map[string]termStats {
+	matches := map[string]termStats{}
+	prefix := []byte(term)
+
+	cursor := termsBucket.Cursor()
+	for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+		_, key, found := strings.Cut(string(k), TERM_SEPARATOR)
+		if !found {
+			continue
+		}
+
+		count, n := binary.Uvarint(v)
+		offset, _ := binary.Uvarint(v[max(n, 0):])
+		if n <= 0 {
+			// Terms indexed before their occurrences were stored only tell that the document contains them.
+			count, offset = 1, 0
+		}
+
+		stats, matched := matches[key]
+		if !matched {
+			stats.offset = int(offset)
+		}
+		matches[key] = termStats{count: stats.count + int(count), offset: min(stats.offset, int(offset))}
+	}
+
+	return matches
+}
+
+// getTermKey is a helper function that returns the key the term is stored with for the document at path.
+func getTermKey(term string, path string) []byte {
+	return []byte(term + TERM_SEPARATOR + path)
+}
+
+// getSnippet is a helper function that returns the text around the byte offset of the first match
+// in lowerText, taken from text with whitespace collapsed. The start of the text is returned if
+// the offset is not known.
+func getSnippet(lowerText string, text string, offset int) string {
+	// Lower casing can change the length of some characters, in which case the snippet is taken from lowerText.
+	source := text
+	if len(lowerText) != len(text) {
+		source = lowerText
+	}
+
+	runes := []rune(source)
+	runeOffset := 0
+	if offset > 0 {
+		runeOffset = len([]rune(source[:offset]))
+	}
+
+	start := max(runeOffset-SNIPPET_LENGTH, 0)
+	end := min(runeOffset+SNIPPET_LENGTH, len(runes))
+	snippet := strings.Join(strings.Fields(string(runes[start:end])), " ")
+
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(runes) {
+		snippet = snippet + "..."
+	}
+
+	return snippet
+}
+
+// tokenize is a helper function that splits the text into its distinct terms, in lower case.
+// Terms shorter than MIN_TERM_LENGTH characters are left out.
+func tokenize(text string) []string {
+	terms, _ := getTermStats(text)
+	return terms
+}
+
+// getTermStats is a helper function that splits the text into its distinct terms, in lower case and
+// in the order they first occur, and counts their occurrences. Offsets are in bytes of the lower case text.
+// Terms shorter than MIN_TERM_LENGTH characters are left out.
+func getTermStats(text string) ([]string, map[string]termStats) {
+	terms := []string{}
+	stats := map[string]termStats{}
+
+	lowerText := strings.ToLower(norm.NFC.String(text))
+	start := -1
+	addTerm := func(end int) {
+		word := lowerText[start:end]
+		start = -1
+		if utf8.RuneCountInString(word) < MIN_TERM_LENGTH {
+			return
+		}
+
+		wordStats, seen := stats[word]
+		if !seen {
+			terms = append(terms, word)
+			wordStats.offset = end - len(word)
+		}
+		wordStats.count++
+		stats[word] = wordStats
+	}
+
+	for i, r := range lowerText {
+		isWordRune := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordRune && start < 0 {
+			start = i
+		} else if !isWordRune && start >= 0 {
+			addTerm(i)
+		}
+	}
+	if start >= 0 {
+		addTerm(len(lowerText))
+	}
+
+	return terms, stats
+}
+
+// isTempFile is a helper function that checks whether the file or folder is left by a download or an
+// extraction in progress, which are hidden too.
+func isTempFile(name string) bool {
+	return strings.HasSuffix(name, appConstants.DOWNLOAD_TEMP_SUFFIX) || strings.HasPrefix(name, appConstants.EXTRACT_TEMP_DIR_PREFIX)
+}
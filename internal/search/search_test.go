@@ -0,0 +1,153 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/beebeeoii/lominus/internal/app"
+)
+
+func TestMain(m *testing.M) {
+	os.Exit(runWithTestDB(m))
+}
+
+func TestUpdateAndQuery(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() { Clear() })
+
+	writeFile(t, filepath.Join(root, "CS2040", "Lectures", "Graphs.txt"), "Shortest paths with Dijkstra.\nDijkstra's algorithm needs non-negative weights.")
+	writeFile(t, filepath.Join(root, "CS2040", "Lectures", "Sorting.md"), "# Sorting\nMerge sort and quick sort.")
+	writeFile(t, filepath.Join(root, "CS2030S", "Notes.txt"), "Streams are lazy, unlike Dijkstra.")
+	writeFile(t, filepath.Join(root, "CS2030S", "Notes.pptx.bin"), "Dijkstra in a file whose text cannot be extracted")
+
+	// Hidden files, downloads and extractions in progress are not indexed.
+	writeFile(t, filepath.Join(root, "CS2040", ".Graphs.txt.123.part"), "Dijkstra")
+	writeFile(t, filepath.Join(root, "CS2040", "Slides.txt.part"), "Dijkstra")
+	writeFile(t, filepath.Join(root, "CS2040", ".lominus-extract-123", "Graphs.txt"), "Dijkstra")
+	writeFile(t, filepath.Join(root, "CS2040", ".versions", "Graphs.20240301-134500.txt"), "Dijkstra")
+
+	indexed, updateErr := Update(context.Background(), root)
+	if updateErr != nil || indexed != 3 {
+		t.Fatalf("Update() = %d, %v, want 3 files indexed", indexed, updateErr)
+	}
+
+	hits, queryErr := Query("DIJK", 0)
+	if queryErr != nil {
+		t.Fatal(queryErr)
+	}
+	if len(hits) != 2 || hits[0].Path != "CS2040/Lectures/Graphs.txt" || hits[0].Score != 2 || hits[0].Module != "CS2040" {
+		t.Fatalf("Query(DIJK) = %+v, want both files mentioning Dijkstra, most matches first", hits)
+	}
+
+	LoadSnippet(root, &hits[1])
+	if hits[1].Snippet != "Streams are lazy, unlike Dijkstra." {
+		t.Errorf("LoadSnippet() = %q, want the text around the match", hits[1].Snippet)
+	}
+
+	if hits, _ := Query("merge sort", 0); len(hits) != 1 || hits[0].Path != "CS2040/Lectures/Sorting.md" {
+		t.Errorf("Query(merge sort) = %+v, want the file containing both words", hits)
+	}
+
+	// Only files changed since they were last indexed are read again, and files that are gone are removed.
+	writeFile(t, filepath.Join(root, "CS2040", "Lectures", "Graphs.txt"), "Breadth first search.")
+	if err := os.Remove(filepath.Join(root, "CS2030S", "Notes.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	indexed, updateErr = Update(context.Background(), root)
+	if updateErr != nil || indexed != 1 {
+		t.Fatalf("Update() = %d, %v, want the changed file indexed", indexed, updateErr)
+	}
+	if hits, _ := Query("dijkstra", 0); len(hits) != 0 {
+		t.Errorf("Query(dijkstra) = %+v, want no file", hits)
+	}
+	if hits, _ := Query("breadth", 0); len(hits) != 1 {
+		t.Errorf("Query(breadth) = %+v, want the changed file", hits)
+	}
+}
+
+func TestUpdateInBatches(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() { Clear() })
+
+	for i := 0; i < BATCH_SIZE+1; i++ {
+		writeFile(t, filepath.Join(root, "CS2040", fmt.Sprintf("Tutorial%d.txt", i)), "Binary heaps")
+	}
+
+	indexed, updateErr := Update(context.Background(), root)
+	if updateErr != nil || indexed != BATCH_SIZE+1 {
+		t.Fatalf("Update() = %d, %v, want every file indexed", indexed, updateErr)
+	}
+
+	if hits, _ := Query("heaps", 0); len(hits) != BATCH_SIZE+1 {
+		t.Errorf("Query(heaps) = %d files, want %d", len(hits), BATCH_SIZE+1)
+	}
+	if hits, _ := Query("heaps", 10); len(hits) != 10 {
+		t.Errorf("Query(heaps, 10) = %d files, want 10", len(hits))
+	}
+}
+
+func TestUpdateCancelled(t *testing.T) {
+	root := t.TempDir()
+	t.Cleanup(func() { Clear() })
+	writeFile(t, filepath.Join(root, "CS2040", "Graphs.txt"), "Dijkstra")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if indexed, err := Update(ctx, root); !errors.Is(err, context.Canceled) || indexed != 0 {
+		t.Errorf("Update() of a cancelled context = %d, %v, want context.Canceled", indexed, err)
+	}
+}
+
+func TestGetTermStats(t *testing.T) {
+	terms, stats := getTermStats("Café, CAFÉ and a café-au-lait")
+
+	if strings.Join(terms, " ") != "café and au lait" {
+		t.Errorf("getTermStats() terms = %q, want the distinct terms in lower case", terms)
+	}
+	if stats["café"].count != 3 || stats["café"].offset != 0 {
+		t.Errorf("getTermStats() café = %+v, want 3 occurrences from offset 0", stats["café"])
+	}
+	if stats["lait"].offset != strings.Index("café, café and a café-au-lait", "lait") {
+		t.Errorf("getTermStats() lait = %+v, want the byte offset of its first occurrence", stats["lait"])
+	}
+}
+
+// runWithTestDB is a helper function that runs the tests with the Lominus database in a temporary folder.
+func runWithTestDB(m *testing.M) int {
+	configDir, tempErr := os.MkdirTemp("", "lominus-search")
+	if tempErr != nil {
+		panic(tempErr)
+	}
+	defer os.RemoveAll(configDir)
+
+	for _, env := range []string{"XDG_CONFIG_HOME", "HOME", "AppData"} {
+		os.Setenv(env, configDir)
+	}
+
+	db, initErr := app.Init()
+	if initErr != nil {
+		panic(initErr)
+	}
+	defer db.Close()
+
+	return m.Run()
+}
+
+// writeFile is a helper function that writes the contents to filePath, creating its folder.
+func writeFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
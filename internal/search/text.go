@@ -0,0 +1,184 @@
+package search
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// TEXT_EXTENSIONS are the extensions of the files whose text can be extracted, in lower case.
+var TEXT_EXTENSIONS = []string{".pdf", ".docx", ".pptx", ".txt", ".md"}
+
+// slideNameRegex matches the slides of a pptx file, eg. ppt/slides/slide12.xml.
+var slideNameRegex = regexp.MustCompile(`^ppt/slides/slide(\d+)\.xml$`)
+
+// CanExtractText checks whether the text of the file can be extracted, by its name.
+func CanExtractText(fileName string) bool {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	for _, textExt := range TEXT_EXTENSIONS {
+		if ext == textExt {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ExtractText returns the text of the file, up to MAX_TEXT_LENGTH bytes.
+// PDF, DOCX, PPTX, TXT and MD files are supported.
+func ExtractText(filePath string) (string, error) {
+	var text string
+	var err error
+
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".pdf":
+		text, err = extractPdfText(filePath)
+	case ".docx":
+		text, err = extractXmlText(filePath, func(name string) bool {
+			return name == "word/document.xml"
+		})
+	case ".pptx":
+		text, err = extractXmlText(filePath, func(name string) bool {
+			return slideNameRegex.MatchString(name)
+		})
+	case ".txt", ".md":
+		text, err = extractPlainText(filePath)
+	default:
+		return "", fmt.Errorf("unable to extract text from %s", filepath.Base(filePath))
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return truncateText(text), nil
+}
+
+// extractPlainText is a helper function that reads the text of a plain text file.
+func extractPlainText(filePath string) (string, error) {
+	file, openErr := os.Open(filePath)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer file.Close()
+
+	data, readErr := io.ReadAll(io.LimitReader(file, MAX_TEXT_LENGTH))
+	return string(data), readErr
+}
+
+// extractPdfText is a helper function that extracts the text of a PDF file.
+// Malformed PDFs make the PDF reader panic, which is returned as an error instead.
+func extractPdfText(filePath string) (text string, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("unable to read %s: %v", filepath.Base(filePath), recovered)
+		}
+	}()
+
+	file, reader, openErr := pdf.Open(filePath)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer file.Close()
+
+	plainText, plainTextErr := reader.GetPlainText()
+	if plainTextErr != nil {
+		return "", plainTextErr
+	}
+
+	data, readErr := io.ReadAll(io.LimitReader(plainText, MAX_TEXT_LENGTH))
+	return string(data), readErr
+}
+
+// extractXmlText is a helper function that extracts the character data of the XML documents in an
+// Office Open XML file, such as DOCX and PPTX files, whose names are accepted by include.
+// Documents are read in the order of their names, with slides ordered by number.
+func extractXmlText(filePath string, include func(name string) bool) (string, error) {
+	reader, openErr := zip.OpenReader(filePath)
+	if openErr != nil {
+		return "", openErr
+	}
+	defer reader.Close()
+
+	documents := []*zip.File{}
+	for _, file := range reader.File {
+		if include(file.Name) {
+			documents = append(documents, file)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return getDocumentOrder(documents[i].Name) < getDocumentOrder(documents[j].Name)
+	})
+
+	text := strings.Builder{}
+	for _, document := range documents {
+		content, contentErr := document.Open()
+		if contentErr != nil {
+			return "", contentErr
+		}
+
+		text.WriteString(getXmlCharData(io.LimitReader(content, MAX_TEXT_LENGTH)))
+		content.Close()
+		text.WriteString("\n")
+		if text.Len() >= MAX_TEXT_LENGTH {
+			break
+		}
+	}
+
+	return text.String(), nil
+}
+
+// getXmlCharData is a helper function that returns the character data of an XML document,
+// with the paragraphs separated by new lines.
+func getXmlCharData(content io.Reader) string {
+	decoder := xml.NewDecoder(content)
+	text := bytes.Buffer{}
+
+	for {
+		token, tokenErr := decoder.Token()
+		// Documents cut off at MAX_TEXT_LENGTH are still indexed up to where they were cut off.
+		if tokenErr != nil {
+			return text.String()
+		}
+
+		switch token := token.(type) {
+		case xml.CharData:
+			text.Write(token)
+		case xml.EndElement:
+			// Paragraphs in both word and drawing documents are "p" elements.
+			if token.Name.Local == "p" {
+				text.WriteString("\n")
+			}
+		}
+	}
+}
+
+// getDocumentOrder is a helper function that returns the number of the slide, or 0 for other documents.
+func getDocumentOrder(name string) int {
+	match := slideNameRegex.FindStringSubmatch(name)
+	if match == nil {
+		return 0
+	}
+
+	order, _ := strconv.Atoi(match[1])
+	return order
+}
+
+// truncateText is a helper function that shortens the text to MAX_TEXT_LENGTH bytes without splitting a character.
+func truncateText(text string) string {
+	if len(text) <= MAX_TEXT_LENGTH {
+		return text
+	}
+
+	return strings.ToValidUTF8(text[:MAX_TEXT_LENGTH], "")
+}
@@ -0,0 +1,201 @@
+// Package secrets provides primitives to encrypt secrets, such as API tokens, before they are stored,
+// with AES-GCM and a key read from a key file or derived from a passphrase.
+//
+// Threat model: a key file is kept next to the secrets it encrypts, only readable by the user. It protects
+// the secrets from reads of the stored data on its own, such as a database file attached to a bug report or
+// synced to another service. Anyone who can read the folder, such as from a copy or backup of it, can read the
+// key file too and decrypt the secrets. The key is deliberately not bound to the machine, such as with the
+// keyring of the OS or the machine ID: the folder keeps working when it is restored on another machine, and
+// no platform specific keyring is needed to run Lominus. Secrets are protected from anyone without the
+// passphrase only when the key is derived from one.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// ENCRYPTED_PREFIX marks encrypted values, such that values stored before they were encrypted
+// are told apart and read as they are.
+const ENCRYPTED_PREFIX = "enc:v1:"
+
+// KEY_FILE_NAME is the name of the key file, which is kept next to the secrets it encrypts.
+// See the package documentation for what it protects against.
+const KEY_FILE_NAME = "lominus.key"
+
+// KEY_LENGTH is the length in bytes of keys, for AES-256.
+const KEY_LENGTH = 32
+
+// SALT_LENGTH is the length in bytes of the salts keys are derived from passphrases with.
+const SALT_LENGTH = 16
+
+// Cost parameters of scrypt, as recommended for interactive logins.
+const (
+	SCRYPT_N = 1 << 15
+	SCRYPT_R = 8
+	SCRYPT_P = 1
+)
+
+// ErrWrongKey is returned when a value cannot be decrypted with the key, such as when the passphrase is wrong
+// or the key file was replaced.
+var ErrWrongKey = errors.New("unable to decrypt secret - wrong passphrase or key file")
+
+// Cipher struct encrypts and decrypts values with a key.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher with a key of KEY_LENGTH bytes.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KEY_LENGTH {
+		return nil, fmt.Errorf("invalid key length %d, expected %d", len(key), KEY_LENGTH)
+	}
+
+	block, blockErr := aes.NewCipher(key)
+	if blockErr != nil {
+		return nil, blockErr
+	}
+
+	aead, aeadErr := cipher.NewGCM(block)
+	if aeadErr != nil {
+		return nil, aeadErr
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// DeriveKey derives a key from the passphrase and salt with scrypt.
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, SCRYPT_N, SCRYPT_R, SCRYPT_P, KEY_LENGTH)
+}
+
+// NewSalt returns a random salt of SALT_LENGTH bytes.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SALT_LENGTH)
+	_, err := rand.Read(salt)
+
+	return salt, err
+}
+
+// ReadKeyFile reads the key in the key file. An error wrapping fs.ErrNotExist is returned if it does not exist.
+func ReadKeyFile(keyPath string) ([]byte, error) {
+	key, readErr := os.ReadFile(keyPath)
+	if readErr != nil {
+		return nil, readErr
+	}
+
+	if len(key) != KEY_LENGTH {
+		return nil, fmt.Errorf("invalid key file %s", keyPath)
+	}
+
+	return key, nil
+}
+
+// LoadKeyFile reads the key in the key file, creating the file with a random key if it does not exist.
+// The key file is only readable by the user.
+func LoadKeyFile(keyPath string) ([]byte, error) {
+	key, readErr := ReadKeyFile(keyPath)
+	if !errors.Is(readErr, fs.ErrNotExist) {
+		return key, readErr
+	}
+
+	key = make([]byte, KEY_LENGTH)
+	if _, randErr := rand.Read(key); randErr != nil {
+		return nil, randErr
+	}
+
+	keyFile, createErr := os.OpenFile(keyPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(createErr, fs.ErrExist) {
+		// Another process created the key file in the meantime.
+		return LoadKeyFile(keyPath)
+	}
+	if createErr != nil {
+		return nil, createErr
+	}
+
+	_, writeErr := keyFile.Write(key)
+	closeErr := keyFile.Close()
+	if writeErr != nil {
+		return nil, writeErr
+	}
+
+	return key, closeErr
+}
+
+// GetKeyPath returns the path of the key file the secrets in the file at dataPath are encrypted with,
+// which is KEY_FILE_NAME in the same folder. The secrets cannot be decrypted without it.
+func GetKeyPath(dataPath string) string {
+	return filepath.Join(filepath.Dir(dataPath), KEY_FILE_NAME)
+}
+
+// NewKeyFileCipher creates a Cipher with the key in the key file at keyPath. If the key file does not exist,
+// it is created with a random key if create is true, or else an error wrapping fs.ErrNotExist is returned.
+func NewKeyFileCipher(keyPath string, create bool) (*Cipher, error) {
+	readKey := ReadKeyFile
+	if create {
+		readKey = LoadKeyFile
+	}
+
+	key, keyErr := readKey(keyPath)
+	if keyErr != nil {
+		return nil, keyErr
+	}
+
+	return NewCipher(key)
+}
+
+// IsEncrypted checks whether the value was encrypted by a Cipher.
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, ENCRYPTED_PREFIX)
+}
+
+// Encrypt encrypts the value, returning it in text form with ENCRYPTED_PREFIX.
+// Empty values are returned as they are, as there is nothing to hide and they tell that a secret is not set.
+func (c *Cipher) Encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, randErr := rand.Read(nonce); randErr != nil {
+		return "", randErr
+	}
+
+	sealed := c.aead.Seal(nonce, nonce, []byte(value), nil)
+	return ENCRYPTED_PREFIX + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt. Values without ENCRYPTED_PREFIX, such as those stored
+// before secrets were encrypted, are returned as they are.
+func (c *Cipher) Decrypt(value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+
+	sealed, decodeErr := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, ENCRYPTED_PREFIX))
+	if decodeErr != nil {
+		return "", decodeErr
+	}
+
+	nonceSize := c.aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", ErrWrongKey
+	}
+
+	plaintext, openErr := c.aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if openErr != nil {
+		return "", ErrWrongKey
+	}
+
+	return string(plaintext), nil
+}
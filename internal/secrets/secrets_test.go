@@ -0,0 +1,103 @@
+package secrets
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptDecrypt(t *testing.T) {
+	cipher, cipherErr := NewCipher(bytes.Repeat([]byte{1}, KEY_LENGTH))
+	if cipherErr != nil {
+		t.Fatal(cipherErr)
+	}
+
+	encrypted, encryptErr := cipher.Encrypt("token")
+	if encryptErr != nil || !IsEncrypted(encrypted) {
+		t.Fatalf("Encrypt() = %q, %v, want a value with %s", encrypted, encryptErr, ENCRYPTED_PREFIX)
+	}
+	if again, _ := cipher.Encrypt("token"); again == encrypted {
+		t.Errorf("Encrypt() = %q twice, want a new nonce every time", again)
+	}
+
+	if decrypted, err := cipher.Decrypt(encrypted); err != nil || decrypted != "token" {
+		t.Errorf("Decrypt() = %q, %v, want token", decrypted, err)
+	}
+
+	// Empty and plaintext values are returned as they are.
+	if encrypted, err := cipher.Encrypt(""); err != nil || encrypted != "" {
+		t.Errorf("Encrypt(\"\") = %q, %v, want it empty", encrypted, err)
+	}
+	if decrypted, err := cipher.Decrypt("plaintext"); err != nil || decrypted != "plaintext" {
+		t.Errorf("Decrypt(plaintext) = %q, %v, want it as it is", decrypted, err)
+	}
+
+	other, _ := NewCipher(bytes.Repeat([]byte{2}, KEY_LENGTH))
+	if _, err := other.Decrypt(encrypted); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Decrypt() with another key = %v, want ErrWrongKey", err)
+	}
+
+	if _, err := NewCipher([]byte("short")); err == nil {
+		t.Errorf("NewCipher() of a short key = nil, want an error")
+	}
+}
+
+func TestDeriveKey(t *testing.T) {
+	salt, saltErr := NewSalt()
+	if saltErr != nil {
+		t.Fatal(saltErr)
+	}
+
+	key, keyErr := DeriveKey("passphrase", salt)
+	if keyErr != nil || len(key) != KEY_LENGTH {
+		t.Fatalf("DeriveKey() = %d bytes, %v, want %d bytes", len(key), keyErr, KEY_LENGTH)
+	}
+
+	if again, _ := DeriveKey("passphrase", salt); !bytes.Equal(again, key) {
+		t.Errorf("DeriveKey() of the same passphrase and salt = %x, want %x", again, key)
+	}
+	if other, _ := DeriveKey("other", salt); bytes.Equal(other, key) {
+		t.Errorf("DeriveKey() of another passphrase = the same key")
+	}
+
+	otherSalt, _ := NewSalt()
+	if other, _ := DeriveKey("passphrase", otherSalt); bytes.Equal(other, key) {
+		t.Errorf("DeriveKey() with another salt = the same key")
+	}
+}
+
+func TestNewKeyFileCipher(t *testing.T) {
+	keyPath := GetKeyPath(filepath.Join(t.TempDir(), "credentials.gob"))
+	if filepath.Base(keyPath) != KEY_FILE_NAME {
+		t.Errorf("GetKeyPath() = %s, want %s next to the file", keyPath, KEY_FILE_NAME)
+	}
+
+	if _, err := NewKeyFileCipher(keyPath, false); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("NewKeyFileCipher() of a missing key file = %v, want an error wrapping fs.ErrNotExist", err)
+	}
+
+	cipher, cipherErr := NewKeyFileCipher(keyPath, true)
+	if cipherErr != nil {
+		t.Fatal(cipherErr)
+	}
+
+	info, statErr := os.Stat(keyPath)
+	if statErr != nil {
+		t.Fatal(statErr)
+	}
+	if perm := info.Mode().Perm(); perm&0077 != 0 && os.PathSeparator == '/' {
+		t.Errorf("key file mode = %s, want it only readable by the user", perm)
+	}
+
+	encrypted, _ := cipher.Encrypt("token")
+	again, againErr := NewKeyFileCipher(keyPath, false)
+	if againErr != nil {
+		t.Fatal(againErr)
+	}
+	if decrypted, err := again.Decrypt(encrypted); err != nil || decrypted != "token" {
+		t.Errorf("Decrypt() with the key file read again = %q, %v, want token", decrypted, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Package section provides primitives to parse text configured by the user in sections per module,
+// such as filter rules and hooks.
+package section
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+)
+
+// Sections struct contains the items for all modules and the items for specific modules only,
+// keyed by module code in upper case.
+type Sections[T any] struct {
+	Global  []T
+	Modules map[string][]T
+}
+
+// Parse parses the text representation of Sections. Each non-empty line is either:
+//   - a comment starting with "#",
+//   - a section header "[MODULECODE]" after which items are for that module only, or
+//   - an item, which is parsed by parseItem.
+//
+// Items before the first section header are for all modules. Module codes are case insensitive, such that
+// "[cs2030s]" is the same section as "[CS2030S]". Errors are prefixed with the line number.
+func Parse[T any](text string, parseItem func(line string) (T, error)) (Sections[T], error) {
+	sections := Sections[T]{Global: []T{}, Modules: map[string][]T{}}
+	module := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			module = strings.ToUpper(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+
+		item, err := parseItem(line)
+		if err != nil {
+			return sections, fmt.Errorf("line %d: %s", lineNo, err.Error())
+		}
+
+		if module == "" {
+			sections.Global = append(sections.Global, item)
+		} else {
+			sections.Modules[module] = append(sections.Modules[module], item)
+		}
+	}
+
+	return sections, scanner.Err()
+}
+
+// ForModule returns the items for the module followed by the items for all modules.
+// The module code is case insensitive.
+func (sections Sections[T]) ForModule(moduleCode string) []T {
+	return append(append([]T{}, sections.Modules[strings.ToUpper(moduleCode)]...), sections.Global...)
+}
@@ -0,0 +1,64 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"os"
+	"time"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	"github.com/beebeeoii/lominus/internal/versions"
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+// IsModifiedLocally checks whether the local copy of an indexed file has been modified since
+// it was downloaded, by comparing the hash of its contents against the hash recorded in the index.
+// Files indexed without a hash are assumed to be unmodified.
+func IsModifiedLocally(localPath string, indexEntry indexing.IndexMapEntry) (bool, error) {
+	if indexEntry.Hash == "" || !appFiles.Exists(localPath) {
+		return false, nil
+	}
+
+	hash, hashErr := appFiles.Hash(localPath)
+	if hashErr != nil {
+		return false, hashErr
+	}
+
+	return hash != indexEntry.Hash, nil
+}
+
+// ResolveConflict applies the conflict policy to a locally modified file that is about to be
+// updated. It returns whether the update should still be downloaded, and the path the locally
+// modified file was moved to, if it was. Locally modified files that are overwritten are moved into
+// the .versions folder first, such that they can still be restored.
+func ResolveConflict(localPath string, conflictPolicy string, now time.Time) (bool, string, error) {
+	switch conflictPolicy {
+	case appPref.CONFLICT_SKIP:
+		return false, "", nil
+	case appPref.CONFLICT_OVERWRITE:
+		versionPath, stashErr := versions.Stash(localPath, now)
+		return true, versionPath, stashErr
+	default:
+		conflictPath := appFiles.ConflictName(localPath, now)
+		return true, conflictPath, os.Rename(localPath, conflictPath)
+	}
+}
+
+// ResolveCollision applies the collision policy to the file at localPath, which was not downloaded by Lominus
+// and is in the way of a download. It returns the name the download should be saved with, or an empty string
+// if it should not be downloaded, and the path the file in the way was renamed to, if it was.
+func ResolveCollision(localPath string, file api.File, collisionPolicy string) (string, string, error) {
+	switch collisionPolicy {
+	case appPref.COLLISION_SKIP:
+		return "", "", nil
+	case appPref.COLLISION_OVERWRITE:
+		return file.Name, "", os.Remove(localPath)
+	case appPref.COLLISION_RENAME_NEW:
+		return api.GetDisambiguatedName(file.Name, file.Id), "", nil
+	default:
+		renamedPath, renameErr := appFiles.AutoRename(localPath)
+		return file.Name, renamedPath, renameErr
+	}
+}
@@ -0,0 +1,100 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"context"
+	"time"
+
+	appAuth "github.com/beebeeoii/lominus/internal/app/auth"
+	appFilter "github.com/beebeeoii/lominus/internal/app/filter"
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appSyncState "github.com/beebeeoii/lominus/internal/app/syncstate"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	"github.com/beebeeoii/lominus/internal/notifications"
+	"github.com/beebeeoii/lominus/pkg/api"
+	"github.com/beebeeoii/lominus/pkg/constants"
+)
+
+// CanvasProvider struct is the Provider that retrieves files from Canvas using the Token.
+type CanvasProvider struct {
+	Token string
+}
+
+// AppStorage struct is the Storage backed by the Lominus database.
+type AppStorage struct{}
+
+// SystemClock struct is the Clock that tells the system time.
+type SystemClock struct{}
+
+// ChannelNotifier struct is the Notifier that pushes notifications to the notification channel.
+type ChannelNotifier struct{}
+
+// NewDefaultSyncer creates a Syncer that syncs files from Canvas into the root sync directory,
+// using the user's saved credentials, preferences and filter rules.
+func NewDefaultSyncer(rootSyncDirectory string) (*Syncer, error) {
+	canvasCredentials, credErr := appAuth.GetCanvasCredentials()
+	if credErr != nil {
+		return nil, credErr
+	}
+
+	return NewSyncer(
+		rootSyncDirectory,
+		CanvasProvider{Token: canvasCredentials.CanvasApiToken},
+		AppStorage{},
+		SystemClock{},
+		ChannelNotifier{},
+	), nil
+}
+
+// Preview builds the Plan for the root sync directory using the user's saved credentials
+// and filter rules, without downloading or modifying any file.
+func Preview(rootSyncDirectory string) (Plan, error) {
+	syncer, syncerErr := NewDefaultSyncer(rootSyncDirectory)
+	if syncerErr != nil {
+		return Plan{}, syncerErr
+	}
+
+	return syncer.Plan()
+}
+
+func (provider CanvasProvider) ListFiles(highWaterMarks map[string]time.Time) (RemoteListing, error) {
+	return FetchRemoteFiles(provider.Token, constants.Canvas, highWaterMarks)
+}
+
+func (provider CanvasProvider) Download(ctx context.Context, file api.File, folderPath string) error {
+	return file.DownloadContext(ctx, folderPath)
+}
+
+func (AppStorage) GetPreferences() (appPref.Preferences, error) {
+	return appPref.GetPreferences()
+}
+
+func (AppStorage) GetFilterRules() (filter.Rules, error) {
+	return appFilter.GetFilterRules()
+}
+
+func (AppStorage) GetSyncState() (appSyncState.SyncState, error) {
+	return appSyncState.GetSyncState()
+}
+
+func (AppStorage) SaveSyncState(state appSyncState.SyncState) error {
+	return appSyncState.SaveSyncState(state)
+}
+
+func (AppStorage) LoadIndexMap() (map[string]indexing.IndexMapEntry, error) {
+	return indexing.LoadIndexMap()
+}
+
+func (AppStorage) SaveIndexMap(indexMap map[string]indexing.IndexMapEntry) error {
+	return indexing.SaveIndexMap(indexing.ToIndexMap(indexMap))
+}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (ChannelNotifier) Notify(title string, content string) {
+	notifications.NotificationChannel <- notifications.Notification{Title: title, Content: content}
+}
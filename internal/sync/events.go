@@ -0,0 +1,46 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import "time"
+
+// Event is emitted by a Syncer during a sync. It is one of SyncStarted, FilePlanned,
+// FileDownloaded, FileFailed or SyncFinished.
+type Event interface {
+	isEvent()
+}
+
+// SyncStarted is emitted when a sync starts.
+type SyncStarted struct {
+	Time time.Time
+}
+
+// FilePlanned is emitted for every file in the Plan, before any of them is acted on.
+type FilePlanned struct {
+	Entry PlanEntry
+}
+
+// FileDownloaded is emitted when a new or updated file has been downloaded to Path.
+type FileDownloaded struct {
+	Entry PlanEntry
+	Path  string
+}
+
+// FileFailed is emitted when a file could not be moved or downloaded.
+type FileFailed struct {
+	Entry PlanEntry
+	Err   error
+}
+
+// SyncFinished is emitted when a sync finishes. Err is set if the sync could not be carried out.
+type SyncFinished struct {
+	Time   time.Time
+	Result Result
+	Err    error
+}
+
+func (SyncStarted) isEvent()    {}
+func (FilePlanned) isEvent()    {}
+func (FileDownloaded) isEvent() {}
+func (FileFailed) isEvent()     {}
+func (SyncFinished) isEvent()   {}
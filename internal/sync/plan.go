@@ -0,0 +1,359 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+// Action describes what a sync would do to a file.
+type Action string
+
+const (
+	ActionNew           Action = "new"
+	ActionUpdate        Action = "updated"
+	ActionMove          Action = "moved"
+	ActionUnchanged     Action = "unchanged"
+	ActionSkip          Action = "skipped"
+	ActionRemoteDeleted Action = "remote-deleted"
+)
+
+// Actions lists every Action in the order they are presented to the user.
+var Actions = []Action{
+	ActionNew,
+	ActionUpdate,
+	ActionMove,
+	ActionRemoteDeleted,
+	ActionSkip,
+	ActionUnchanged,
+}
+
+// PlanEntry struct describes what a sync would do to a single file and why.
+// Key is the path of the file relative to the root sync directory, eg. CS2040/Lectures/Lecture1.pdf.
+// PreviousKey is set if the file was renamed or moved remotely, and is where the local copy
+// currently is. The local copy is moved to Key before anything else is done to it.
+// File is the remote file, except for ActionRemoteDeleted where it is the local file.
+// Collision is set for ActionUpdate if the file at Key was not downloaded by Lominus,
+// such as one the user put there, and is in the way of the download.
+type PlanEntry struct {
+	Action      Action
+	Reason      string
+	Key         string
+	PreviousKey string
+	File        api.File
+	Collision   bool
+}
+
+// Plan struct is the datapack containing what a sync would do to every file.
+type Plan struct {
+	RootSyncDirectory string
+	Entries           []PlanEntry
+
+	visitedModules map[string]bool
+}
+
+// BuildPlan compares the files on the LMS against the files on the local desktop and
+// decides what a sync would do to each of them. Only files previously downloaded by Lominus,
+// as recorded in indexMap, are considered deleted remotely and only if they belong to a module
+// in listing.Modules.
+//
+// localFiles is expected to be built by indexing.Build and indexMap to be loaded by
+// indexing.LoadIndexMap. Files found in indexMap at a different path than they are remotely
+// are moved instead of downloaded again, unless a file not downloaded by Lominus is in the way.
+func BuildPlan(
+	rootSyncDirectory string,
+	listing RemoteListing,
+	localFiles map[string]api.File,
+	indexMap map[string]indexing.IndexMapEntry,
+	rules filter.Rules,
+) Plan {
+	plan := Plan{
+		RootSyncDirectory: rootSyncDirectory,
+		Entries:           []PlanEntry{},
+		visitedModules:    map[string]bool{},
+	}
+	remoteKeys := map[string]bool{}
+
+	indexedKeys := map[string]bool{}
+	for _, indexEntry := range indexMap {
+		indexedKeys[strings.ToLower(indexEntry.Path)] = true
+	}
+
+	for _, module := range listing.Modules {
+		plan.visitedModules[strings.ToLower(module.ModuleCode)] = true
+	}
+
+	for _, file := range listing.Files {
+		key := getKey(file)
+		remoteKeys[strings.ToLower(key)] = true
+
+		if skip, rule := rules.Skips(file); skip {
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Action: ActionSkip,
+				Reason: fmt.Sprintf("skipped by rule: %s", rule.String()),
+				Key:    key,
+				File:   file,
+			})
+			continue
+		}
+
+		localFile, exists := localFiles[strings.ToLower(key)]
+		previousKey := ""
+
+		indexEntry, indexed := indexMap[file.Id]
+		if !exists && indexed && !strings.EqualFold(indexEntry.Path, key) {
+			if previousFile, previousExists := localFiles[strings.ToLower(indexEntry.Path)]; previousExists {
+				previousKey = indexEntry.Path
+				localFile, exists = previousFile, true
+				remoteKeys[strings.ToLower(previousKey)] = true
+			}
+		}
+
+		// The local copy stays where it is if a file not downloaded by Lominus is at key,
+		// such as when it was downloaded under another name because of the collision policy.
+		if exists && previousKey == "" && indexed && !strings.EqualFold(indexEntry.Path, key) && !indexedKeys[strings.ToLower(key)] {
+			if keptFile, keptExists := localFiles[strings.ToLower(indexEntry.Path)]; keptExists {
+				key = indexEntry.Path
+				localFile = keptFile
+				file.Name = path.Base(key)
+				remoteKeys[strings.ToLower(key)] = true
+			}
+		}
+		collision := exists && previousKey == "" && !indexedKeys[strings.ToLower(key)]
+
+		// The remote time recorded when the local copy was downloaded is compared against, as the
+		// modification time of the local copy changes when it is copied or restored from a backup.
+		// Local copies that were not downloaded by Lominus can only be compared by their modification time.
+		localLastUpdated := localFile.LastUpdated
+		if indexed && (previousKey != "" || strings.EqualFold(indexEntry.Path, key)) {
+			localLastUpdated = time.Unix(indexEntry.LastUpdated, 0)
+		}
+
+		switch {
+		case !exists:
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Action: ActionNew,
+				Reason: "not found locally",
+				Key:    key,
+				File:   file,
+			})
+		case localLastUpdated.Unix() < file.LastUpdated.Unix():
+			reason := fmt.Sprintf(
+				"updated remotely on %s, local copy is from %s",
+				file.LastUpdated.Local().Format(REASON_TIME_FORMAT),
+				localLastUpdated.Local().Format(REASON_TIME_FORMAT),
+			)
+			if previousKey != "" {
+				reason = fmt.Sprintf("moved from %s and %s", previousKey, reason)
+			}
+
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Action:      ActionUpdate,
+				Reason:      reason,
+				Key:         key,
+				PreviousKey: previousKey,
+				File:        file,
+				Collision:   collision,
+			})
+		case previousKey != "":
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Action:      ActionMove,
+				Reason:      fmt.Sprintf("moved or renamed remotely from %s", previousKey),
+				Key:         key,
+				PreviousKey: previousKey,
+				File:        file,
+			})
+		default:
+			plan.Entries = append(plan.Entries, PlanEntry{
+				Action: ActionUnchanged,
+				Reason: "local copy is up to date",
+				Key:    key,
+				File:   file,
+			})
+		}
+	}
+
+	remoteIds := map[string]bool{}
+	for _, file := range listing.Files {
+		remoteIds[file.Id] = true
+	}
+
+	for id, indexEntry := range indexMap {
+		if remoteIds[id] || !plan.visitedModules[strings.ToLower(getModuleCode(indexEntry))] {
+			continue
+		}
+
+		localFile, exists := localFiles[strings.ToLower(indexEntry.Path)]
+		if !exists || remoteKeys[strings.ToLower(indexEntry.Path)] {
+			continue
+		}
+
+		localFile.Id = id
+		plan.Entries = append(plan.Entries, PlanEntry{
+			Action: ActionRemoteDeleted,
+			Reason: "no longer available remotely",
+			Key:    indexEntry.Path,
+			File:   localFile,
+		})
+	}
+
+	sort.SliceStable(plan.Entries, func(i, j int) bool {
+		return plan.Entries[i].Key < plan.Entries[j].Key
+	})
+
+	return plan
+}
+
+// UpdateIndexMap updates indexMap, keyed by file Id, to reflect the local files after the plan
+// has been carried out. failedKeys contains the keys of the entries that could not be carried out
+// and hashes contains the hashes of the files downloaded, keyed by the entries' keys.
+// Only files downloaded by Lominus, now or in an earlier sync, are indexed.
+// Entries of files no longer available remotely are kept until their local copies are gone,
+// such as when they are moved into the trash folder.
+func (plan Plan) UpdateIndexMap(
+	indexMap map[string]indexing.IndexMapEntry,
+	failedKeys map[string]bool,
+	hashes map[string]string,
+) {
+	remoteIds := map[string]bool{}
+
+	for _, entry := range plan.Entries {
+		if entry.Action == ActionRemoteDeleted {
+			continue
+		}
+		remoteIds[entry.File.Id] = true
+
+		if entry.Action == ActionSkip || failedKeys[entry.Key] {
+			continue
+		}
+
+		// Local copies that were not downloaded are only indexed if they were before, such that a file of the
+		// user's that has the name of a remote file is not taken for a download and replaced by a later update.
+		indexEntry, indexed := indexMap[entry.File.Id]
+		hash, downloaded := hashes[entry.Key]
+		if !downloaded {
+			if !indexed || !(strings.EqualFold(indexEntry.Path, entry.Key) || strings.EqualFold(indexEntry.Path, entry.PreviousKey)) {
+				continue
+			}
+			hash = indexEntry.Hash
+		}
+
+		indexMap[entry.File.Id] = indexing.IndexMapEntry{
+			Id:           entry.File.Id,
+			FileName:     entry.File.Name,
+			OriginalName: entry.File.OriginalName,
+			Path:         entry.Key,
+			LastUpdated:  entry.File.LastUpdated.Unix(),
+			Hash:         hash,
+		}
+	}
+
+	for id, indexEntry := range indexMap {
+		if remoteIds[id] || !plan.visitedModules[strings.ToLower(getModuleCode(indexEntry))] {
+			continue
+		}
+
+		if !appFiles.Exists(filepath.Join(plan.RootSyncDirectory, filepath.FromSlash(indexEntry.Path))) {
+			delete(indexMap, id)
+		}
+	}
+}
+
+// Filter returns the entries of the plan with the given action.
+func (plan Plan) Filter(action Action) []PlanEntry {
+	entries := []PlanEntry{}
+
+	for _, entry := range plan.Entries {
+		if entry.Action == action {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// Count returns the number of entries of the plan for every action.
+func (plan Plan) Count() map[Action]int {
+	count := map[Action]int{}
+
+	for _, entry := range plan.Entries {
+		count[entry.Action] += 1
+	}
+
+	return count
+}
+
+// VisitedModules returns the codes of the modules whose files were listed completely.
+func (plan Plan) VisitedModules() []string {
+	modules := []string{}
+
+	for module := range plan.visitedModules {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	return modules
+}
+
+// LocalPath returns the absolute path of the file described by the entry.
+func (plan Plan) LocalPath(entry PlanEntry) string {
+	return filepath.Join(plan.RootSyncDirectory, filepath.FromSlash(entry.Key))
+}
+
+// PreviousLocalPath returns the absolute path where the local copy of a file renamed or moved
+// remotely currently is. It returns an empty string if the file was not renamed or moved.
+func (plan Plan) PreviousLocalPath(entry PlanEntry) string {
+	if entry.PreviousKey == "" {
+		return ""
+	}
+
+	return filepath.Join(plan.RootSyncDirectory, filepath.FromSlash(entry.PreviousKey))
+}
+
+// String returns a human readable description of the plan, grouped by action.
+// Unchanged files are only counted.
+func (plan Plan) String() string {
+	count := plan.Count()
+	lines := []string{}
+
+	for _, action := range Actions {
+		lines = append(lines, fmt.Sprintf("%s: %d", action, count[action]))
+	}
+
+	for _, action := range Actions {
+		if action == ActionUnchanged || count[action] == 0 {
+			continue
+		}
+
+		lines = append(lines, "", fmt.Sprintf("[%s]", action))
+		for _, entry := range plan.Filter(action) {
+			lines = append(lines, fmt.Sprintf("%s - %s", entry.Key, entry.Reason))
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+const REASON_TIME_FORMAT = "02 Jan 2006 15:04"
+
+// getModuleCode is a helper function that returns the module code of an indexed file,
+// which is the first folder of its path.
+func getModuleCode(indexEntry indexing.IndexMapEntry) string {
+	return strings.Split(indexEntry.Path, "/")[0]
+}
+
+// getKey is a helper function that returns the path of the file relative to the root
+// sync directory, eg. CS2040/Lectures/Lecture1.pdf.
+func getKey(file api.File) string {
+	return strings.Join(append(append([]string{}, file.Ancestors...), file.Name), "/")
+}
@@ -0,0 +1,244 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/pkg/api"
+	"github.com/beebeeoii/lominus/pkg/constants"
+)
+
+// RemoteListing struct is the datapack containing the files retrieved from the LMS.
+// Modules contains only the modules whose files were listed completely. It is used
+// to tell apart files that were deleted remotely from files that could not be listed.
+// Errors contains why some modules could not be listed.
+// HighWaterMarks contains, for every module listed, the time of its most recent remote change.
+// Full describes whether every module was requested to be listed completely.
+type RemoteListing struct {
+	Files          []api.File
+	Modules        []api.Module
+	Errors         []ModuleError
+	HighWaterMarks map[string]time.Time
+	Full           bool
+}
+
+// ModuleError struct is the datapack containing an error that occurred in a module.
+// Module is empty if the error is not specific to a module.
+type ModuleError struct {
+	Module string
+	Err    error
+}
+
+func (err ModuleError) Error() string {
+	if err.Module == "" {
+		return err.Err.Error()
+	}
+
+	return fmt.Sprintf("%s: %s", err.Module, err.Err.Error())
+}
+
+func (err ModuleError) Unwrap() error {
+	return err.Err
+}
+
+// FetchRemoteFiles retrieves all the files of all accessible modules on the platform.
+// Failing to list a module's files does not stop the other modules from being listed.
+//
+// Files sharing a local path in a module listed completely are disambiguated by api.DisambiguateFiles.
+//
+// If highWaterMarks is not nil, only the files of a module that changed since its high-water mark
+// are retrieved, in as little as one request per module. Such modules are not listed completely.
+// Modules without a high-water mark, or whose changed files Canvas refuses to list, are listed completely.
+func FetchRemoteFiles(token string, platform constants.Platform, highWaterMarks map[string]time.Time) (RemoteListing, error) {
+	listing := RemoteListing{
+		Files:          []api.File{},
+		Modules:        []api.Module{},
+		Errors:         []ModuleError{},
+		HighWaterMarks: map[string]time.Time{},
+		Full:           highWaterMarks == nil,
+	}
+
+	logs.Logger.Debugln("building - module request")
+
+	modules, modulesErr := getModules(token, platform)
+	if modulesErr != nil {
+		return listing, modulesErr
+	}
+
+	for _, module := range modules {
+		if !module.IsAccessible {
+			continue
+		}
+
+		// Canvas may refuse to list the files of a module at once, such as to students, even when its
+		// folders can be listed. Such modules are listed completely instead.
+		highWaterMark, incremental := highWaterMarks[module.ModuleCode]
+		if incremental {
+			files, filesErr := getChangedModuleFiles(token, module, highWaterMark)
+
+			var statusErr api.StatusError
+			switch {
+			case errors.As(filesErr, &statusErr):
+				logs.Logger.Debugf("%s - unable to list changed files, listing completely: %s", module.ModuleCode, filesErr)
+			case filesErr != nil:
+				logs.Logger.Warnln(filesErr)
+				listing.Errors = append(listing.Errors, ModuleError{Module: module.ModuleCode, Err: filesErr})
+				continue
+			default:
+				logs.Logger.Debugf("%s - %d files changed since %s", module.ModuleCode, len(files), highWaterMark.Format(time.RFC3339))
+				listing.Files = append(listing.Files, files...)
+				listing.HighWaterMarks[module.ModuleCode] = getHighWaterMark(files, highWaterMark)
+				continue
+			}
+		}
+
+		files, filesErr := getModuleFiles(token, platform, module)
+		if filesErr != nil {
+			logs.Logger.Warnln(filesErr)
+			listing.Errors = append(listing.Errors, ModuleError{Module: module.ModuleCode, Err: filesErr})
+			continue
+		}
+
+		listing.Files = append(listing.Files, api.DisambiguateFiles(files)...)
+		listing.Modules = append(listing.Modules, module)
+		listing.HighWaterMarks[module.ModuleCode] = getHighWaterMark(files, time.Time{})
+	}
+
+	return listing, nil
+}
+
+// getModules is a helper function that retrieves Module objects based on the platform
+// passed in the arguments.
+func getModules(token string, platform constants.Platform) ([]api.Module, error) {
+	modules := []api.Module{}
+
+	modulesRequest, modulesReqErr := api.BuildModulesRequest(token, platform)
+	if modulesReqErr != nil {
+		return modules, modulesReqErr
+	}
+
+	modules, modulesErr := modulesRequest.GetModules()
+	if modulesErr != nil {
+		return modules, modulesErr
+	}
+
+	return modules, nil
+}
+
+// getModuleFiles is a helper function that retrieves all the files, including nested ones,
+// of a module.
+func getModuleFiles(token string, platform constants.Platform, module api.Module) ([]api.File, error) {
+	moduleFolderReq, moduleFolderReqErr := api.BuildModuleFolderRequest(token, module)
+	if moduleFolderReqErr != nil {
+		return []api.File{}, moduleFolderReqErr
+	}
+
+	moduleFolder, moduleFolderErr := moduleFolderReq.GetModuleFolder()
+	if moduleFolderErr != nil {
+		return []api.File{}, moduleFolderErr
+	}
+
+	foldersReq, foldersReqErr := api.BuildFoldersRequest(token, platform, moduleFolder)
+	if foldersReqErr != nil {
+		return []api.File{}, foldersReqErr
+	}
+
+	return foldersReq.GetRootFiles()
+}
+
+// getChangedModuleFiles is a helper function that retrieves the files, including nested ones,
+// of a module that changed since, including those changed in the same second.
+func getChangedModuleFiles(token string, module api.Module, since time.Time) ([]api.File, error) {
+	courseFilesReq, courseFilesReqErr := api.BuildCourseFilesRequest(token, module)
+	if courseFilesReqErr != nil {
+		return []api.File{}, courseFilesReqErr
+	}
+
+	return courseFilesReq.GetFilesUpdatedSince(since)
+}
+
+// getHighWaterMark is a helper function that returns the time of the most recent change
+// among the files, or highWaterMark if it is more recent.
+func getHighWaterMark(files []api.File, highWaterMark time.Time) time.Time {
+	for _, file := range files {
+		if file.UpdatedAt.After(highWaterMark) {
+			highWaterMark = file.UpdatedAt
+		}
+	}
+
+	return highWaterMark
+}
+
+// disambiguateChangedFiles is a helper function that disambiguates the files of the modules listed
+// incrementally. As only their changed files are listed, they are disambiguated together with the files
+// previously downloaded from those modules, such that they get the same names as in a complete listing.
+func disambiguateChangedFiles(listing RemoteListing, indexMap map[string]indexing.IndexMapEntry) []api.File {
+	if listing.Full {
+		return listing.Files
+	}
+
+	completeModules := map[string]bool{}
+	for _, module := range listing.Modules {
+		completeModules[strings.ToLower(module.ModuleCode)] = true
+	}
+
+	listedIds := map[string]bool{}
+	for _, file := range listing.Files {
+		listedIds[file.Id] = true
+	}
+
+	files := append([]api.File{}, listing.Files...)
+	for id, indexEntry := range indexMap {
+		if listedIds[id] || completeModules[strings.ToLower(getModuleCode(indexEntry))] {
+			continue
+		}
+
+		parts := strings.Split(indexEntry.Path, "/")
+		name := api.GetOriginalName(parts[len(parts)-1], id)
+		if indexEntry.OriginalName != "" {
+			name = appFiles.NormalizeName(indexEntry.OriginalName)
+		}
+
+		files = append(files, api.File{
+			Id:        id,
+			Name:      name,
+			Ancestors: parts[:len(parts)-1],
+		})
+	}
+
+	return api.DisambiguateFiles(files)[:len(listing.Files)]
+}
+
+// fitPaths is a helper function that shortens the names of the files whose absolute paths would be longer
+// than appFiles.MAX_PATH_LENGTH, such as in deeply nested folders. Shortened names end with the file Id,
+// eg. A very long na (12345).pdf, such that they stay unique and the same on every sync.
+func fitPaths(rootSyncDirectory string, files []api.File) []api.File {
+	fitted := append([]api.File{}, files...)
+
+	for i, file := range fitted {
+		path := filepath.Join(rootSyncDirectory, filepath.FromSlash(getKey(file)))
+		if len(path) <= appFiles.MAX_PATH_LENGTH {
+			continue
+		}
+
+		name := api.GetOriginalName(file.Name, file.Id)
+		suffix := api.GetDisambiguatedName("", file.Id)
+		budget := appFiles.MAX_PATH_LENGTH - (len(path) - len(file.Name)) - len(suffix)
+		if budget <= len(filepath.Ext(name)) {
+			logs.Logger.Warnf("%s - path too long to be shortened", getKey(file))
+			continue
+		}
+
+		fitted[i].Name = api.GetDisambiguatedName(appFiles.TruncateName(name, budget), file.Id)
+	}
+
+	return fitted
+}
@@ -0,0 +1,80 @@
+package sync
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+func TestFitPaths(t *testing.T) {
+	rootSyncDirectory := "/" + strings.Repeat("d", 99)
+	longFolder := strings.Repeat("f", 100)
+
+	tests := []struct {
+		file api.File
+		want string
+	}{
+		// 100 + 1 + 6 + 1 + 11 bytes, well within the budget.
+		{
+			file: api.File{Id: "1", Name: "Lecture.pdf", Ancestors: []string{"CS2040"}},
+			want: "Lecture.pdf",
+		},
+		// 100 + 1 + 6 + 1 + 100 + 1 + 31 bytes, exactly MAX_PATH_LENGTH.
+		{
+			file: api.File{Id: "2", Name: strings.Repeat("n", 27) + ".pdf", Ancestors: []string{"CS2040", longFolder}},
+			want: strings.Repeat("n", 27) + ".pdf",
+		},
+		// One byte over, shortened to 31 bytes with the file Id such that it stays unique.
+		{
+			file: api.File{Id: "3", Name: strings.Repeat("n", 28) + ".pdf", Ancestors: []string{"CS2040", longFolder}},
+			want: strings.Repeat("n", 23) + " (3).pdf",
+		},
+		// Multibyte characters are not split, leaving 21 of the 23 bytes before the Id.
+		{
+			file: api.File{Id: "4", Name: strings.Repeat("日", 20) + ".pdf", Ancestors: []string{"CS2040", longFolder}},
+			want: strings.Repeat("日", 7) + " (4).pdf",
+		},
+	}
+
+	files := []api.File{}
+	for _, test := range tests {
+		files = append(files, test.file)
+	}
+
+	fitted := fitPaths(rootSyncDirectory, files)
+
+	for i, test := range tests {
+		if fitted[i].Name != test.want {
+			t.Errorf("fitPaths(%q) = %q, want %q", test.file.Name, fitted[i].Name, test.want)
+		}
+
+		path := filepath.Join(rootSyncDirectory, filepath.FromSlash(getKey(fitted[i])))
+		if len(path) > appFiles.MAX_PATH_LENGTH || !utf8.ValidString(path) {
+			t.Errorf("fitPaths(%q) has a path of %d bytes, want a valid path of at most %d", test.file.Name, len(path), appFiles.MAX_PATH_LENGTH)
+		}
+	}
+
+	if files[2].Name != tests[2].file.Name {
+		t.Errorf("fitPaths modified the files passed in")
+	}
+
+	// Shortened names stay the same on every sync.
+	for i, file := range fitPaths(rootSyncDirectory, fitted) {
+		if file.Name != fitted[i].Name {
+			t.Errorf("fitPaths(%q) = %q, want it unchanged", fitted[i].Name, file.Name)
+		}
+	}
+}
+
+func TestFitPathsKeepsNamesThatCannotBeShortened(t *testing.T) {
+	rootSyncDirectory := "/" + strings.Repeat("d", appFiles.MAX_PATH_LENGTH)
+	file := api.File{Id: "1", Name: "Lecture.pdf", Ancestors: []string{"CS2040"}}
+
+	if fitted := fitPaths(rootSyncDirectory, []api.File{file}); fitted[0].Name != file.Name {
+		t.Errorf("fitPaths(%q) = %q, want it unchanged", file.Name, fitted[0].Name)
+	}
+}
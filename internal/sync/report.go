@@ -0,0 +1,211 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+// ErrorCategory describes the cause of an error, which decides the hint given to the user.
+type ErrorCategory string
+
+// Error categories
+const (
+	// CategoryAuth is an error caused by an invalid or expired token.
+	CategoryAuth ErrorCategory = "auth"
+	// CategoryDiskFull is an error caused by the disk running out of space.
+	CategoryDiskFull ErrorCategory = "diskFull"
+	// CategoryPermission is an error caused by Lominus not being allowed to write to the root sync directory.
+	CategoryPermission ErrorCategory = "permission"
+	// CategoryNetwork is an error caused by the LMS being unreachable or unavailable.
+	CategoryNetwork ErrorCategory = "network"
+	// CategoryNoDirectory is an error caused by the root sync directory not being set.
+	CategoryNoDirectory ErrorCategory = "noDirectory"
+	// CategoryOther is any other error.
+	CategoryOther ErrorCategory = "other"
+)
+
+// ErrNoRootSyncDirectory is returned when a sync is carried out before the root sync directory is set.
+var ErrNoRootSyncDirectory = errors.New("root sync directory not set")
+
+// ALL_MODULES is the name shown for errors that are not specific to a module.
+const ALL_MODULES = "All modules"
+
+// ReportEntry struct is the datapack containing the errors of the same category in a module.
+// Module is empty if the errors are not specific to a module.
+type ReportEntry struct {
+	Module   string
+	Category ErrorCategory
+	Errors   []string
+}
+
+// Report struct is the datapack containing the errors of a sync, grouped by module and category.
+type Report struct {
+	Entries []ReportEntry
+}
+
+// NewReport collates the errors of the sync by module and category.
+// Entries are sorted by module, with errors that are not specific to a module first.
+func NewReport(result Result) Report {
+	entries := map[string]*ReportEntry{}
+
+	add := func(module string, err error) {
+		category := Categorize(err)
+		key := module + "/" + string(category)
+
+		if _, exists := entries[key]; !exists {
+			entries[key] = &ReportEntry{Module: module, Category: category, Errors: []string{}}
+		}
+		entries[key].Errors = append(entries[key].Errors, err.Error())
+	}
+
+	for _, moduleErr := range result.Errors {
+		add(moduleErr.Module, moduleErr.Err)
+	}
+
+	for _, fileErr := range result.Failed {
+		add(strings.Split(fileErr.Entry.Key, "/")[0], fmt.Errorf("%s: %w", fileErr.Entry.File.Name, fileErr.Err))
+	}
+
+	report := Report{Entries: []ReportEntry{}}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, *entry)
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Module != report.Entries[j].Module {
+			return report.Entries[i].Module < report.Entries[j].Module
+		}
+
+		return report.Entries[i].Category < report.Entries[j].Category
+	})
+
+	return report
+}
+
+// Categorize returns the category of the error.
+func Categorize(err error) ErrorCategory {
+	var statusErr api.StatusError
+	if errors.As(err, &statusErr) {
+		switch {
+		case statusErr.StatusCode == http.StatusUnauthorized:
+			return CategoryAuth
+		case statusErr.StatusCode == http.StatusTooManyRequests, statusErr.StatusCode >= 500:
+			return CategoryNetwork
+		default:
+			return CategoryOther
+		}
+	}
+
+	if errors.Is(err, ErrNoRootSyncDirectory) {
+		return CategoryNoDirectory
+	}
+
+	if errors.Is(err, syscall.ENOSPC) {
+		return CategoryDiskFull
+	}
+
+	if errors.Is(err, fs.ErrPermission) {
+		return CategoryPermission
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return CategoryNetwork
+	}
+
+	return CategoryOther
+}
+
+// Hint returns what the user can do about errors of the category.
+// It returns an empty string if there is nothing in particular to do.
+func (category ErrorCategory) Hint() string {
+	switch category {
+	case CategoryAuth:
+		return "Your Canvas token may have expired. Generate a new token on Canvas and save it in the Canvas tab."
+	case CategoryDiskFull:
+		return "Your disk is full. Free up some space and sync again."
+	case CategoryPermission:
+		return "Lominus is not allowed to write to your folder. Check its permissions or choose another folder in Preferences."
+	case CategoryNetwork:
+		return "Canvas could not be reached. Check your internet connection and sync again."
+	case CategoryNoDirectory:
+		return "Choose the folder to sync your files into in Preferences."
+	default:
+		return ""
+	}
+}
+
+// Count returns the number of errors in the report.
+func (report Report) Count() int {
+	count := 0
+	for _, entry := range report.Entries {
+		count += len(entry.Errors)
+	}
+
+	return count
+}
+
+// Summary returns a short description of the report that fits in a notification.
+func (report Report) Summary() string {
+	lines := []string{}
+	hints := map[ErrorCategory]bool{}
+
+	for _, entry := range report.Entries {
+		lines = append(lines, fmt.Sprintf("%s: %d %s", entry.moduleName(), len(entry.Errors), entry.Category))
+		hints[entry.Category] = true
+	}
+
+	if len(lines) > 4 {
+		lines = append(lines[:3], "...")
+	}
+
+	// A single hint is short enough to be actionable from the notification itself.
+	if len(hints) == 1 {
+		for category := range hints {
+			if hint := category.Hint(); hint != "" {
+				lines = append(lines, hint)
+			}
+		}
+	}
+
+	return strings.Join(append(lines, "See History for details."), "\n")
+}
+
+// String returns the details of the report, with the errors and hints of every module and category.
+func (report Report) String() string {
+	sections := []string{}
+
+	for _, entry := range report.Entries {
+		lines := []string{fmt.Sprintf("%s (%s)", entry.moduleName(), entry.Category)}
+		if hint := entry.Category.Hint(); hint != "" {
+			lines = append(lines, hint)
+		}
+
+		for _, err := range entry.Errors {
+			lines = append(lines, fmt.Sprintf("- %s", err))
+		}
+
+		sections = append(sections, strings.Join(lines, "\n"))
+	}
+
+	return strings.Join(sections, "\n\n")
+}
+
+// moduleName is a helper function that returns the name of the module shown to the user.
+func (entry ReportEntry) moduleName() string {
+	if entry.Module == "" {
+		return ALL_MODULES
+	}
+
+	return entry.Module
+}
@@ -0,0 +1,579 @@
+// Package sync provides primitives to plan and carry out syncs of LMS files onto
+// local storage.
+package sync
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appSyncState "github.com/beebeeoii/lominus/internal/app/syncstate"
+	"github.com/beebeeoii/lominus/internal/extract"
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/trash"
+	"github.com/beebeeoii/lominus/internal/versions"
+	"github.com/beebeeoii/lominus/pkg/api"
+)
+
+// FULL_SYNC_INTERVAL is how often the files of every module are listed completely in incremental mode,
+// to pick up the remote changes that cannot be detected incrementally, such as deletions and folder renames.
+const FULL_SYNC_INTERVAL = 24 * time.Hour
+
+// Provider retrieves files from the LMS.
+// If highWaterMarks is not nil, only the files that changed since the high-water mark of their module
+// need to be retrieved. Download stops when ctx is cancelled, without leaving a partially downloaded file.
+type Provider interface {
+	ListFiles(highWaterMarks map[string]time.Time) (RemoteListing, error)
+	Download(ctx context.Context, file api.File, folderPath string) error
+}
+
+// Storage loads and persists the data a sync needs between runs.
+type Storage interface {
+	GetPreferences() (appPref.Preferences, error)
+	GetFilterRules() (filter.Rules, error)
+	GetSyncState() (appSyncState.SyncState, error)
+	SaveSyncState(state appSyncState.SyncState) error
+	LoadIndexMap() (map[string]indexing.IndexMapEntry, error)
+	SaveIndexMap(indexMap map[string]indexing.IndexMapEntry) error
+}
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Notifier pushes notifications to the user.
+type Notifier interface {
+	Notify(title string, content string)
+}
+
+// Syncer struct carries out a sync of the files from the Provider into the root sync directory.
+// Every sync emits events to the listeners subscribed via Subscribe, in the following order:
+// SyncStarted, FilePlanned for every file, FileDownloaded or FileFailed for every file acted on,
+// and SyncFinished.
+type Syncer struct {
+	RootSyncDirectory string
+	Provider          Provider
+	Storage           Storage
+	Clock             Clock
+	Notifier          Notifier
+
+	listeners []func(Event)
+}
+
+// Result struct is the datapack containing the outcome of a sync.
+// Errors contains the errors that did not stop the sync but are not specific to a file,
+// such as modules that could not be listed.
+// Collisions contains the files not downloaded by Lominus that were in the way of a download.
+// Cancelled describes whether the sync was cancelled before every file was acted on.
+type Result struct {
+	Started    time.Time
+	Finished   time.Time
+	Plan       Plan
+	Downloaded []PlanEntry
+	Failed     []FileError
+	Conflicts  []PlanEntry
+	Collisions []Collision
+	Trashed    []PlanEntry
+	Errors     []ModuleError
+	Cancelled  bool
+}
+
+// FileError struct is the datapack containing a file that could not be moved or downloaded, and why.
+type FileError struct {
+	Entry PlanEntry
+	Err   error
+}
+
+// Collision struct is the datapack containing a file not downloaded by Lominus that was in the way
+// of a download, and the collision policy that was applied to it.
+type Collision struct {
+	Entry  PlanEntry
+	Policy string
+}
+
+// NewSyncer creates a Syncer with the given dependencies.
+func NewSyncer(
+	rootSyncDirectory string,
+	provider Provider,
+	storage Storage,
+	clock Clock,
+	notifier Notifier,
+) *Syncer {
+	return &Syncer{
+		RootSyncDirectory: rootSyncDirectory,
+		Provider:          provider,
+		Storage:           storage,
+		Clock:             clock,
+		Notifier:          notifier,
+		listeners:         []func(Event){},
+	}
+}
+
+// Subscribe registers a listener that will be called with every event emitted by the Syncer.
+// Listeners are called synchronously, in the order they were subscribed.
+func (syncer *Syncer) Subscribe(listener func(Event)) {
+	syncer.listeners = append(syncer.listeners, listener)
+}
+
+// Plan builds the Plan of what a sync would do, without downloading or modifying any file.
+func (syncer *Syncer) Plan() (Plan, error) {
+	pref, prefErr := syncer.Storage.GetPreferences()
+	if prefErr != nil {
+		logs.Logger.Warnln(prefErr)
+	}
+
+	state, stateErr := syncer.Storage.GetSyncState()
+	if stateErr != nil {
+		logs.Logger.Warnln(stateErr)
+	}
+
+	plan, _, _, err := syncer.buildPlan(syncer.getHighWaterMarks(pref, state))
+	return plan, err
+}
+
+// Run carries out one sync and returns its outcome.
+// Cancelling ctx stops the sync, including the file being downloaded. The files already acted on
+// are kept and recorded, and ctx.Err() is returned.
+//
+// TODO Cleanup notifications - make it more user friendly. No point
+// putting technical logs in notifications.
+func (syncer *Syncer) Run(ctx context.Context) (Result, error) {
+	result := Result{
+		Started:    syncer.Clock.Now(),
+		Downloaded: []PlanEntry{},
+		Failed:     []FileError{},
+		Conflicts:  []PlanEntry{},
+		Collisions: []Collision{},
+		Trashed:    []PlanEntry{},
+		Errors:     []ModuleError{},
+	}
+
+	syncer.Notifier.Notify("Sync", "Sync Started!")
+	syncer.emit(SyncStarted{Time: result.Started})
+	logs.Logger.Infof("sync started: %s", result.Started.Format(time.RFC3339))
+
+	runErr := syncer.run(ctx, &result)
+	if runErr != nil && result.Cancelled {
+		syncer.Notifier.Notify("Sync", "Sync cancelled")
+		logs.Logger.Infoln(runErr)
+	} else if runErr != nil {
+		syncer.Notifier.Notify("Sync failed", failureSummary(runErr))
+		logs.Logger.Errorln(runErr)
+	}
+
+	result.Finished = syncer.Clock.Now()
+	syncer.emit(SyncFinished{Time: result.Finished, Result: result, Err: runErr})
+	logs.Logger.Infof("sync completed: %s", result.Finished.Format(time.RFC3339))
+
+	return result, runErr
+}
+
+// run is a helper function that carries out the sync and records its outcome in result.
+func (syncer *Syncer) run(ctx context.Context, result *Result) error {
+	pref, prefErr := syncer.Storage.GetPreferences()
+	if prefErr != nil {
+		logs.Logger.Warnln(prefErr)
+	}
+
+	state, stateErr := syncer.Storage.GetSyncState()
+	if stateErr != nil {
+		logs.Logger.Warnln(stateErr)
+	}
+
+	plan, indexMap, listing, planErr := syncer.buildPlan(syncer.getHighWaterMarks(pref, state))
+	if planErr != nil {
+		return planErr
+	}
+	result.Plan = plan
+	result.Errors = append(result.Errors, listing.Errors...)
+
+	planCount := plan.Count()
+	logs.Logger.Debugf("plan built - %v", planCount)
+
+	for _, entry := range plan.Entries {
+		syncer.emit(FilePlanned{Entry: entry})
+	}
+
+	failedKeys := map[string]bool{}
+	hashes := map[string]string{}
+
+	for _, entry := range plan.Entries {
+		if entry.PreviousKey == "" {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			failedKeys[entry.Key] = true
+			continue
+		}
+
+		logs.Logger.Debugf("moving - %s [%s]", entry.Key, entry.Reason)
+		filePath := plan.LocalPath(entry)
+		appFiles.EnsureDir(filepath.Dir(filePath))
+		moveErr := os.Rename(plan.PreviousLocalPath(entry), filePath)
+		if moveErr != nil {
+			logs.Logger.Warnln(moveErr)
+			failedKeys[entry.Key] = true
+			result.Failed = append(result.Failed, FileError{Entry: entry, Err: moveErr})
+			syncer.emit(FileFailed{Entry: entry, Err: moveErr})
+		}
+	}
+
+	for i, entry := range plan.Entries {
+		if entry.Action != ActionNew && entry.Action != ActionUpdate {
+			continue
+		}
+
+		if failedKeys[entry.Key] {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			failedKeys[entry.Key] = true
+			continue
+		}
+
+		if entry.Collision {
+			collisionPolicy := pref.GetCollisionPolicy(entry.File.Ancestors[0])
+			logs.Logger.Debugf("collision - %s [%s]", entry.Key, collisionPolicy)
+			result.Collisions = append(result.Collisions, Collision{Entry: entry, Policy: collisionPolicy})
+
+			name, renamedPath, resolveErr := ResolveCollision(plan.LocalPath(entry), entry.File, collisionPolicy)
+			if resolveErr != nil {
+				logs.Logger.Warnln(resolveErr)
+				failedKeys[entry.Key] = true
+				result.Failed = append(result.Failed, FileError{Entry: entry, Err: resolveErr})
+				syncer.emit(FileFailed{Entry: entry, Err: resolveErr})
+				continue
+			}
+
+			if renamedPath != "" {
+				logs.Logger.Debugf("file in the way renamed to - %s", renamedPath)
+			}
+
+			// The plan is updated such that the index records what was actually done.
+			if name == "" {
+				entry.Action = ActionSkip
+				entry.Reason = fmt.Sprintf("skipped by collision policy: %s", collisionPolicy)
+				plan.Entries[i] = entry
+				continue
+			}
+
+			if name != entry.File.Name {
+				entry.Key = path.Join(path.Dir(entry.Key), name)
+				entry.File.Name = name
+				plan.Entries[i] = entry
+			}
+		} else if entry.Action == ActionUpdate {
+			modified, modifiedErr := IsModifiedLocally(plan.LocalPath(entry), indexMap[entry.File.Id])
+			if modifiedErr != nil {
+				logs.Logger.Warnln(modifiedErr)
+			}
+
+			if modified {
+				logs.Logger.Debugf("conflict - %s [%s]", entry.Key, pref.ConflictPolicy)
+				result.Conflicts = append(result.Conflicts, entry)
+
+				download, conflictPath, resolveErr := ResolveConflict(plan.LocalPath(entry), pref.ConflictPolicy, syncer.Clock.Now())
+				if resolveErr != nil {
+					logs.Logger.Warnln(resolveErr)
+					failedKeys[entry.Key] = true
+					result.Failed = append(result.Failed, FileError{Entry: entry, Err: resolveErr})
+					syncer.emit(FileFailed{Entry: entry, Err: resolveErr})
+					continue
+				}
+
+				if conflictPath != "" {
+					logs.Logger.Debugf("locally modified file kept as - %s", conflictPath)
+				}
+
+				// The plan is updated such that the index keeps the previous entry, and the update
+				// is offered again by the next sync.
+				if !download {
+					entry.Action = ActionSkip
+					entry.Reason = fmt.Sprintf("skipped by conflict policy: %s", pref.ConflictPolicy)
+					plan.Entries[i] = entry
+					continue
+				}
+			}
+		}
+
+		logs.Logger.Debugf("downloading - %s [%s]", entry.Key, entry.Reason)
+		filePath := filepath.Dir(plan.LocalPath(entry))
+		appFiles.EnsureDir(filePath)
+		downloadErr := syncer.Provider.Download(ctx, entry.File, filePath)
+		if downloadErr != nil && ctx.Err() != nil {
+			failedKeys[entry.Key] = true
+			continue
+		}
+
+		if downloadErr != nil {
+			logs.Logger.Warnln(downloadErr)
+			failedKeys[entry.Key] = true
+			result.Failed = append(result.Failed, FileError{Entry: entry, Err: downloadErr})
+			syncer.emit(FileFailed{Entry: entry, Err: downloadErr})
+			continue
+		}
+
+		hash, hashErr := appFiles.Hash(plan.LocalPath(entry))
+		if hashErr != nil {
+			logs.Logger.Warnln(hashErr)
+		} else {
+			hashes[entry.Key] = hash
+		}
+
+		result.Downloaded = append(result.Downloaded, entry)
+		syncer.emit(FileDownloaded{Entry: entry, Path: plan.LocalPath(entry)})
+	}
+
+	// Archives are extracted once every file is downloaded, such that they are not extracted into
+	// a folder synced from the LMS with the same name before it is downloaded.
+	if pref.ExtractArchives {
+		for _, entry := range result.Downloaded {
+			if ctx.Err() != nil || !extract.IsArchive(entry.File.Name) {
+				continue
+			}
+
+			logs.Logger.Debugf("extracting - %s", entry.Key)
+			extractDir, extractErr := extract.Extract(plan.LocalPath(entry))
+			if extractErr != nil {
+				logs.Logger.Warnln(extractErr)
+				result.Errors = append(result.Errors, ModuleError{Module: entry.File.Ancestors[0], Err: extractErr})
+			} else {
+				logs.Logger.Debugf("extracted into - %s", extractDir)
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		result.Cancelled = true
+
+		plan.UpdateIndexMap(indexMap, failedKeys, hashes)
+		saveIndexMapErr := syncer.Storage.SaveIndexMap(indexMap)
+		if saveIndexMapErr != nil {
+			logs.Logger.Errorln(saveIndexMapErr)
+		}
+
+		return ctx.Err()
+	}
+
+	if pref.MirrorDeletions {
+		for _, entry := range plan.Filter(ActionRemoteDeleted) {
+			logs.Logger.Debugf("trashing - %s [%s]", entry.Key, entry.Reason)
+			trashErr := trash.MoveToTrash(syncer.RootSyncDirectory, entry.Key, syncer.Clock.Now())
+			if trashErr != nil {
+				logs.Logger.Warnln(trashErr)
+				continue
+			}
+			result.Trashed = append(result.Trashed, entry)
+		}
+	}
+
+	purgeErr := trash.Purge(syncer.RootSyncDirectory, pref.TrashRetentionDays, syncer.Clock.Now())
+	if purgeErr != nil {
+		logs.Logger.Warnln(purgeErr)
+	}
+
+	pruneErr := versions.PruneAll(syncer.RootSyncDirectory, pref.VersionsKeepLast, pref.VersionsKeepDays, syncer.Clock.Now())
+	if pruneErr != nil {
+		logs.Logger.Warnln(pruneErr)
+	}
+
+	plan.UpdateIndexMap(indexMap, failedKeys, hashes)
+	saveIndexMapErr := syncer.Storage.SaveIndexMap(indexMap)
+	if saveIndexMapErr != nil {
+		logs.Logger.Errorln(saveIndexMapErr)
+	}
+
+	if saveIndexMapErr == nil {
+		syncer.updateSyncState(state, listing, failedKeys)
+	}
+
+	syncer.notifySummary(*result, pref.ConflictPolicy)
+
+	return nil
+}
+
+// buildPlan is a helper function that retrieves the remote and local files and builds the Plan.
+// It also returns the loaded IndexMap, keyed by file Id, and the RemoteListing, whose Errors include
+// every error that occurred while listing the remote files. The Plan is still built if some remote
+// files could not be listed.
+func (syncer *Syncer) buildPlan(highWaterMarks map[string]time.Time) (Plan, map[string]indexing.IndexMapEntry, RemoteListing, error) {
+	// If directory for file sync is not set, there is nothing to plan.
+	if syncer.RootSyncDirectory == "" {
+		return Plan{}, nil, RemoteListing{}, ErrNoRootSyncDirectory
+	}
+
+	listing, listingErr := syncer.Provider.ListFiles(highWaterMarks)
+	listing.Errors = append([]ModuleError{}, listing.Errors...)
+	if listingErr != nil {
+		logs.Logger.Warnln(listingErr)
+		listing.Errors = append(listing.Errors, ModuleError{Err: listingErr})
+	}
+
+	logs.Logger.Debugln("building - index map")
+	localFiles, localFilesErr := indexing.Build(syncer.RootSyncDirectory)
+	if localFilesErr != nil {
+		return Plan{}, nil, listing, fmt.Errorf("unable to read the files in %s: %w", syncer.RootSyncDirectory, localFilesErr)
+	}
+
+	indexMap, indexMapErr := syncer.Storage.LoadIndexMap()
+	if indexMapErr != nil {
+		return Plan{}, nil, listing, fmt.Errorf("unable to load the index of downloaded files: %w", indexMapErr)
+	}
+
+	listing.Files = fitPaths(syncer.RootSyncDirectory, disambiguateChangedFiles(listing, indexMap))
+
+	rules, rulesErr := syncer.Storage.GetFilterRules()
+	if rulesErr != nil {
+		logs.Logger.Warnln(rulesErr)
+	}
+
+	plan := BuildPlan(syncer.RootSyncDirectory, listing, localFiles, indexMap, rules)
+
+	return plan, indexMap, listing, nil
+}
+
+// getHighWaterMarks is a helper function that returns the high-water marks the files are listed
+// incrementally from, or nil if every module is to be listed completely. Every module is listed
+// completely if incremental syncs are disabled, or if it has been FULL_SYNC_INTERVAL since the last time.
+func (syncer *Syncer) getHighWaterMarks(pref appPref.Preferences, state appSyncState.SyncState) map[string]time.Time {
+	if !pref.IncrementalSync || syncer.Clock.Now().Sub(state.LastFullSync) >= FULL_SYNC_INTERVAL {
+		return nil
+	}
+
+	return state.HighWaterMarks
+}
+
+// updateSyncState is a helper function that saves the high-water marks of the modules listed,
+// except for the modules with files that failed to sync, so that those files are retrieved again
+// by the next sync.
+func (syncer *Syncer) updateSyncState(state appSyncState.SyncState, listing RemoteListing, failedKeys map[string]bool) {
+	failedModules := map[string]bool{}
+	for key := range failedKeys {
+		failedModules[strings.Split(key, "/")[0]] = true
+	}
+
+	if state.HighWaterMarks == nil {
+		state.HighWaterMarks = map[string]time.Time{}
+	}
+
+	for module, highWaterMark := range listing.HighWaterMarks {
+		if failedModules[module] {
+			continue
+		}
+		state.HighWaterMarks[module] = highWaterMark
+	}
+
+	if listing.Full && len(listing.Errors) == 0 {
+		state.LastFullSync = syncer.Clock.Now()
+	}
+
+	saveStateErr := syncer.Storage.SaveSyncState(state)
+	if saveStateErr != nil {
+		logs.Logger.Errorln(saveStateErr)
+	}
+}
+
+// notifySummary is a helper function that notifies the user of the errors, conflicts and files
+// updated during the sync.
+func (syncer *Syncer) notifySummary(result Result, conflictPolicy string) {
+	report := NewReport(result)
+	if report.Count() > 0 {
+		syncer.Notifier.Notify(fmt.Sprintf("Sync: %d errors", report.Count()), report.Summary())
+	}
+
+	if len(result.Conflicts) > 0 {
+		conflicts := []string{}
+		for _, entry := range result.Conflicts {
+			conflicts = append(conflicts, entry.Key)
+		}
+
+		syncer.Notifier.Notify(
+			fmt.Sprintf("Sync: %d locally modified", len(conflicts)),
+			conflictsSummary(conflicts, conflictPolicy),
+		)
+	}
+
+	planCount := result.Plan.Count()
+	nFilesToUpdate := planCount[ActionNew] + planCount[ActionUpdate]
+	nFilesSkipped := planCount[ActionSkip]
+
+	if len(result.Downloaded) == 0 {
+		syncer.Notifier.Notify("Sync", "Your files are up to date"+skippedSummary(nFilesSkipped))
+		return
+	}
+
+	updatedFilesModulesNames := []string{}
+	for _, entry := range result.Downloaded {
+		updatedFilesModulesNames = append(updatedFilesModulesNames, fmt.Sprintf("[%s] %s ", entry.File.Ancestors[0], entry.File.Name))
+	}
+
+	if len(updatedFilesModulesNames) > 4 {
+		updatedFilesModulesNames = append(updatedFilesModulesNames[:3], "...")
+	}
+
+	syncer.Notifier.Notify(
+		fmt.Sprintf("Sync: %d/%d updated", len(result.Downloaded), nFilesToUpdate),
+		strings.Join(updatedFilesModulesNames, "\n")+skippedSummary(nFilesSkipped),
+	)
+}
+
+// emit is a helper function that passes the event to every listener.
+func (syncer *Syncer) emit(event Event) {
+	for _, listener := range syncer.listeners {
+		listener(event)
+	}
+}
+
+// skippedSummary is a helper function that describes the number of files skipped by
+// filter rules, to be appended to the sync summary. It returns an empty string if
+// no file was skipped.
+func skippedSummary(nFilesSkipped int) string {
+	if nFilesSkipped == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("\n%d skipped by rule", nFilesSkipped)
+}
+
+// failureSummary is a helper function that describes the error that stopped the sync,
+// and what the user can do about it.
+func failureSummary(err error) string {
+	lines := []string{err.Error()}
+	if hint := Categorize(err).Hint(); hint != "" {
+		lines = append(lines, hint)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// conflictsSummary is a helper function that lists the locally modified files that were updated
+// remotely, and what was done to them according to the conflict policy.
+func conflictsSummary(conflicts []string, conflictPolicy string) string {
+	var resolution string
+
+	switch conflictPolicy {
+	case appPref.CONFLICT_SKIP:
+		resolution = "Updates skipped:"
+	case appPref.CONFLICT_OVERWRITE:
+		resolution = "Overwritten with updates:"
+	default:
+		resolution = "Kept as conflict copies:"
+	}
+
+	if len(conflicts) > 4 {
+		conflicts = append(conflicts[:3], "...")
+	}
+
+	return strings.Join(append([]string{resolution}, conflicts...), "\n")
+}
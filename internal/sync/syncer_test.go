@@ -0,0 +1,519 @@
+package sync
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appSyncState "github.com/beebeeoii/lominus/internal/app/syncstate"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/filter"
+	"github.com/beebeeoii/lominus/internal/indexing"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/pkg/api"
+	"github.com/sirupsen/logrus"
+)
+
+// fakeProvider lists Files and downloads them with the contents in Contents, keyed by file Id.
+// OnDownload, if set, is called at the start of every download.
+type fakeProvider struct {
+	Files      []api.File
+	Contents   map[string]string
+	OnDownload func(file api.File)
+}
+
+// fakeStorage keeps the data of a sync in memory.
+type fakeStorage struct {
+	pref     appPref.Preferences
+	state    appSyncState.SyncState
+	indexMap map[string]indexing.IndexMapEntry
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+type fakeNotifier struct {
+	notifications []string
+}
+
+func (provider *fakeProvider) ListFiles(highWaterMarks map[string]time.Time) (RemoteListing, error) {
+	modules := []api.Module{}
+	seen := map[string]bool{}
+	for _, file := range provider.Files {
+		if !seen[file.Ancestors[0]] {
+			seen[file.Ancestors[0]] = true
+			modules = append(modules, api.Module{ModuleCode: file.Ancestors[0], IsAccessible: true})
+		}
+	}
+
+	return RemoteListing{
+		Files:          append([]api.File{}, provider.Files...),
+		Modules:        modules,
+		Errors:         []ModuleError{},
+		HighWaterMarks: map[string]time.Time{},
+		Full:           true,
+	}, nil
+}
+
+func (provider *fakeProvider) Download(ctx context.Context, file api.File, folderPath string) error {
+	if provider.OnDownload != nil {
+		provider.OnDownload(file)
+	}
+
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return os.WriteFile(filepath.Join(folderPath, file.Name), []byte(provider.Contents[file.Id]), 0644)
+}
+
+func (storage *fakeStorage) GetPreferences() (appPref.Preferences, error) {
+	return storage.pref, nil
+}
+
+func (storage *fakeStorage) GetFilterRules() (filter.Rules, error) {
+	return filter.Rules{}, nil
+}
+
+func (storage *fakeStorage) GetSyncState() (appSyncState.SyncState, error) {
+	return storage.state, nil
+}
+
+func (storage *fakeStorage) SaveSyncState(state appSyncState.SyncState) error {
+	storage.state = state
+	return nil
+}
+
+func (storage *fakeStorage) LoadIndexMap() (map[string]indexing.IndexMapEntry, error) {
+	indexMap := map[string]indexing.IndexMapEntry{}
+	for id, entry := range storage.indexMap {
+		indexMap[id] = entry
+	}
+
+	return indexMap, nil
+}
+
+func (storage *fakeStorage) SaveIndexMap(indexMap map[string]indexing.IndexMapEntry) error {
+	storage.indexMap = indexMap
+	return nil
+}
+
+func (clock fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (notifier *fakeNotifier) Notify(title string, content string) {
+	notifier.notifications = append(notifier.notifications, title+": "+content)
+}
+
+var lastUpdated = time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+
+func TestMain(m *testing.M) {
+	logs.Logger = logrus.New()
+	logs.Logger.SetOutput(io.Discard)
+
+	os.Exit(m.Run())
+}
+
+// newTestSyncer is a helper function that creates a Syncer into a temporary root sync directory
+// with the conflict policy, which lists and downloads files.
+func newTestSyncer(t *testing.T, conflictPolicy string, files ...api.File) (*Syncer, *fakeProvider, *fakeStorage, *fakeNotifier) {
+	provider := &fakeProvider{Files: files, Contents: map[string]string{}}
+	for _, file := range files {
+		provider.Contents[file.Id] = "v1 of " + file.Name
+	}
+
+	storage := &fakeStorage{
+		pref: appPref.Preferences{
+			ConflictPolicy:  conflictPolicy,
+			CollisionPolicy: appPref.COLLISION_RENAME_OLD,
+		},
+		indexMap: map[string]indexing.IndexMapEntry{},
+	}
+	notifier := &fakeNotifier{}
+
+	syncer := NewSyncer(t.TempDir(), provider, storage, fakeClock{now: lastUpdated.Add(time.Hour)}, notifier)
+
+	return syncer, provider, storage, notifier
+}
+
+// newTestFile is a helper function that creates a remote file with the key, eg. CS2040/Lecture1.pdf.
+func newTestFile(id string, key string) api.File {
+	parts := strings.Split(key, "/")
+
+	return api.File{
+		Id:          id,
+		Name:        parts[len(parts)-1],
+		Ancestors:   parts[:len(parts)-1],
+		LastUpdated: lastUpdated,
+	}
+}
+
+// run is a helper function that runs the sync and fails the test if it returns an error.
+func run(t *testing.T, syncer *Syncer) Result {
+	t.Helper()
+
+	result, err := syncer.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() returned error: %v", err)
+	}
+
+	return result
+}
+
+// assertContents is a helper function that fails the test if the file at the key does not have the contents.
+func assertContents(t *testing.T, syncer *Syncer, key string, contents string) {
+	t.Helper()
+
+	data, readErr := os.ReadFile(filepath.Join(syncer.RootSyncDirectory, filepath.FromSlash(key)))
+	if readErr != nil {
+		t.Fatalf("unable to read %s: %v", key, readErr)
+	}
+
+	if string(data) != contents {
+		t.Errorf("%s = %q, want %q", key, data, contents)
+	}
+}
+
+// modify is a helper function that changes the contents of the local copy at the key.
+func modify(t *testing.T, syncer *Syncer, key string, contents string) {
+	t.Helper()
+
+	writeErr := os.WriteFile(filepath.Join(syncer.RootSyncDirectory, filepath.FromSlash(key)), []byte(contents), 0644)
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+}
+
+// update is a helper function that updates the remote file with the Id to a later version with the contents.
+func update(provider *fakeProvider, id string, contents string) {
+	for i, file := range provider.Files {
+		if file.Id == id {
+			provider.Files[i].LastUpdated = file.LastUpdated.Add(time.Hour)
+		}
+	}
+	provider.Contents[id] = contents
+}
+
+func TestRunDownloadsNewFiles(t *testing.T) {
+	syncer, _, storage, notifier := newTestSyncer(
+		t,
+		appPref.CONFLICT_KEEP_BOTH,
+		newTestFile("1", "CS2040/Lecture1.pdf"),
+		newTestFile("2", "CS2040/Tutorials/Tutorial1.pdf"),
+	)
+
+	events := []Event{}
+	syncer.Subscribe(func(event Event) {
+		events = append(events, event)
+	})
+
+	result := run(t, syncer)
+
+	if len(result.Downloaded) != 2 {
+		t.Fatalf("downloaded %d files, want 2", len(result.Downloaded))
+	}
+	assertContents(t, syncer, "CS2040/Lecture1.pdf", "v1 of Lecture1.pdf")
+	assertContents(t, syncer, "CS2040/Tutorials/Tutorial1.pdf", "v1 of Tutorial1.pdf")
+
+	indexEntry := storage.indexMap["2"]
+	if indexEntry.Path != "CS2040/Tutorials/Tutorial1.pdf" || indexEntry.LastUpdated != lastUpdated.Unix() || indexEntry.Hash == "" {
+		t.Errorf("index entry = %+v, want path, time and hash of the download", indexEntry)
+	}
+
+	if _, started := events[0].(SyncStarted); !started {
+		t.Errorf("first event = %T, want SyncStarted", events[0])
+	}
+	if _, finished := events[len(events)-1].(SyncFinished); !finished {
+		t.Errorf("last event = %T, want SyncFinished", events[len(events)-1])
+	}
+
+	if last := notifier.notifications[len(notifier.notifications)-1]; !strings.HasPrefix(last, "Sync: 2/2 updated") {
+		t.Errorf("last notification = %q, want the number of files updated", last)
+	}
+
+	result = run(t, syncer)
+	if len(result.Downloaded) != 0 {
+		t.Errorf("downloaded %d files on the second sync, want 0", len(result.Downloaded))
+	}
+}
+
+func TestRunUpdatesFiles(t *testing.T) {
+	syncer, provider, storage, _ := newTestSyncer(t, appPref.CONFLICT_KEEP_BOTH, newTestFile("1", "CS2040/Lecture1.pdf"))
+	run(t, syncer)
+
+	update(provider, "1", "v2")
+	result := run(t, syncer)
+
+	if len(result.Downloaded) != 1 || result.Downloaded[0].Action != ActionUpdate {
+		t.Fatalf("downloaded %+v, want the update", result.Downloaded)
+	}
+	if len(result.Conflicts) != 0 {
+		t.Errorf("conflicts = %+v, want none", result.Conflicts)
+	}
+	assertContents(t, syncer, "CS2040/Lecture1.pdf", "v2")
+
+	if storage.indexMap["1"].LastUpdated != lastUpdated.Add(time.Hour).Unix() {
+		t.Errorf("indexed time = %d, want the time of the update", storage.indexMap["1"].LastUpdated)
+	}
+}
+
+func TestRunMovesFiles(t *testing.T) {
+	syncer, provider, storage, _ := newTestSyncer(t, appPref.CONFLICT_KEEP_BOTH, newTestFile("1", "CS2040/Lecture1.pdf"))
+	run(t, syncer)
+
+	provider.Files[0].Ancestors = []string{"CS2040", "Week 1"}
+	result := run(t, syncer)
+
+	if len(result.Downloaded) != 0 {
+		t.Errorf("downloaded %+v, want the file moved instead", result.Downloaded)
+	}
+	if moved := result.Plan.Filter(ActionMove); len(moved) != 1 {
+		t.Fatalf("moved %+v, want 1 file", moved)
+	}
+	assertContents(t, syncer, "CS2040/Week 1/Lecture1.pdf", "v1 of Lecture1.pdf")
+
+	if _, statErr := os.Stat(filepath.Join(syncer.RootSyncDirectory, "CS2040", "Lecture1.pdf")); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("previous path still exists: %v", statErr)
+	}
+	if storage.indexMap["1"].Path != "CS2040/Week 1/Lecture1.pdf" {
+		t.Errorf("indexed path = %s, want the new path", storage.indexMap["1"].Path)
+	}
+}
+
+func TestRunDoesNotIndexFilesItDidNotDownload(t *testing.T) {
+	syncer, provider, storage, _ := newTestSyncer(t, appPref.CONFLICT_KEEP_BOTH, newTestFile("1", "CS2040/Lecture1.pdf"))
+
+	// The user's own file has the name of the remote file and is newer than it.
+	userPath := filepath.Join(syncer.RootSyncDirectory, "CS2040", "Lecture1.pdf")
+	if err := os.MkdirAll(filepath.Dir(userPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	modify(t, syncer, "CS2040/Lecture1.pdf", "my notes")
+	if err := os.Chtimes(userPath, lastUpdated.Add(30*time.Minute), lastUpdated.Add(30*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	result := run(t, syncer)
+	if unchanged := result.Plan.Filter(ActionUnchanged); len(unchanged) != 1 {
+		t.Fatalf("unchanged %+v, want the user's file", unchanged)
+	}
+	if indexEntry, indexed := storage.indexMap["1"]; indexed {
+		t.Fatalf("index entry = %+v, want the user's file not indexed", indexEntry)
+	}
+
+	update(provider, "1", "v2")
+	result = run(t, syncer)
+
+	if len(result.Collisions) != 1 {
+		t.Fatalf("collisions = %+v, want the user's file in the way of the update", result.Collisions)
+	}
+	assertContents(t, syncer, "CS2040/Lecture1.pdf", "v2")
+
+	entries, readErr := os.ReadDir(filepath.Dir(userPath))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("%s has %d files, want the update and the user's renamed file", filepath.Dir(userPath), len(entries))
+	}
+	for _, entry := range entries {
+		if entry.Name() != "Lecture1.pdf" {
+			assertContents(t, syncer, "CS2040/"+entry.Name(), "my notes")
+		}
+	}
+}
+
+func TestRunConflicts(t *testing.T) {
+	tests := []struct {
+		policy   string
+		contents string
+		kept     func(syncer *Syncer) ([]string, error)
+	}{
+		{
+			policy:   appPref.CONFLICT_KEEP_BOTH,
+			contents: "v2",
+			kept: func(syncer *Syncer) ([]string, error) {
+				return filepath.Glob(filepath.Join(syncer.RootSyncDirectory, "CS2040", "Lecture1*conflict*.pdf"))
+			},
+		},
+		{
+			policy:   appPref.CONFLICT_OVERWRITE,
+			contents: "v2",
+			kept: func(syncer *Syncer) ([]string, error) {
+				return filepath.Glob(filepath.Join(syncer.RootSyncDirectory, "CS2040", appConstants.VERSIONS_DIR_NAME, "Lecture1.*.pdf"))
+			},
+		},
+		{
+			policy:   appPref.CONFLICT_SKIP,
+			contents: "my notes",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.policy, func(t *testing.T) {
+			syncer, provider, _, _ := newTestSyncer(t, test.policy, newTestFile("1", "CS2040/Lecture1.pdf"))
+			run(t, syncer)
+
+			modify(t, syncer, "CS2040/Lecture1.pdf", "my notes")
+			update(provider, "1", "v2")
+			result := run(t, syncer)
+
+			if len(result.Conflicts) != 1 {
+				t.Fatalf("conflicts = %+v, want 1", result.Conflicts)
+			}
+			assertContents(t, syncer, "CS2040/Lecture1.pdf", test.contents)
+
+			if test.kept != nil {
+				kept, globErr := test.kept(syncer)
+				if globErr != nil || len(kept) != 1 {
+					t.Fatalf("locally modified copies kept = %v (%v), want 1", kept, globErr)
+				}
+
+				data, _ := os.ReadFile(kept[0])
+				if string(data) != "my notes" {
+					t.Errorf("%s = %q, want the locally modified contents", kept[0], data)
+				}
+			}
+		})
+	}
+}
+
+func TestRunSkippedConflictIsOfferedAgain(t *testing.T) {
+	syncer, provider, storage, _ := newTestSyncer(t, appPref.CONFLICT_SKIP, newTestFile("1", "CS2040/Lecture1.pdf"))
+	run(t, syncer)
+
+	modify(t, syncer, "CS2040/Lecture1.pdf", "my notes")
+	update(provider, "1", "v2")
+	run(t, syncer)
+
+	if storage.indexMap["1"].LastUpdated != lastUpdated.Unix() {
+		t.Errorf("indexed time = %d, want the time of the skipped version", storage.indexMap["1"].LastUpdated)
+	}
+
+	// Once the local changes are discarded, the update is downloaded.
+	storage.pref.ConflictPolicy = appPref.CONFLICT_OVERWRITE
+	result := run(t, syncer)
+
+	if len(result.Downloaded) != 1 {
+		t.Fatalf("downloaded %+v, want the skipped update", result.Downloaded)
+	}
+	assertContents(t, syncer, "CS2040/Lecture1.pdf", "v2")
+}
+
+func TestRunCancelled(t *testing.T) {
+	syncer, provider, storage, notifier := newTestSyncer(
+		t,
+		appPref.CONFLICT_KEEP_BOTH,
+		newTestFile("1", "CS2040/Lecture1.pdf"),
+		newTestFile("2", "CS2040/Lecture2.pdf"),
+		newTestFile("3", "CS2040/Lecture3.pdf"),
+	)
+
+	// The sync is cancelled while the second file is being downloaded.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider.OnDownload = func(file api.File) {
+		if file.Id == "2" {
+			cancel()
+		}
+	}
+
+	result, err := syncer.Run(ctx)
+
+	if !errors.Is(err, context.Canceled) || !result.Cancelled {
+		t.Fatalf("Run() = %v (cancelled %t), want context.Canceled", err, result.Cancelled)
+	}
+	if len(result.Downloaded) != 1 || result.Downloaded[0].File.Id != "1" {
+		t.Fatalf("downloaded %+v, want only the file downloaded before the sync was cancelled", result.Downloaded)
+	}
+	if len(result.Failed) != 0 {
+		t.Errorf("failed %+v, want the interrupted download not to be reported as failed", result.Failed)
+	}
+	if _, indexed := storage.indexMap["1"]; !indexed || len(storage.indexMap) != 1 {
+		t.Errorf("index = %+v, want only the file downloaded", storage.indexMap)
+	}
+	if last := notifier.notifications[len(notifier.notifications)-1]; last != "Sync: Sync cancelled" {
+		t.Errorf("last notification = %q, want the sync cancelled", last)
+	}
+
+	provider.OnDownload = nil
+	result = run(t, syncer)
+	if len(result.Downloaded) != 2 {
+		t.Errorf("downloaded %d files after the cancelled sync, want the remaining 2", len(result.Downloaded))
+	}
+}
+
+func TestRunNotifiesWhyItFailed(t *testing.T) {
+	syncer, _, _, notifier := newTestSyncer(t, appPref.CONFLICT_KEEP_BOTH, newTestFile("1", "CS2040/Lecture1.pdf"))
+	syncer.RootSyncDirectory = ""
+
+	_, err := syncer.Run(context.Background())
+
+	if !errors.Is(err, ErrNoRootSyncDirectory) {
+		t.Fatalf("Run() = %v, want ErrNoRootSyncDirectory", err)
+	}
+	if last := notifier.notifications[len(notifier.notifications)-1]; !strings.Contains(last, CategoryNoDirectory.Hint()) {
+		t.Errorf("last notification = %q, want the hint to choose a folder", last)
+	}
+}
+
+func TestRunExtractsArchivesNextToFoldersWithTheSameName(t *testing.T) {
+	syncer, provider, storage, _ := newTestSyncer(
+		t,
+		appPref.CONFLICT_KEEP_BOTH,
+		newTestFile("1", "CS2040/Week3.zip"),
+		newTestFile("2", "CS2040/Week3/Notes.pdf"),
+	)
+	storage.pref.ExtractArchives = true
+	provider.Contents["1"] = newTestZip(t, map[string]string{"Notes.pdf": "zipped notes", "Slides.pdf": "zipped slides"})
+
+	result := run(t, syncer)
+
+	if len(result.Errors) != 0 {
+		t.Fatalf("errors = %+v, want none", result.Errors)
+	}
+	assertContents(t, syncer, "CS2040/Week3/Notes.pdf", "v1 of Notes.pdf")
+	assertContents(t, syncer, "CS2040/Week3 (extracted)/Notes.pdf", "zipped notes")
+	assertContents(t, syncer, "CS2040/Week3 (extracted)/Slides.pdf", "zipped slides")
+
+	// The updated archive is extracted into the same folder.
+	update(provider, "1", newTestZip(t, map[string]string{"Notes.pdf": "zipped notes v2"}))
+	run(t, syncer)
+
+	assertContents(t, syncer, "CS2040/Week3/Notes.pdf", "v1 of Notes.pdf")
+	assertContents(t, syncer, "CS2040/Week3 (extracted)/Notes.pdf", "zipped notes v2")
+	if _, statErr := os.Stat(filepath.Join(syncer.RootSyncDirectory, "CS2040", "Week3 (extracted)", "Slides.pdf")); !errors.Is(statErr, os.ErrNotExist) {
+		t.Errorf("file no longer in the archive still exists: %v", statErr)
+	}
+}
+
+// newTestZip is a helper function that returns the contents of a zip archive of the files, keyed by name.
+func newTestZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	buffer := bytes.Buffer{}
+	writer := zip.NewWriter(&buffer)
+	for name, contents := range files {
+		fileWriter, createErr := writer.Create(name)
+		if createErr != nil {
+			t.Fatal(createErr)
+		}
+		fileWriter.Write([]byte(contents))
+	}
+
+	if closeErr := writer.Close(); closeErr != nil {
+		t.Fatal(closeErr)
+	}
+
+	return buffer.String()
+}
@@ -0,0 +1,142 @@
+// Package trash provides primitives to move files deleted remotely into a local trash folder,
+// and to restore or purge them.
+package trash
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+)
+
+// Item struct describes a file in the trash.
+// Date is the day the file was trashed in the DATE_FORMAT.
+// Key is the original path of the file relative to the root sync directory, eg. CS2040/Lectures/Lecture1.pdf.
+type Item struct {
+	Date string
+	Key  string
+	Path string
+}
+
+const DATE_FORMAT = "2006-01-02"
+
+// GetTrashDir returns the trash folder of the root sync directory.
+// Trashed files are stored in <root sync directory>/.lominus-trash/<date>/<original path>.
+func GetTrashDir(rootSyncDirectory string) string {
+	return filepath.Join(rootSyncDirectory, appConstants.TRASH_DIR_NAME)
+}
+
+// MoveToTrash moves the file at key, relative to the root sync directory, into the trash folder
+// for the day. Files are never deleted.
+func MoveToTrash(rootSyncDirectory string, key string, now time.Time) error {
+	filePath := filepath.Join(rootSyncDirectory, filepath.FromSlash(key))
+	trashPath := filepath.Join(GetTrashDir(rootSyncDirectory), now.Format(DATE_FORMAT), filepath.FromSlash(key))
+
+	if !appFiles.Exists(filePath) {
+		return &appFiles.FileNotFoundError{FileName: filePath}
+	}
+
+	if ensureDirErr := appFiles.EnsureDir(filepath.Dir(trashPath)); ensureDirErr != nil {
+		return ensureDirErr
+	}
+
+	// The same file can be trashed more than once a day if it is restored in between.
+	if appFiles.Exists(trashPath) {
+		if _, renameErr := appFiles.AutoRename(trashPath); renameErr != nil {
+			return renameErr
+		}
+	}
+
+	return os.Rename(filePath, trashPath)
+}
+
+// List returns all the files in the trash, most recently trashed first.
+func List(rootSyncDirectory string) ([]Item, error) {
+	items := []Item{}
+	trashDir := GetTrashDir(rootSyncDirectory)
+
+	if !appFiles.Exists(trashDir) {
+		return items, nil
+	}
+
+	err := filepath.Walk(trashDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		relativePath := filepath.ToSlash(path[len(trashDir)+1:])
+		date, key, found := strings.Cut(relativePath, "/")
+		if !found {
+			return nil
+		}
+
+		items = append(items, Item{
+			Date: date,
+			Key:  key,
+			Path: path,
+		})
+
+		return nil
+	})
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].Date > items[j].Date
+	})
+
+	return items, err
+}
+
+// Restore moves a trashed file back to where it was before it was trashed.
+// It fails if a file already exists at the original location.
+func Restore(rootSyncDirectory string, item Item) error {
+	filePath := filepath.Join(rootSyncDirectory, filepath.FromSlash(item.Key))
+
+	if appFiles.Exists(filePath) {
+		return fmt.Errorf("unable to restore %s: file already exists", item.Key)
+	}
+
+	if ensureDirErr := appFiles.EnsureDir(filepath.Dir(filePath)); ensureDirErr != nil {
+		return ensureDirErr
+	}
+
+	return os.Rename(item.Path, filePath)
+}
+
+// Purge permanently removes files trashed more than retentionDays ago.
+// Nothing is removed if retentionDays is not positive.
+func Purge(rootSyncDirectory string, retentionDays int, now time.Time) error {
+	trashDir := GetTrashDir(rootSyncDirectory)
+
+	if retentionDays <= 0 || !appFiles.Exists(trashDir) {
+		return nil
+	}
+
+	dateDirs, readDirErr := os.ReadDir(trashDir)
+	if readDirErr != nil {
+		return readDirErr
+	}
+
+	cutOff := now.AddDate(0, 0, -retentionDays)
+
+	for _, dateDir := range dateDirs {
+		date, parseErr := time.ParseInLocation(DATE_FORMAT, dateDir.Name(), now.Location())
+		if parseErr != nil || !dateDir.IsDir() || !date.Before(cutOff) {
+			continue
+		}
+
+		if removeErr := os.RemoveAll(filepath.Join(trashDir, dateDir.Name())); removeErr != nil {
+			return removeErr
+		}
+	}
+
+	return nil
+}
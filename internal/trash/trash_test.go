@@ -0,0 +1,115 @@
+package trash
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMoveToTrashAndRestore(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+
+	writeFile(t, filepath.Join(root, "CS2040", "Lecture1.pdf"), "v1")
+	if err := MoveToTrash(root, "CS2040/Lecture1.pdf", now); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same file trashed again on the same day is kept too.
+	writeFile(t, filepath.Join(root, "CS2040", "Lecture1.pdf"), "v2")
+	if err := MoveToTrash(root, "CS2040/Lecture1.pdf", now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := MoveToTrash(root, "CS2040/Missing.pdf", now); err == nil {
+		t.Errorf("MoveToTrash() of a missing file = nil, want an error")
+	}
+
+	items, listErr := List(root)
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	if len(items) != 2 {
+		t.Fatalf("List() = %+v, want both trashed files", items)
+	}
+
+	var trashed Item
+	for _, item := range items {
+		if item.Date != "2024-03-01" {
+			t.Errorf("trashed on %s, want 2024-03-01", item.Date)
+		}
+		if item.Key == "CS2040/Lecture1.pdf" {
+			trashed = item
+		}
+	}
+	if trashed.Key == "" {
+		t.Fatalf("List() = %+v, want the file at its original key", items)
+	}
+
+	if err := Restore(root, trashed); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(root, "CS2040", "Lecture1.pdf")); string(data) != "v2" {
+		t.Errorf("restored %q, want the file trashed last under its name", data)
+	}
+
+	// A file is never restored over another.
+	for _, item := range items {
+		if item.Key != trashed.Key {
+			writeFile(t, filepath.Join(root, filepath.FromSlash(item.Key)), "other")
+			if err := Restore(root, item); err == nil {
+				t.Errorf("Restore() over an existing file = nil, want an error")
+			}
+		}
+	}
+}
+
+func TestPurge(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 3, 31, 10, 0, 0, 0, time.Local)
+
+	for _, date := range []string{"2024-02-29", "2024-03-02", "2024-03-30"} {
+		writeFile(t, filepath.Join(GetTrashDir(root), date, "CS2040", "Lecture1.pdf"), date)
+	}
+	writeFile(t, filepath.Join(GetTrashDir(root), "notes", "mine.txt"), "not a date")
+
+	if err := Purge(root, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	if items, _ := List(root); len(items) != 4 {
+		t.Fatalf("List() = %+v after Purge() without retention, want nothing removed", items)
+	}
+
+	if err := Purge(root, 30, now); err != nil {
+		t.Fatal(err)
+	}
+
+	items, listErr := List(root)
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+
+	dates := map[string]bool{}
+	for _, item := range items {
+		dates[item.Date] = true
+	}
+	if dates["2024-02-29"] || !dates["2024-03-02"] || !dates["2024-03-30"] || !dates["notes"] {
+		t.Errorf("List() = %+v, want only the files trashed more than 30 days ago removed", items)
+	}
+	if last := items[len(items)-1]; last.Date != "2024-03-02" {
+		t.Errorf("List() ends with %s, want the least recently trashed last", last.Date)
+	}
+}
+
+// writeFile is a helper function that writes the contents to filePath, creating its folder.
+func writeFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
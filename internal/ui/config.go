@@ -0,0 +1,120 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appConfig "github.com/beebeeoii/lominus/internal/app/config"
+	appDir "github.com/beebeeoii/lominus/internal/app/dir"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	fileDialog "github.com/sqweek/dialog"
+)
+
+// getConfigView builds the view for exporting the user's settings to a config file and importing them
+// from one. It is placed in the Preferences tab.
+func getConfigView(parentWindow fyne.Window) (fyne.CanvasObject, error) {
+	logs.Logger.Debugln("config view loaded")
+
+	label := widget.NewLabelWithStyle(
+		appConstants.CONFIG_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+
+	startupConfigPath := appConstants.CONFIG_FILE_NAME
+	if baseDir, retrieveBaseDirErr := appDir.GetBaseDir(); retrieveBaseDirErr == nil {
+		startupConfigPath = filepath.Join(baseDir, appConstants.CONFIG_FILE_NAME)
+	}
+
+	description := widget.NewRichTextFromMarkdown(fmt.Sprintf(appConstants.CONFIG_DESCRIPTION, startupConfigPath))
+	description.Wrapping = fyne.TextWrapWord
+
+	exportButton := widget.NewButton(appConstants.EXPORT_CONFIG_TEXT, func() {
+		dialog.NewConfirm(appConstants.APP_NAME, appConstants.EXPORT_CONFIG_SECRETS_MESSAGE, func(includeSecrets bool) {
+			exportConfig(parentWindow, includeSecrets)
+		}, parentWindow).Show()
+	})
+
+	importButton := widget.NewButton(appConstants.IMPORT_CONFIG_TEXT, func() {
+		filePath, fileErr := fileDialog.File().
+			Filter("TOML", "toml").
+			Filter("JSON", "json").
+			Title(appConstants.IMPORT_CONFIG_TEXT).
+			Load()
+		if fileErr != nil {
+			if fileErr.Error() != "Cancelled" {
+				logs.Logger.Errorln(fileErr)
+			}
+			return
+		}
+
+		importErr := appConfig.ImportFile(filePath)
+		if importErr != nil {
+			logs.Logger.Errorln(importErr)
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.CONFIG_IMPORT_FAILED_MESSAGE, importErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		logs.Logger.Infof("config imported: %s", filePath)
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.CONFIG_IMPORTED_MESSAGE,
+			parentWindow,
+		).Show()
+	})
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		container.NewGridWithColumns(2, exportButton, importButton),
+	), nil
+}
+
+// exportConfig is a helper function that asks the user where to export their settings to, and exports them.
+func exportConfig(parentWindow fyne.Window, includeSecrets bool) {
+	filePath, fileErr := fileDialog.File().
+		Filter("TOML", "toml").
+		Filter("JSON", "json").
+		Title(appConstants.EXPORT_CONFIG_TEXT).
+		Save()
+	if fileErr != nil {
+		if fileErr.Error() != "Cancelled" {
+			logs.Logger.Errorln(fileErr)
+		}
+		return
+	}
+
+	if filepath.Ext(filePath) == "" {
+		filePath += ".toml"
+	}
+
+	exportErr := appConfig.ExportFile(filePath, includeSecrets)
+	if exportErr != nil {
+		logs.Logger.Errorln(exportErr)
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.PREFERENCES_FAILED_MESSAGE,
+			parentWindow,
+		).Show()
+		return
+	}
+
+	logs.Logger.Infof("config exported: %s", filePath)
+	dialog.NewInformation(
+		appConstants.APP_NAME,
+		fmt.Sprintf(appConstants.CONFIG_EXPORTED_MESSAGE, filePath),
+		parentWindow,
+	).Show()
+}
@@ -0,0 +1,79 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appFilter "github.com/beebeeoii/lominus/internal/app/filter"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/filter"
+	logs "github.com/beebeeoii/lominus/internal/log"
+)
+
+type FiltersData struct {
+	Rules string
+}
+
+// getFiltersTab builds the filters tab in the main UI.
+func getFiltersTab(filtersData FiltersData, parentWindow fyne.Window) (*container.TabItem, error) {
+	logs.Logger.Debugln("filters tab loaded")
+	tab := container.NewTabItem(appConstants.FILTERS_TITLE, container.NewVBox())
+
+	label := widget.NewLabelWithStyle(
+		appConstants.FILTERS_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.FILTERS_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	rulesEntry := widget.NewMultiLineEntry()
+	rulesEntry.SetPlaceHolder(appConstants.FILTERS_PLACEHOLDER)
+	rulesEntry.SetMinRowsVisible(8)
+	rulesEntry.SetText(filtersData.Rules)
+
+	saveButton := widget.NewButton(appConstants.SAVE_FILTERS_TEXT, func() {
+		if _, parseErr := filter.Parse(rulesEntry.Text); parseErr != nil {
+			logs.Logger.Debugf("invalid filter rules - %s", parseErr.Error())
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.FILTERS_INVALID_MESSAGE, parseErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		saveErr := appFilter.SaveFilterText(rulesEntry.Text)
+		if saveErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(saveErr)
+			return
+		}
+
+		logs.Logger.Debugln("filters saved")
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.FILTERS_SAVED_MESSAGE,
+			parentWindow,
+		).Show()
+	})
+
+	tab.Content = container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		rulesEntry,
+		saveButton,
+	)
+
+	return tab, nil
+}
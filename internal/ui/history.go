@@ -0,0 +1,146 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appHistory "github.com/beebeeoii/lominus/internal/app/history"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+)
+
+// HISTORY_TIME_FORMAT is the format the start and end times of runs are shown in.
+const HISTORY_TIME_FORMAT = "2006-01-02 15:04:05"
+
+// getHistoryTab builds the history tab in the main UI.
+func getHistoryTab(parentWindow fyne.Window) (*container.TabItem, error) {
+	logs.Logger.Debugln("history tab loaded")
+	tab := container.NewTabItem(appConstants.HISTORY_TITLE, container.NewVBox())
+
+	label := widget.NewLabelWithStyle(
+		appConstants.HISTORY_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.HISTORY_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	runs := []appHistory.Run{}
+	emptyLabel := widget.NewLabel(appConstants.HISTORY_EMPTY_MESSAGE)
+
+	list := widget.NewList(
+		func() int {
+			return len(runs)
+		},
+		func() fyne.CanvasObject {
+			return widget.NewLabel("")
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			run := runs[id]
+			object.(*widget.Label).SetText(fmt.Sprintf(
+				appConstants.HISTORY_RUN_TEXT,
+				run.Started.Format(HISTORY_TIME_FORMAT),
+				run.Trigger,
+				run.Added,
+				run.Updated,
+				run.Failed,
+			))
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		showRunDialog(runs[id], parentWindow)
+		list.Unselect(id)
+	}
+
+	refresh := func() {
+		history, historyErr := appHistory.GetRuns()
+		if historyErr != nil {
+			logs.Logger.Errorln(historyErr)
+			return
+		}
+
+		runs = history
+		if len(runs) == 0 {
+			emptyLabel.Show()
+		} else {
+			emptyLabel.Hide()
+		}
+		list.Refresh()
+	}
+	refresh()
+
+	refreshButton := widget.NewButton(appConstants.REFRESH_TEXT, refresh)
+
+	tab.Content = container.NewBorder(
+		container.NewVBox(label, widget.NewSeparator(), description, emptyLabel),
+		refreshButton,
+		nil,
+		nil,
+		list,
+	)
+
+	return tab, nil
+}
+
+// showRunDialog shows the details of the run, including what happened to each file.
+func showRunDialog(run appHistory.Run, parentWindow fyne.Window) {
+	modules := strings.Join(run.Modules, ", ")
+	if modules == "" {
+		modules = "-"
+	}
+
+	details := []string{fmt.Sprintf(
+		appConstants.HISTORY_DETAILS_TEXT,
+		run.Started.Format(HISTORY_TIME_FORMAT),
+		run.Finished.Format(HISTORY_TIME_FORMAT),
+		run.Trigger,
+		modules,
+	)}
+
+	if run.Cancelled {
+		details = append(details, "", appConstants.HISTORY_CANCELLED_TEXT)
+	}
+
+	if len(run.Report.Entries) > 0 {
+		details = append(details, "", appConstants.HISTORY_ERRORS_TEXT, run.Report.String())
+	} else if len(run.Errors) > 0 {
+		details = append(details, "", appConstants.HISTORY_ERRORS_TEXT)
+		for _, runErr := range run.Errors {
+			details = append(details, fmt.Sprintf("- %s", runErr))
+		}
+	}
+
+	details = append(details, "", appConstants.HISTORY_FILES_TEXT)
+	if len(run.Files) == 0 {
+		details = append(details, appConstants.HISTORY_NO_FILES_TEXT)
+	}
+	for _, fileResult := range run.Files {
+		line := fmt.Sprintf("[%s] %s (%s)", fileResult.Result, fileResult.Key, fileResult.Action)
+		if fileResult.Error != "" {
+			line = fmt.Sprintf("%s: %s", line, fileResult.Error)
+		}
+		if fileResult.Policy != "" {
+			line = fmt.Sprintf("%s: %s", line, collisionPolicyMap[fileResult.Policy])
+		}
+		details = append(details, line)
+	}
+
+	detailsLabel := widget.NewLabel(strings.Join(details, "\n"))
+	detailsLabel.Wrapping = fyne.TextWrapWord
+	detailsScroll := container.NewVScroll(detailsLabel)
+	detailsScroll.SetMinSize(fyne.NewSize(500, 400))
+
+	dialog.NewCustom(
+		fmt.Sprintf("%s - %s", appConstants.HISTORY_TITLE, run.Started.Format(time.RFC1123)),
+		appConstants.CLOSE_TEXT,
+		detailsScroll,
+		parentWindow,
+	).Show()
+}
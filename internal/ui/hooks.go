@@ -0,0 +1,79 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appHooks "github.com/beebeeoii/lominus/internal/app/hooks"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/hooks"
+	logs "github.com/beebeeoii/lominus/internal/log"
+)
+
+type HooksData struct {
+	Hooks string
+}
+
+// getHooksTab builds the hooks tab in the main UI.
+func getHooksTab(hooksData HooksData, parentWindow fyne.Window) (*container.TabItem, error) {
+	logs.Logger.Debugln("hooks tab loaded")
+	tab := container.NewTabItem(appConstants.HOOKS_TITLE, container.NewVBox())
+
+	label := widget.NewLabelWithStyle(
+		appConstants.HOOKS_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.HOOKS_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	hooksEntry := widget.NewMultiLineEntry()
+	hooksEntry.SetPlaceHolder(appConstants.HOOKS_PLACEHOLDER)
+	hooksEntry.SetMinRowsVisible(8)
+	hooksEntry.SetText(hooksData.Hooks)
+
+	saveButton := widget.NewButton(appConstants.SAVE_HOOKS_TEXT, func() {
+		if _, parseErr := hooks.Parse(hooksEntry.Text); parseErr != nil {
+			logs.Logger.Debugf("invalid hooks - %s", parseErr.Error())
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.HOOKS_INVALID_MESSAGE, parseErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		saveErr := appHooks.SaveHooksText(hooksEntry.Text)
+		if saveErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(saveErr)
+			return
+		}
+
+		logs.Logger.Debugln("hooks saved")
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.HOOKS_SAVED_MESSAGE,
+			parentWindow,
+		).Show()
+	})
+
+	tab.Content = container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		hooksEntry,
+		saveButton,
+	)
+
+	return tab, nil
+}
@@ -4,6 +4,7 @@ package ui
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
@@ -13,23 +14,60 @@ import (
 	appDir "github.com/beebeeoii/lominus/internal/app/dir"
 	appPref "github.com/beebeeoii/lominus/internal/app/pref"
 	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	"github.com/beebeeoii/lominus/internal/cron"
 	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/schedule"
+	"github.com/beebeeoii/lominus/pkg/api"
 	fileDialog "github.com/sqweek/dialog"
 )
 
-var frequencyMap = map[int]string{
-	1:  appConstants.SYNC_FREQUENCY_ONE_HOUR,
-	2:  appConstants.SYNC_FREQUENCY_TWO_HOUR,
-	4:  appConstants.SYNC_FREQUENCY_FOUR_HOUR,
-	6:  appConstants.SYNC_FREQUENCY_SIX_HOUR,
-	12: appConstants.SYNC_FREQUENCY_TWELVE_HOUR,
-	-1: appConstants.SYNC_FREQUENCY_DISABLED,
+var scheduleKindMap = map[schedule.Kind]string{
+	schedule.KindDisabled: appConstants.SYNC_SCHEDULE_DISABLED,
+	schedule.KindEvery:    appConstants.SYNC_SCHEDULE_EVERY,
+	schedule.KindCron:     appConstants.SYNC_SCHEDULE_CRON,
+	schedule.KindDaily:    appConstants.SYNC_SCHEDULE_DAILY,
+}
+
+var scheduleHintMap = map[schedule.Kind]string{
+	schedule.KindEvery: appConstants.SYNC_SCHEDULE_EVERY_HINT,
+	schedule.KindCron:  appConstants.SYNC_SCHEDULE_CRON_HINT,
+	schedule.KindDaily: appConstants.SYNC_SCHEDULE_DAILY_HINT,
+}
+
+var trashRetentionMap = map[int]string{
+	7:  appConstants.TRASH_RETENTION_SEVEN_DAYS,
+	30: appConstants.TRASH_RETENTION_THIRTY_DAYS,
+	90: appConstants.TRASH_RETENTION_NINETY_DAYS,
+	-1: appConstants.TRASH_RETENTION_FOREVER,
+}
+
+var conflictPolicyMap = map[string]string{
+	appPref.CONFLICT_KEEP_BOTH: appConstants.CONFLICT_KEEP_BOTH_TEXT,
+	appPref.CONFLICT_SKIP:      appConstants.CONFLICT_SKIP_TEXT,
+	appPref.CONFLICT_OVERWRITE: appConstants.CONFLICT_OVERWRITE_TEXT,
+}
+
+var collisionPolicyMap = map[string]string{
+	appPref.COLLISION_RENAME_OLD: appConstants.COLLISION_RENAME_OLD_TEXT,
+	appPref.COLLISION_RENAME_NEW: appConstants.COLLISION_RENAME_NEW_TEXT,
+	appPref.COLLISION_OVERWRITE:  appConstants.COLLISION_OVERWRITE_TEXT,
+	appPref.COLLISION_SKIP:       appConstants.COLLISION_SKIP_TEXT,
 }
 
 type PreferencesData struct {
-	Directory string
-	Frequency int
-	LogLevel  string
+	Directory               string
+	Schedule                schedule.Schedule
+	QuietHours              schedule.QuietHours
+	IncrementalSync         bool
+	ExtractArchives         bool
+	LogLevel                string
+	MirrorDeletions         bool
+	TrashRetentionDays      int
+	ConflictPolicy          string
+	CollisionPolicy         string
+	ModuleCollisionPolicies map[string]string
+	VersionsKeepLast        int
+	VersionsKeepDays        int
 }
 
 // getPreferencesTab builds the preferences tab in the main UI.
@@ -42,17 +80,71 @@ func getPreferencesTab(preferencesData PreferencesData, _ fyne.Window) (*contain
 		return tab, fileDirectoryViewErr
 	}
 
-	syncView, syncViewErr := getSyncView(w, preferencesData.Frequency)
+	syncView, syncViewErr := getSyncView(
+		w,
+		preferencesData.Schedule,
+		preferencesData.QuietHours,
+		preferencesData.IncrementalSync,
+		preferencesData.ExtractArchives,
+	)
 	if syncViewErr != nil {
 		return tab, syncViewErr
 	}
 
+	deletionsView, deletionsViewErr := getDeletionsView(
+		w,
+		preferencesData.MirrorDeletions,
+		preferencesData.TrashRetentionDays,
+	)
+	if deletionsViewErr != nil {
+		return tab, deletionsViewErr
+	}
+
+	conflictsView, conflictsViewErr := getConflictsView(w, preferencesData.ConflictPolicy)
+	if conflictsViewErr != nil {
+		return tab, conflictsViewErr
+	}
+
+	collisionsView, collisionsViewErr := getCollisionsView(
+		w,
+		preferencesData.CollisionPolicy,
+		preferencesData.ModuleCollisionPolicies,
+	)
+	if collisionsViewErr != nil {
+		return tab, collisionsViewErr
+	}
+
+	versionsView, versionsViewErr := getVersionsView(
+		w,
+		preferencesData.VersionsKeepLast,
+		preferencesData.VersionsKeepDays,
+	)
+	if versionsViewErr != nil {
+		return tab, versionsViewErr
+	}
+
+	configView, configViewErr := getConfigView(w)
+	if configViewErr != nil {
+		return tab, configViewErr
+	}
+
 	advancedView, advancedViewErr := getAdvancedView(w, preferencesData.LogLevel)
 	if advancedViewErr != nil {
 		return tab, advancedViewErr
 	}
 
-	tab.Content = container.NewVBox(fileDirectoryView, syncView, advancedView)
+	tab.Content = container.NewVScroll(
+		container.NewVBox(
+			fileDirectoryView,
+			syncView,
+			deletionsView,
+			conflictsView,
+			collisionsView,
+			versionsView,
+			configView,
+			advancedView,
+		),
+	)
 
 	return tab, nil
 }
@@ -111,9 +203,17 @@ func getFileDirectoryView(parentWindow fyne.Window, directory string) (fyne.Canv
 	return container.NewVBox(label, widget.NewSeparator(), folderPathLabel, chooseDirButton), nil
 }
 
-// getSyncView builds the view for choosing frequency of sync for LMS files.
+// getSyncView builds the view for choosing the schedule of syncs for LMS files,
+// the quiet hours during which no scheduled sync or notification runs, and whether syncs
+// only check for files changed since the last sync, and whether downloaded archives are extracted.
 // It is placed in the Preferences tab.
-func getSyncView(parentWindow fyne.Window, frequency int) (fyne.CanvasObject, error) {
+func getSyncView(
+	parentWindow fyne.Window,
+	syncSchedule schedule.Schedule,
+	quietHours schedule.QuietHours,
+	incrementalSync bool,
+	extractArchives bool,
+) (fyne.CanvasObject, error) {
 	logs.Logger.Debugln("sync view loaded")
 
 	label := widget.NewLabelWithStyle(
@@ -124,35 +224,81 @@ func getSyncView(parentWindow fyne.Window, frequency int) (fyne.CanvasObject, er
 	description := widget.NewRichTextFromMarkdown(appConstants.SYNC_TAB_DESCRIPTION)
 	description.Wrapping = fyne.TextWrapWord
 
-	frequencySelect := widget.NewSelect([]string{
-		appConstants.SYNC_FREQUENCY_DISABLED,
-		appConstants.SYNC_FREQUENCY_ONE_HOUR,
-		appConstants.SYNC_FREQUENCY_TWO_HOUR,
-		appConstants.SYNC_FREQUENCY_FOUR_HOUR,
-		appConstants.SYNC_FREQUENCY_SIX_HOUR,
-		appConstants.SYNC_FREQUENCY_TWELVE_HOUR,
+	scheduleEntry := widget.NewEntry()
+	if !syncSchedule.IsDisabled() {
+		_, value, _ := strings.Cut(syncSchedule.String(), " ")
+		scheduleEntry.SetText(value)
+	}
+
+	selectedKind := syncSchedule.Kind
+	kindSelect := widget.NewSelect([]string{
+		appConstants.SYNC_SCHEDULE_DISABLED,
+		appConstants.SYNC_SCHEDULE_EVERY,
+		appConstants.SYNC_SCHEDULE_CRON,
+		appConstants.SYNC_SCHEDULE_DAILY,
 	}, func(s string) {
-		var newFrequency int
-		switch s {
-		case appConstants.SYNC_FREQUENCY_DISABLED:
-			newFrequency = -1
-		case appConstants.SYNC_FREQUENCY_ONE_HOUR:
-			newFrequency = 1
-		case appConstants.SYNC_FREQUENCY_TWO_HOUR:
-			newFrequency = 2
-		case appConstants.SYNC_FREQUENCY_FOUR_HOUR:
-			newFrequency = 4
-		case appConstants.SYNC_FREQUENCY_SIX_HOUR:
-			newFrequency = 6
-		case appConstants.SYNC_FREQUENCY_TWELVE_HOUR:
-			newFrequency = 12
-		default:
-			newFrequency = 1
-		}
-
-		logs.Logger.Debugf("frequency selected - %d", newFrequency)
-
-		savePrefErr := appPref.SaveSyncFrequency(newFrequency)
+		for kind, text := range scheduleKindMap {
+			if text == s {
+				selectedKind = kind
+			}
+		}
+
+		logs.Logger.Debugf("schedule kind selected - %s", selectedKind)
+		scheduleEntry.SetPlaceHolder(scheduleHintMap[selectedKind])
+		if selectedKind == schedule.KindDisabled {
+			scheduleEntry.Disable()
+		} else {
+			scheduleEntry.Enable()
+		}
+	})
+	kindSelect.SetSelected(scheduleKindMap[syncSchedule.Kind])
+
+	quietHoursEntry := widget.NewEntry()
+	quietHoursEntry.SetPlaceHolder(appConstants.QUIET_HOURS_PLACEHOLDER)
+	quietHoursEntry.SetText(quietHours.String())
+
+	saveButton := widget.NewButton(appConstants.SAVE_SCHEDULE_TEXT, func() {
+		newSchedule, scheduleErr := schedule.Parse(fmt.Sprintf("%s %s", selectedKind, scheduleEntry.Text))
+		if scheduleErr != nil {
+			logs.Logger.Debugf("invalid schedule - %s", scheduleErr.Error())
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.SCHEDULE_INVALID_MESSAGE, scheduleErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		newQuietHours, quietHoursErr := schedule.ParseQuietHours(quietHoursEntry.Text)
+		if quietHoursErr != nil {
+			logs.Logger.Debugf("invalid quiet hours - %s", quietHoursErr.Error())
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.QUIET_HOURS_INVALID_MESSAGE, quietHoursErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		pref, prefErr := appPref.GetPreferences()
+		if prefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(prefErr)
+			return
+		}
+
+		savePrefErr := appPref.SaveSchedule(newSchedule)
+		if savePrefErr == nil {
+			savePrefErr = appPref.SaveQuietHours(newQuietHours)
+		}
+		if savePrefErr == nil {
+			savePrefErr = cron.Reschedule(pref.Directory, newSchedule)
+		}
+
 		if savePrefErr != nil {
 			dialog.NewInformation(
 				appConstants.APP_NAME,
@@ -162,11 +308,265 @@ func getSyncView(parentWindow fyne.Window, frequency int) (fyne.CanvasObject, er
 			logs.Logger.Errorln(savePrefErr)
 			return
 		}
-		logs.Logger.Debugln("frequency saved")
+
+		logs.Logger.Debugf("schedule saved - %s", newSchedule.String())
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.SCHEDULE_SAVED_MESSAGE,
+			parentWindow,
+		).Show()
 	})
-	frequencySelect.Selected = frequencyMap[frequency]
 
-	return container.NewVBox(label, widget.NewSeparator(), description, frequencySelect), nil
+	incrementalCheckbox := widget.NewCheck(appConstants.INCREMENTAL_SYNC_TITLE, func(onIncremental bool) {
+		logs.Logger.Debugf("incremental sync changed to - %v", onIncremental)
+
+		savePrefErr := appPref.SaveIncrementalSync(onIncremental)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+	})
+	incrementalCheckbox.Checked = incrementalSync
+
+	extractCheckbox := widget.NewCheck(appConstants.EXTRACT_ARCHIVES_TITLE, func(onExtract bool) {
+		logs.Logger.Debugf("extract archives changed to - %v", onExtract)
+
+		savePrefErr := appPref.SaveExtractArchives(onExtract)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+	})
+	extractCheckbox.Checked = extractArchives
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		container.NewBorder(nil, nil, kindSelect, nil, scheduleEntry),
+		container.NewBorder(nil, nil, widget.NewLabel(appConstants.QUIET_HOURS_TEXT), nil, quietHoursEntry),
+		saveButton,
+		incrementalCheckbox,
+		extractCheckbox,
+	), nil
+}
+
+// getDeletionsView builds the view for choosing whether files deleted remotely are moved
+// into the trash folder, and for how long they are kept. It is placed in the Preferences tab.
+func getDeletionsView(parentWindow fyne.Window, mirrorDeletions bool, trashRetentionDays int) (fyne.CanvasObject, error) {
+	logs.Logger.Debugln("deletions view loaded")
+
+	label := widget.NewLabelWithStyle(
+		appConstants.DELETIONS_TAB_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.DELETIONS_TAB_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	mirrorCheckbox := widget.NewCheck(appConstants.MIRROR_DELETIONS_TITLE, func(onMirror bool) {
+		logs.Logger.Debugf("mirror deletions changed to - %v", onMirror)
+
+		savePrefErr := appPref.SaveMirrorDeletions(onMirror)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+	})
+	mirrorCheckbox.Checked = mirrorDeletions
+
+	retentionSelect := widget.NewSelect([]string{
+		appConstants.TRASH_RETENTION_SEVEN_DAYS,
+		appConstants.TRASH_RETENTION_THIRTY_DAYS,
+		appConstants.TRASH_RETENTION_NINETY_DAYS,
+		appConstants.TRASH_RETENTION_FOREVER,
+	}, func(s string) {
+		newTrashRetentionDays := 30
+		for days, text := range trashRetentionMap {
+			if text == s {
+				newTrashRetentionDays = days
+			}
+		}
+
+		logs.Logger.Debugf("trash retention selected - %d", newTrashRetentionDays)
+
+		savePrefErr := appPref.SaveTrashRetentionDays(newTrashRetentionDays)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+		logs.Logger.Debugln("trash retention saved")
+	})
+	retentionSelect.Selected = trashRetentionMap[trashRetentionDays]
+
+	viewTrashButton := widget.NewButton(appConstants.VIEW_TRASH_TEXT, func() {
+		showTrashDialog(parentWindow)
+	})
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		mirrorCheckbox,
+		retentionSelect,
+		viewTrashButton,
+	), nil
+}
+
+// getConflictsView builds the view for choosing what happens when a locally modified file
+// is updated remotely. It is placed in the Preferences tab.
+func getConflictsView(parentWindow fyne.Window, conflictPolicy string) (fyne.CanvasObject, error) {
+	logs.Logger.Debugln("conflicts view loaded")
+
+	label := widget.NewLabelWithStyle(
+		appConstants.CONFLICTS_TAB_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.CONFLICTS_TAB_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	conflictPolicySelect := widget.NewSelect([]string{
+		appConstants.CONFLICT_KEEP_BOTH_TEXT,
+		appConstants.CONFLICT_SKIP_TEXT,
+		appConstants.CONFLICT_OVERWRITE_TEXT,
+	}, func(s string) {
+		newConflictPolicy := appPref.CONFLICT_KEEP_BOTH
+		for policy, text := range conflictPolicyMap {
+			if text == s {
+				newConflictPolicy = policy
+			}
+		}
+
+		logs.Logger.Debugf("conflict policy selected - %s", newConflictPolicy)
+
+		savePrefErr := appPref.SaveConflictPolicy(newConflictPolicy)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+		logs.Logger.Debugln("conflict policy saved")
+	})
+	conflictPolicySelect.Selected = conflictPolicyMap[conflictPolicy]
+
+	return container.NewVBox(label, widget.NewSeparator(), description, conflictPolicySelect), nil
+}
+
+// getCollisionsView builds the view for choosing what happens when a file not downloaded by Lominus
+// is in the way of a download, globally and for specific modules. It is placed in the Preferences tab.
+func getCollisionsView(
+	parentWindow fyne.Window,
+	collisionPolicy string,
+	moduleCollisionPolicies map[string]string,
+) (fyne.CanvasObject, error) {
+	logs.Logger.Debugln("collisions view loaded")
+
+	label := widget.NewLabelWithStyle(
+		appConstants.COLLISIONS_TAB_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.COLLISIONS_TAB_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	collisionPolicyOptions := []string{}
+	for _, policy := range appPref.CollisionPolicies {
+		collisionPolicyOptions = append(collisionPolicyOptions, collisionPolicyMap[policy])
+	}
+
+	collisionPolicySelect := widget.NewSelect(collisionPolicyOptions, func(s string) {
+		newCollisionPolicy := appPref.COLLISION_RENAME_OLD
+		for policy, text := range collisionPolicyMap {
+			if text == s {
+				newCollisionPolicy = policy
+			}
+		}
+
+		logs.Logger.Debugf("collision policy selected - %s", newCollisionPolicy)
+
+		savePrefErr := appPref.SaveCollisionPolicy(newCollisionPolicy)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+		logs.Logger.Debugln("collision policy saved")
+	})
+	collisionPolicySelect.Selected = collisionPolicyMap[collisionPolicy]
+
+	moduleCollisionPoliciesEntry := widget.NewEntry()
+	moduleCollisionPoliciesEntry.SetPlaceHolder(appConstants.MODULE_COLLISION_POLICIES_PLACEHOLDER)
+	moduleCollisionPoliciesEntry.SetText(appPref.FormatModuleCollisionPolicies(moduleCollisionPolicies))
+
+	saveButton := widget.NewButton(appConstants.SAVE_MODULE_COLLISION_POLICIES_TEXT, func() {
+		newModuleCollisionPolicies, parseErr := appPref.ParseModuleCollisionPolicies(moduleCollisionPoliciesEntry.Text)
+		if parseErr != nil {
+			logs.Logger.Debugf("invalid module collision policies - %s", parseErr.Error())
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.MODULE_COLLISION_POLICIES_INVALID_MESSAGE, parseErr.Error()),
+				parentWindow,
+			).Show()
+			return
+		}
+
+		savePrefErr := appPref.SaveModuleCollisionPolicies(newModuleCollisionPolicies)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+		logs.Logger.Debugln("module collision policies saved")
+
+		moduleCollisionPoliciesEntry.SetText(appPref.FormatModuleCollisionPolicies(newModuleCollisionPolicies))
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.MODULE_COLLISION_POLICIES_SAVED_MESSAGE,
+			parentWindow,
+		).Show()
+	})
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		collisionPolicySelect,
+		container.NewBorder(nil, nil, widget.NewLabel(appConstants.MODULE_COLLISION_POLICIES_TEXT), nil, moduleCollisionPoliciesEntry),
+		saveButton,
+	), nil
 }
 
 // getAdvancedView builds the view for advanced options such as debug mode.
@@ -232,5 +632,34 @@ func getAdvancedView(parentWindow fyne.Window, logLevel string) (fyne.CanvasObje
 
 	debugCheckbox.Checked = logLevel == "debug"
 
-	return container.NewVBox(label, widget.NewSeparator(), description, debugCheckbox), nil
+	clearCacheButton := widget.NewButton(appConstants.CLEAR_CACHE_TEXT, func() {
+		clearCacheErr := api.ClearCache()
+		if clearCacheErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(clearCacheErr)
+			return
+		}
+
+		logs.Logger.Debugln("cache cleared")
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.CACHE_CLEARED_MESSAGE,
+			parentWindow,
+		).Show()
+	})
+	cacheDescription := widget.NewRichTextFromMarkdown(appConstants.CLEAR_CACHE_DESCRIPTION)
+	cacheDescription.Wrapping = fyne.TextWrapWord
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		debugCheckbox,
+		cacheDescription,
+		clearCacheButton,
+	), nil
 }
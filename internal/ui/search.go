@@ -0,0 +1,208 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/search"
+)
+
+// SEARCH_LIMIT is the number of results shown for a query.
+const SEARCH_LIMIT = 100
+
+// getSearchTab builds the search tab in the main UI.
+func getSearchTab(parentWindow fyne.Window) (*container.TabItem, error) {
+	logs.Logger.Debugln("search tab loaded")
+	tab := container.NewTabItem(appConstants.SEARCH_TITLE, container.NewVBox())
+
+	label := widget.NewLabelWithStyle(
+		appConstants.SEARCH_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.SEARCH_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	hits := []search.Hit{}
+	// Snippets are read from the files as their results are shown, keyed by the path of the file.
+	rootSyncDirectory := ""
+	snippets := map[string]string{}
+	var snippetsMutex sync.Mutex
+
+	emptyLabel := widget.NewLabel(appConstants.SEARCH_NO_RESULTS_MESSAGE)
+	emptyLabel.Hide()
+
+	var list *widget.List
+	list = widget.NewList(
+		func() int {
+			return len(hits)
+		},
+		func() fyne.CanvasObject {
+			snippet := widget.NewLabel("")
+			snippet.Wrapping = fyne.TextWrapWord
+			return container.NewVBox(
+				widget.NewLabelWithStyle("", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}),
+				snippet,
+			)
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			hit := hits[id]
+			labels := object.(*fyne.Container).Objects
+			labels[0].(*widget.Label).SetText(fmt.Sprintf(appConstants.SEARCH_RESULT_TEXT, hit.Module, hit.Path))
+
+			snippetsMutex.Lock()
+			snippet, loaded := snippets[hit.Path]
+			if !loaded {
+				snippets[hit.Path] = ""
+			}
+			loadedSnippets := snippets
+			directory := rootSyncDirectory
+			snippetsMutex.Unlock()
+
+			labels[1].(*widget.Label).SetText(snippet)
+			if loaded {
+				return
+			}
+
+			go func() {
+				search.LoadSnippet(directory, &hit)
+
+				snippetsMutex.Lock()
+				loadedSnippets[hit.Path] = hit.Snippet
+				snippetsMutex.Unlock()
+				list.RefreshItem(id)
+			}()
+		},
+	)
+	list.OnSelected = func(id widget.ListItemID) {
+		openHit(hits[id], parentWindow)
+		list.Unselect(id)
+	}
+
+	queryEntry := widget.NewEntry()
+	queryEntry.SetPlaceHolder(appConstants.SEARCH_PLACEHOLDER)
+
+	runQuery := func() {
+		pref, prefErr := appPref.GetPreferences()
+		if prefErr != nil {
+			logs.Logger.Errorln(prefErr)
+			return
+		}
+
+		results, queryErr := search.Query(queryEntry.Text, SEARCH_LIMIT)
+		if queryErr != nil {
+			logs.Logger.Errorln(queryErr)
+			dialog.NewError(queryErr, parentWindow).Show()
+			return
+		}
+
+		hits = results
+		snippetsMutex.Lock()
+		rootSyncDirectory = pref.Directory
+		snippets = map[string]string{}
+		snippetsMutex.Unlock()
+		if len(hits) == 0 && queryEntry.Text != "" {
+			emptyLabel.Show()
+		} else {
+			emptyLabel.Hide()
+		}
+		list.UnselectAll()
+		list.Refresh()
+	}
+	queryEntry.OnSubmitted = func(string) {
+		runQuery()
+	}
+
+	searchButton := widget.NewButton(appConstants.SEARCH_TEXT, runQuery)
+
+	var updateButton *widget.Button
+	updateButton = widget.NewButton(appConstants.UPDATE_SEARCH_INDEX_TEXT, func() {
+		pref, prefErr := appPref.GetPreferences()
+		if prefErr != nil {
+			logs.Logger.Errorln(prefErr)
+			return
+		}
+
+		if pref.Directory == "" {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.NO_FOLDER_DIRECTORY_SELECTED,
+				parentWindow,
+			).Show()
+			return
+		}
+
+		updateButton.Disable()
+		updateButton.SetText(appConstants.SEARCH_INDEX_UPDATING_TEXT)
+		go func() {
+			defer func() {
+				updateButton.SetText(appConstants.UPDATE_SEARCH_INDEX_TEXT)
+				updateButton.Enable()
+			}()
+
+			indexed, updateErr := search.Update(context.Background(), pref.Directory)
+			if updateErr != nil {
+				logs.Logger.Errorln(updateErr)
+				dialog.NewError(updateErr, parentWindow).Show()
+				return
+			}
+
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				fmt.Sprintf(appConstants.SEARCH_INDEX_UPDATED_TEXT, indexed),
+				parentWindow,
+			).Show()
+		}()
+	})
+
+	tab.Content = container.NewBorder(
+		container.NewVBox(
+			label,
+			widget.NewSeparator(),
+			description,
+			container.NewBorder(nil, nil, nil, searchButton, queryEntry),
+			emptyLabel,
+		),
+		updateButton,
+		nil,
+		nil,
+		list,
+	)
+
+	return tab, nil
+}
+
+// openHit opens the file of the search result with the default application of the platform.
+func openHit(hit search.Hit, parentWindow fyne.Window) {
+	pref, prefErr := appPref.GetPreferences()
+	if prefErr != nil {
+		logs.Logger.Errorln(prefErr)
+		return
+	}
+
+	filePath := filepath.Join(pref.Directory, filepath.FromSlash(hit.Path))
+	urlPath := filepath.ToSlash(filePath)
+	// Windows paths, eg. C:/Users, need a leading slash to be a valid file URL.
+	if !strings.HasPrefix(urlPath, "/") {
+		urlPath = "/" + urlPath
+	}
+
+	fileUrl := &url.URL{Scheme: "file", Path: urlPath}
+	if openErr := fyne.CurrentApp().OpenURL(fileUrl); openErr != nil {
+		logs.Logger.Errorln(openErr)
+		dialog.NewError(openErr, parentWindow).Show()
+	}
+}
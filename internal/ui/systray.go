@@ -25,12 +25,12 @@ func BuildSystemTray() *fyne.Menu {
 				return
 			}
 
-			if pref.Frequency == -1 {
-				notifications.NotificationChannel <- notifications.Notification{Title: "Unable to sync", Content: "Please choose a sync frequency to sync now."}
+			if cron.IsSyncing() {
+				notifications.NotificationChannel <- notifications.Notification{Title: "Sync", Content: "A sync is already in progress."}
 				return
 			}
 
-			cron.Rerun(pref.Directory, pref.Frequency)
+			cron.SyncNow(pref.Directory)
 		}),
 		fyne.NewMenuItem("Open Lominus", func() {
 			w.Show()
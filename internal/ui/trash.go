@@ -0,0 +1,102 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/trash"
+)
+
+// showTrashDialog shows the files in the trash folder of the root sync directory,
+// each of which can be restored to its original location.
+func showTrashDialog(parentWindow fyne.Window) {
+	pref, prefErr := appPref.GetPreferences()
+	if prefErr != nil {
+		logs.Logger.Errorln(prefErr)
+		return
+	}
+
+	if pref.Directory == "" {
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.NO_FOLDER_DIRECTORY_SELECTED,
+			parentWindow,
+		).Show()
+		return
+	}
+
+	items, listErr := trash.List(pref.Directory)
+	if listErr != nil {
+		logs.Logger.Errorln(listErr)
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.PREFERENCES_FAILED_MESSAGE,
+			parentWindow,
+		).Show()
+		return
+	}
+
+	if len(items) == 0 {
+		dialog.NewInformation(
+			appConstants.TRASH_TITLE,
+			appConstants.TRASH_EMPTY_MESSAGE,
+			parentWindow,
+		).Show()
+		return
+	}
+
+	var trashDialog dialog.Dialog
+	list := widget.NewList(
+		func() int {
+			return len(items)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(appConstants.RESTORE_TEXT, nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			item := items[id]
+			row := object.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(fmt.Sprintf("[%s] %s", item.Date, item.Key))
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				restoreErr := trash.Restore(pref.Directory, item)
+				trashDialog.Hide()
+
+				if restoreErr != nil {
+					logs.Logger.Errorln(restoreErr)
+					dialog.NewInformation(
+						appConstants.APP_NAME,
+						fmt.Sprintf(appConstants.RESTORE_FAILED_MESSAGE, item.Key),
+						parentWindow,
+					).Show()
+					return
+				}
+
+				logs.Logger.Debugf("restored - %s", item.Key)
+				dialog.NewInformation(
+					appConstants.APP_NAME,
+					fmt.Sprintf(appConstants.RESTORE_SUCCESSFUL_MESSAGE, item.Key),
+					parentWindow,
+				).Show()
+			}
+		},
+	)
+
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(500, 400))
+
+	trashDialog = dialog.NewCustom(
+		appConstants.TRASH_TITLE,
+		appConstants.CLOSE_TEXT,
+		listScroll,
+		parentWindow,
+	)
+	trashDialog.Show()
+}
@@ -9,15 +9,18 @@ import (
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/driver/desktop"
-	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
 
 	appAuth "github.com/beebeeoii/lominus/internal/app/auth"
+	appFilter "github.com/beebeeoii/lominus/internal/app/filter"
+	appHooks "github.com/beebeeoii/lominus/internal/app/hooks"
 	appInt "github.com/beebeeoii/lominus/internal/app/integrations/telegram"
 	appPref "github.com/beebeeoii/lominus/internal/app/pref"
 	appConstants "github.com/beebeeoii/lominus/internal/constants"
 	"github.com/beebeeoii/lominus/internal/cron"
+	logs "github.com/beebeeoii/lominus/internal/log"
 	"github.com/beebeeoii/lominus/internal/notifications"
+	appSync "github.com/beebeeoii/lominus/internal/sync"
 )
 
 var mainApp fyne.App
@@ -43,9 +46,24 @@ func Init() error {
 		return tIdsErr
 	}
 
+	filterText, filterErr := appFilter.GetFilterText()
+	if filterErr != nil {
+		return filterErr
+	}
+
+	hooksText, hooksTextErr := appHooks.GetHooksText()
+	if hooksTextErr != nil {
+		return hooksTextErr
+	}
+
 	go func() {
 		for {
 			notification := <-notifications.NotificationChannel
+			if cron.IsQuietHours() {
+				logs.Logger.Debugf("quiet hours - notification not shown: %s", notification.Title)
+				continue
+			}
+
 			mainApp.SendNotification(fyne.NewNotification(notification.Title, notification.Content))
 		}
 	}()
@@ -65,9 +83,19 @@ func Init() error {
 	}
 
 	preferencesTab, preferencesErr := getPreferencesTab(PreferencesData{
-		Directory: pref.Directory,
-		Frequency: pref.Frequency,
-		LogLevel:  pref.LogLevel,
+		Directory:               pref.Directory,
+		Schedule:                pref.Schedule,
+		QuietHours:              pref.QuietHours,
+		IncrementalSync:         pref.IncrementalSync,
+		ExtractArchives:         pref.ExtractArchives,
+		LogLevel:                pref.LogLevel,
+		MirrorDeletions:         pref.MirrorDeletions,
+		TrashRetentionDays:      pref.TrashRetentionDays,
+		ConflictPolicy:          pref.ConflictPolicy,
+		CollisionPolicy:         pref.CollisionPolicy,
+		ModuleCollisionPolicies: pref.ModuleCollisionPolicies,
+		VersionsKeepLast:        pref.VersionsKeepLast,
+		VersionsKeepDays:        pref.VersionsKeepDays,
 	}, w)
 	if preferencesErr != nil {
 		return preferencesErr
@@ -81,11 +109,37 @@ func Init() error {
 		return integrationsErr
 	}
 
-	tabsContainer := container.NewAppTabs(credentialsTab, integrationsTab, preferencesTab)
-	content := container.NewVBox(
+	filtersTab, filtersErr := getFiltersTab(FiltersData{
+		Rules: filterText,
+	}, w)
+	if filtersErr != nil {
+		return filtersErr
+	}
+
+	hooksTab, hooksErr := getHooksTab(HooksData{
+		Hooks: hooksText,
+	}, w)
+	if hooksErr != nil {
+		return hooksErr
+	}
+
+	searchTab, searchErr := getSearchTab(w)
+	if searchErr != nil {
+		return searchErr
+	}
+
+	historyTab, historyErr := getHistoryTab(w)
+	if historyErr != nil {
+		return historyErr
+	}
+
+	tabsContainer := container.NewAppTabs(credentialsTab, integrationsTab, preferencesTab, filtersTab, hooksTab, searchTab, historyTab)
+	content := container.NewBorder(
+		nil,
+		container.NewGridWithColumns(2, getPreviewSyncButton(w), getSyncButton(w)),
+		nil,
+		nil,
 		tabsContainer,
-		layout.NewSpacer(),
-		getSyncButton(w),
 	)
 
 	w.SetContent(content)
@@ -104,8 +158,10 @@ func Init() error {
 }
 
 // getSyncButton builds the sync button in the main UI.
+// Its text shows whether a sync is in progress. If one is, the user chooses between waiting for it
+// and cancelling it to start a new one.
 func getSyncButton(parentWindow fyne.Window) *widget.Button {
-	return widget.NewButton(appConstants.SYNC_TEXT, func() {
+	syncButton := widget.NewButton(appConstants.SYNC_TEXT, func() {
 		pref, prefErr := appPref.GetPreferences()
 		if prefErr != nil {
 			return
@@ -121,16 +177,100 @@ func getSyncButton(parentWindow fyne.Window) *widget.Button {
 			return
 		}
 
-		if pref.Frequency == -1 {
+		if !cron.IsSyncing() {
+			cron.SyncNow(pref.Directory)
+			return
+		}
+
+		dialog.NewCustomConfirm(
+			appConstants.SYNC_IN_PROGRESS_TITLE,
+			appConstants.CANCEL_AND_RESTART_TEXT,
+			appConstants.WAIT_TEXT,
+			widget.NewLabel(appConstants.SYNC_IN_PROGRESS_MESSAGE),
+			func(restart bool) {
+				if !restart {
+					logs.Logger.Debugln("joining sync in progress")
+					return
+				}
+
+				go func() {
+					cron.CancelSync()
+					cron.SyncNow(pref.Directory)
+				}()
+			},
+			parentWindow,
+		).Show()
+	})
+
+	cron.SubscribeSyncing(func(syncing bool) {
+		if syncing {
+			syncButton.SetText(appConstants.SYNC_IN_PROGRESS_TEXT)
+		} else {
+			syncButton.SetText(appConstants.SYNC_TEXT)
+		}
+	})
+	if cron.IsSyncing() {
+		syncButton.SetText(appConstants.SYNC_IN_PROGRESS_TEXT)
+	}
+
+	return syncButton
+}
+
+// getPreviewSyncButton builds the preview sync button in the main UI.
+// It shows what a sync would do without downloading or modifying any file.
+func getPreviewSyncButton(parentWindow fyne.Window) *widget.Button {
+	return widget.NewButton(appConstants.PREVIEW_SYNC_TEXT, func() {
+		pref, prefErr := appPref.GetPreferences()
+		if prefErr != nil {
+			return
+		}
+
+		if pref.Directory == "" {
 			dialog.NewInformation(
 				appConstants.APP_NAME,
-				appConstants.NO_FREQUENCY_SELECTED,
+				appConstants.NO_FOLDER_DIRECTORY_SELECTED,
 				parentWindow,
 			).Show()
 
 			return
 		}
 
-		cron.Rerun(pref.Directory, pref.Frequency)
+		status := widget.NewLabel(appConstants.PREVIEWING_SYNC_MESSAGE)
+		progressBar := widget.NewProgressBarInfinite()
+
+		mainDialog := dialog.NewCustomWithoutButtons(
+			appConstants.APP_NAME,
+			container.NewVBox(status, progressBar),
+			parentWindow,
+		)
+		mainDialog.Show()
+
+		go func() {
+			logs.Logger.Debugln("previewing sync")
+			plan, planErr := appSync.Preview(pref.Directory)
+			mainDialog.Hide()
+
+			if planErr != nil {
+				logs.Logger.Errorln(planErr)
+				dialog.NewInformation(
+					appConstants.APP_NAME,
+					appConstants.PREVIEW_SYNC_FAILED_MESSAGE,
+					parentWindow,
+				).Show()
+				return
+			}
+
+			planLabel := widget.NewLabel(plan.String())
+			planLabel.Wrapping = fyne.TextWrapWord
+			planScroll := container.NewVScroll(planLabel)
+			planScroll.SetMinSize(fyne.NewSize(500, 400))
+
+			dialog.NewCustom(
+				appConstants.PREVIEW_SYNC_TITLE,
+				appConstants.CLOSE_TEXT,
+				planScroll,
+				parentWindow,
+			).Show()
+		}()
 	})
 }
@@ -0,0 +1,199 @@
+// Package ui provides primitives that initialises the UI.
+package ui
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	appPref "github.com/beebeeoii/lominus/internal/app/pref"
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	logs "github.com/beebeeoii/lominus/internal/log"
+	"github.com/beebeeoii/lominus/internal/versions"
+	fileDialog "github.com/sqweek/dialog"
+)
+
+var versionsKeepLastOptions = []int{1, 3, 5, 10, 0}
+var versionsKeepDaysOptions = []int{7, 30, 90, 0}
+
+// getVersionsView builds the view for choosing how many superseded versions of files are kept,
+// and for browsing and restoring them. It is placed in the Preferences tab.
+func getVersionsView(parentWindow fyne.Window, keepLast int, keepDays int) (fyne.CanvasObject, error) {
+	logs.Logger.Debugln("versions view loaded")
+
+	label := widget.NewLabelWithStyle(
+		appConstants.VERSIONS_TAB_TITLE,
+		fyne.TextAlignLeading,
+		fyne.TextStyle{Bold: true, Italic: false, Monospace: false, TabWidth: 0},
+	)
+	description := widget.NewRichTextFromMarkdown(appConstants.VERSIONS_TAB_DESCRIPTION)
+	description.Wrapping = fyne.TextWrapWord
+
+	keepLastTexts := []string{}
+	for _, n := range versionsKeepLastOptions {
+		keepLastTexts = append(keepLastTexts, getKeepLastText(n))
+	}
+
+	keepDaysTexts := []string{}
+	for _, n := range versionsKeepDaysOptions {
+		keepDaysTexts = append(keepDaysTexts, getKeepDaysText(n))
+	}
+
+	saveRetention := func() {
+		logs.Logger.Debugf("versions retention selected - %d versions, %d days", keepLast, keepDays)
+
+		savePrefErr := appPref.SaveVersionsRetention(keepLast, keepDays)
+		if savePrefErr != nil {
+			dialog.NewInformation(
+				appConstants.APP_NAME,
+				appConstants.PREFERENCES_FAILED_MESSAGE,
+				parentWindow,
+			).Show()
+			logs.Logger.Errorln(savePrefErr)
+			return
+		}
+		logs.Logger.Debugln("versions retention saved")
+	}
+
+	keepLastSelect := widget.NewSelect(keepLastTexts, func(s string) {
+		for _, n := range versionsKeepLastOptions {
+			if getKeepLastText(n) == s {
+				keepLast = n
+			}
+		}
+		saveRetention()
+	})
+	keepLastSelect.Selected = getKeepLastText(keepLast)
+
+	keepDaysSelect := widget.NewSelect(keepDaysTexts, func(s string) {
+		for _, n := range versionsKeepDaysOptions {
+			if getKeepDaysText(n) == s {
+				keepDays = n
+			}
+		}
+		saveRetention()
+	})
+	keepDaysSelect.Selected = getKeepDaysText(keepDays)
+
+	browseButton := widget.NewButton(appConstants.BROWSE_VERSIONS_TEXT, func() {
+		pref, prefErr := appPref.GetPreferences()
+		if prefErr != nil {
+			logs.Logger.Errorln(prefErr)
+			return
+		}
+
+		filePath, fileErr := fileDialog.File().Title(appConstants.BROWSE_VERSIONS_TEXT).SetStartDir(pref.Directory).Load()
+		if fileErr != nil {
+			if fileErr.Error() != "Cancelled" {
+				logs.Logger.Errorln(fileErr)
+			}
+			return
+		}
+
+		showVersionsDialog(parentWindow, filePath)
+	})
+
+	return container.NewVBox(
+		label,
+		widget.NewSeparator(),
+		description,
+		container.NewGridWithColumns(2, keepLastSelect, keepDaysSelect),
+		browseButton,
+	), nil
+}
+
+// showVersionsDialog shows the superseded versions of the file, each of which can be restored.
+func showVersionsDialog(parentWindow fyne.Window, filePath string) {
+	fileName := filepath.Base(filePath)
+
+	fileVersions, listErr := versions.List(filePath)
+	if listErr != nil {
+		logs.Logger.Errorln(listErr)
+		dialog.NewInformation(
+			appConstants.APP_NAME,
+			appConstants.PREFERENCES_FAILED_MESSAGE,
+			parentWindow,
+		).Show()
+		return
+	}
+
+	if len(fileVersions) == 0 {
+		dialog.NewInformation(
+			appConstants.VERSIONS_TAB_TITLE,
+			fmt.Sprintf(appConstants.NO_VERSIONS_MESSAGE, fileName),
+			parentWindow,
+		).Show()
+		return
+	}
+
+	var versionsDialog dialog.Dialog
+	list := widget.NewList(
+		func() int {
+			return len(fileVersions)
+		},
+		func() fyne.CanvasObject {
+			return container.NewBorder(nil, nil, nil, widget.NewButton(appConstants.RESTORE_TEXT, nil), widget.NewLabel(""))
+		},
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			version := fileVersions[id]
+			versionTime := version.Time.Format("Monday, 02 January 2006 - 15:04:05")
+			row := object.(*fyne.Container)
+			row.Objects[0].(*widget.Label).SetText(versionTime)
+			row.Objects[1].(*widget.Button).OnTapped = func() {
+				restoreErr := versions.Restore(filePath, version, time.Now())
+				versionsDialog.Hide()
+
+				if restoreErr != nil {
+					logs.Logger.Errorln(restoreErr)
+					dialog.NewInformation(
+						appConstants.APP_NAME,
+						fmt.Sprintf(appConstants.RESTORE_VERSION_FAILED_MESSAGE, fileName),
+						parentWindow,
+					).Show()
+					return
+				}
+
+				logs.Logger.Debugf("restored version - %s", version.Path)
+				dialog.NewInformation(
+					appConstants.APP_NAME,
+					fmt.Sprintf(appConstants.RESTORE_VERSION_SUCCESSFUL_MESSAGE, fileName, versionTime),
+					parentWindow,
+				).Show()
+			}
+		},
+	)
+
+	listScroll := container.NewVScroll(list)
+	listScroll.SetMinSize(fyne.NewSize(500, 300))
+
+	versionsDialog = dialog.NewCustom(
+		fmt.Sprintf("%s - %s", appConstants.VERSIONS_TAB_TITLE, fileName),
+		appConstants.CLOSE_TEXT,
+		listScroll,
+		parentWindow,
+	)
+	versionsDialog.Show()
+}
+
+// getKeepLastText is a helper function that describes keeping the last n versions.
+func getKeepLastText(n int) string {
+	if n <= 0 {
+		return appConstants.VERSIONS_KEEP_ALL_TEXT
+	}
+
+	return fmt.Sprintf(appConstants.VERSIONS_KEEP_LAST_TEXT, n)
+}
+
+// getKeepDaysText is a helper function that describes keeping versions for n days.
+func getKeepDaysText(n int) string {
+	if n <= 0 {
+		return appConstants.VERSIONS_KEEP_FOREVER_TEXT
+	}
+
+	return fmt.Sprintf(appConstants.VERSIONS_KEEP_DAYS_TEXT, n)
+}
@@ -0,0 +1,188 @@
+// Package versions provides primitives to keep superseded versions of files in a per-folder
+// .versions folder, and to list, restore or prune them.
+package versions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
+	appFiles "github.com/beebeeoii/lominus/internal/file"
+)
+
+// Version struct describes a superseded version of a file.
+// FileName is the name of the file the version belongs to, eg. Lecture1.pdf.
+// Path is where the version is stored, eg. CS2040/Lectures/.versions/Lecture1.20220131-134500.pdf.
+type Version struct {
+	FileName string
+	Path     string
+	Time     time.Time
+}
+
+const TIME_FORMAT = "20060102-150405"
+
+// versionNameRegex matches the name of a version: <fileNameWOExt>.<time>[-<n>][<ext>]
+var versionNameRegex = regexp.MustCompile(`^(.*)\.(\d{8}-\d{6})(-\d+)?(\.[^.]*)?$`)
+
+// GetVersionsDir returns the folder where superseded versions of files in the directory are stored.
+func GetVersionsDir(directory string) string {
+	return filepath.Join(directory, appConstants.VERSIONS_DIR_NAME)
+}
+
+// Stash moves the file into the .versions folder of its directory, with the time it was
+// superseded appended to its name. Eg. Lecture1.pdf becomes .versions/Lecture1.20220131-134500.pdf.
+// Names too long for the time to be appended are truncated. It returns the path the file was moved to.
+func Stash(filePath string, now time.Time) (string, error) {
+	directory, fileName := filepath.Split(filePath)
+	versionsDir := GetVersionsDir(directory)
+
+	if ensureDirErr := appFiles.EnsureDir(versionsDir); ensureDirErr != nil {
+		return "", ensureDirErr
+	}
+
+	stamp := now.Format(TIME_FORMAT)
+
+	versionPath := filepath.Join(versionsDir, appFiles.AppendToName(fileName, "."+stamp))
+	for n := 1; appFiles.Exists(versionPath); n++ {
+		versionPath = filepath.Join(versionsDir, appFiles.AppendToName(fileName, fmt.Sprintf(".%s-%d", stamp, n)))
+	}
+
+	return versionPath, os.Rename(filePath, versionPath)
+}
+
+// List returns the superseded versions of the file, most recent first.
+func List(filePath string) ([]Version, error) {
+	directory, fileName := filepath.Split(filePath)
+
+	versions, listErr := listDir(GetVersionsDir(directory))
+	if listErr != nil {
+		return versions, listErr
+	}
+
+	fileVersions := []Version{}
+	for _, version := range versions {
+		if version.FileName == fileName {
+			fileVersions = append(fileVersions, version)
+		}
+	}
+
+	return fileVersions, nil
+}
+
+// Restore replaces the file with one of its superseded versions.
+// The current file, if any, is stashed as a version itself so nothing is lost.
+func Restore(filePath string, version Version, now time.Time) error {
+	if appFiles.Exists(filePath) {
+		if _, stashErr := Stash(filePath, now); stashErr != nil {
+			return stashErr
+		}
+	}
+
+	return os.Rename(version.Path, filePath)
+}
+
+// PruneAll removes superseded versions in every .versions folder under the root sync directory,
+// keeping only the keepLast most recent versions of every file and versions superseded in the
+// last keepDays days. A limit that is not positive is not applied.
+func PruneAll(rootSyncDirectory string, keepLast int, keepDays int, now time.Time) error {
+	if keepLast <= 0 && keepDays <= 0 {
+		return nil
+	}
+
+	return filepath.Walk(rootSyncDirectory, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() || info.Name() != appConstants.VERSIONS_DIR_NAME {
+			return nil
+		}
+
+		if pruneErr := prune(path, keepLast, keepDays, now); pruneErr != nil {
+			return pruneErr
+		}
+
+		return filepath.SkipDir
+	})
+}
+
+// prune is a helper function that prunes the versions in a single .versions folder.
+func prune(versionsDir string, keepLast int, keepDays int, now time.Time) error {
+	versions, listErr := listDir(versionsDir)
+	if listErr != nil {
+		return listErr
+	}
+
+	cutOff := now.AddDate(0, 0, -keepDays)
+	nKept := map[string]int{}
+
+	for _, version := range versions {
+		tooMany := keepLast > 0 && nKept[version.FileName] >= keepLast
+		tooOld := keepDays > 0 && version.Time.Before(cutOff)
+
+		if !tooMany && !tooOld {
+			nKept[version.FileName] += 1
+			continue
+		}
+
+		if removeErr := os.Remove(version.Path); removeErr != nil {
+			return removeErr
+		}
+	}
+
+	return nil
+}
+
+// listDir is a helper function that returns all the versions in a .versions folder,
+// most recent first.
+func listDir(versionsDir string) ([]Version, error) {
+	versions := []Version{}
+
+	if !appFiles.Exists(versionsDir) {
+		return versions, nil
+	}
+
+	entries, readDirErr := os.ReadDir(versionsDir)
+	if readDirErr != nil {
+		return versions, readDirErr
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := versionNameRegex.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+
+		versionTime, parseErr := time.ParseInLocation(TIME_FORMAT, match[2], time.Local)
+		if parseErr != nil {
+			continue
+		}
+
+		// Versions stashed within the same second are told apart by their suffix.
+		if match[3] != "" {
+			n, _ := strconv.Atoi(match[3][1:])
+			versionTime = versionTime.Add(time.Duration(n) * time.Millisecond)
+		}
+
+		versions = append(versions, Version{
+			FileName: match[1] + match[4],
+			Path:     filepath.Join(versionsDir, entry.Name()),
+			Time:     versionTime,
+		})
+	}
+
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].Time.After(versions[j].Time)
+	})
+
+	return versions, nil
+}
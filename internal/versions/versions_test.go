@@ -0,0 +1,155 @@
+package versions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStashListAndRestore(t *testing.T) {
+	directory := t.TempDir()
+	filePath := filepath.Join(directory, "Lecture1.pdf")
+	now := time.Date(2024, 3, 1, 13, 45, 0, 0, time.Local)
+
+	writeFile(t, filePath, "v1")
+	versionPath, stashErr := Stash(filePath, now)
+	if stashErr != nil {
+		t.Fatal(stashErr)
+	}
+	if versionPath != filepath.Join(directory, ".versions", "Lecture1.20240301-134500.pdf") {
+		t.Errorf("Stash() = %s, want the time before the extension", versionPath)
+	}
+
+	// Versions stashed within the same second are kept apart.
+	writeFile(t, filePath, "v2")
+	if _, err := Stash(filePath, now); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(directory, "Lecture2.pdf"), "other")
+	if _, err := Stash(filepath.Join(directory, "Lecture2.pdf"), now); err != nil {
+		t.Fatal(err)
+	}
+
+	versions, listErr := List(filePath)
+	if listErr != nil {
+		t.Fatal(listErr)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("List() = %+v, want the 2 versions of the file", versions)
+	}
+	assertFile(t, versions[0].Path, "v2")
+	assertFile(t, versions[1].Path, "v1")
+
+	// The current file is stashed before it is replaced.
+	writeFile(t, filePath, "v3")
+	if err := Restore(filePath, versions[1], now.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	assertFile(t, filePath, "v1")
+
+	versions, _ = List(filePath)
+	if len(versions) != 2 {
+		t.Fatalf("List() = %+v after restoring, want the restored version replaced by the current file", versions)
+	}
+	assertFile(t, versions[0].Path, "v3")
+}
+
+func TestStashLongName(t *testing.T) {
+	directory := t.TempDir()
+	filePath := filepath.Join(directory, strings.Repeat("a", 251)+".pdf")
+	now := time.Date(2024, 3, 1, 13, 45, 0, 0, time.Local)
+
+	for _, contents := range []string{"v1", "v2"} {
+		writeFile(t, filePath, contents)
+		if _, err := Stash(filePath, now); err != nil {
+			t.Fatalf("Stash() of a name of 255 bytes returned error: %v", err)
+		}
+	}
+
+	entries, _ := os.ReadDir(GetVersionsDir(directory))
+	if len(entries) != 2 {
+		t.Errorf("%d versions stashed, want 2", len(entries))
+	}
+}
+
+func TestPruneAll(t *testing.T) {
+	root := t.TempDir()
+	now := time.Date(2024, 3, 31, 12, 0, 0, 0, time.Local)
+	versionsDir := filepath.Join(root, "CS2040", ".versions")
+
+	for _, name := range []string{
+		"Lecture1.20240331-100000.pdf",
+		"Lecture1.20240330-100000.pdf",
+		"Lecture1.20240329-100000.pdf",
+		"Lecture1.20240301-100000.pdf",
+		"Lecture2.20240201-100000.pdf",
+		"notes.txt",
+	} {
+		writeFile(t, filepath.Join(versionsDir, name), name)
+	}
+
+	if err := PruneAll(root, 0, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(t, versionsDir, 6)
+
+	// The 2 most recent versions of every file are kept, and the ones of the last 10 days too.
+	if err := PruneAll(root, 2, 10, now); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(t, versionsDir, 3, "Lecture1.20240331-100000.pdf", "Lecture1.20240330-100000.pdf", "notes.txt")
+
+	if err := PruneAll(root, 1, 0, now); err != nil {
+		t.Fatal(err)
+	}
+	assertNames(t, versionsDir, 2, "Lecture1.20240331-100000.pdf", "notes.txt")
+}
+
+// writeFile is a helper function that writes the contents to filePath, creating its folder.
+func writeFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filePath, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertFile is a helper function that fails the test if the file at filePath does not have the contents.
+func assertFile(t *testing.T, filePath string, contents string) {
+	t.Helper()
+
+	data, readErr := os.ReadFile(filePath)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	if string(data) != contents {
+		t.Errorf("%s = %q, want %q", filePath, data, contents)
+	}
+}
+
+// assertNames is a helper function that fails the test if the directory does not have n files,
+// including the ones named.
+func assertNames(t *testing.T, directory string, n int, names ...string) {
+	t.Helper()
+
+	entries, readErr := os.ReadDir(directory)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+
+	if len(entries) != n {
+		t.Errorf("%s has %d files, want %d", directory, len(entries), n)
+	}
+
+	for _, name := range names {
+		if _, statErr := os.Stat(filepath.Join(directory, name)); statErr != nil {
+			t.Errorf("%s was removed, want it kept", name)
+		}
+	}
+}
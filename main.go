@@ -2,37 +2,66 @@
 package main
 
 import (
+	"log"
+
 	"github.com/beebeeoii/lominus/internal/app"
+	appCache "github.com/beebeeoii/lominus/internal/app/cache"
+	appConfig "github.com/beebeeoii/lominus/internal/app/config"
 	appLock "github.com/beebeeoii/lominus/internal/app/lock"
+	appSecrets "github.com/beebeeoii/lominus/internal/app/secrets"
 	"github.com/beebeeoii/lominus/internal/cron"
 	logs "github.com/beebeeoii/lominus/internal/log"
 	"github.com/beebeeoii/lominus/internal/notifications"
 	"github.com/beebeeoii/lominus/internal/ui"
+	"github.com/beebeeoii/lominus/pkg/api"
 	"github.com/juju/fslock"
 )
 
 // Main is the starting point of where magic begins.
 func main() {
-	db, appInitErr := app.Init()
-	if appInitErr != nil {
-		logs.Logger.Fatalln(appInitErr)
-	}
-	logs.Logger.Infoln("app initialised")
-	defer db.Close()
-
+	// The lock is taken before the app is initialised, such that a second instance exits without
+	// migrating the database or changing the settings of the running one. The logger is not
+	// initialised yet, so errors are only printed.
 	lockPath, getLockPathErr := appLock.GetLockPath()
 	if getLockPathErr != nil {
-		logs.Logger.Fatalln(getLockPathErr)
+		log.Fatalln(getLockPathErr)
 	}
 
 	lock := fslock.New(lockPath)
 	lockErr := lock.TryLock()
 
 	if lockErr != nil {
-		logs.Logger.Fatalln(lockErr)
+		log.Fatalln(lockErr)
 	}
 	defer lock.Unlock()
+
+	db, appInitErr := app.Init()
+	if appInitErr != nil {
+		log.Fatalln(appInitErr)
+	}
 	logs.Logger.Infoln("lock initialised")
+	logs.Logger.Infoln("app initialised")
+	defer db.Close()
+
+	secretsInitErr := appSecrets.Init()
+	if secretsInitErr != nil {
+		logs.Logger.Fatalln(secretsInitErr)
+	}
+	logs.Logger.Infoln("secrets initialised")
+
+	configPath, applyConfigErr := appConfig.ApplyStartupConfig()
+	if applyConfigErr != nil {
+		logs.Logger.Errorln(applyConfigErr)
+	} else if configPath != "" {
+		logs.Logger.Infof("config applied: %s", configPath)
+	}
+
+	cacheDir, getCacheDirErr := appCache.GetCacheDir()
+	if getCacheDirErr != nil {
+		logs.Logger.Fatalln(getCacheDirErr)
+	}
+	api.SetCache(api.DirCache{Dir: cacheDir})
+	logs.Logger.Infoln("cache initialised")
 
 	notifications.Init()
 	logs.Logger.Infoln("notifications initialised")
@@ -3,6 +3,7 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -11,9 +12,12 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
+	appConstants "github.com/beebeeoii/lominus/internal/constants"
 	appFile "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/versions"
 	"github.com/beebeeoii/lominus/pkg/constants"
 	"github.com/beebeeoii/lominus/pkg/interfaces"
 )
@@ -35,12 +39,18 @@ type Folder struct {
 // File struct is the datapack for containing details about a File.
 // Ancestors describe the relative folders that precedes the current file, excluding itself.
 // Eg. Ancestors for a file with the path: /MA2001/Lectures/Lecture1.pdf is ['MA2001', 'Lectures'].
+// Name is the name of the File locally, while OriginalName is its name on the LMS.
+// LastUpdated describes when the content of the File last changed, while UpdatedAt describes when
+// anything about the File, including its name and folder, last changed.
 type File struct {
-	Id          string
-	Name        string
-	Ancestors   []string
-	LastUpdated time.Time
-	DownloadUrl string
+	Id           string
+	Name         string
+	OriginalName string
+	Ancestors    []string
+	LastUpdated  time.Time
+	UpdatedAt    time.Time
+	DownloadUrl  string
+	Size         int64
 }
 
 func (moduleFolderRequest ModuleFolderRequest) GetModuleFolder() (Folder, error) {
@@ -113,7 +123,7 @@ func (foldersRequest FoldersRequest) GetFolders() ([]Folder, error) {
 
 			folders = append(folders, Folder{
 				Id:           strconv.Itoa(folderObject.Id),
-				Name:         appFile.CleanseFolderFileName(folderObject.Name),
+				Name:         appFile.NormalizeName(folderObject.Name),
 				Downloadable: downloadable,
 				HasSubFolder: folderObject.FoldersCount > 0,
 				Ancestors:    ancestors,
@@ -264,21 +274,12 @@ func (filesRequest FilesRequest) GetFiles() ([]File, error) {
 		}
 
 		for _, fileObject := range response {
-			lastUpdated, err := time.Parse(time.RFC3339, fileObject.LastUpdated)
-			tz, _ := time.LoadLocation("Asia/Singapore")
-			lastUpdated = lastUpdated.In(tz)
-
+			file, err := newFile(fileObject, ancestors)
 			if err != nil {
 				return files, err
 			}
 
-			files = append(files, File{
-				Id:          strconv.Itoa(fileObject.Id),
-				Name:        appFile.CleanseFolderFileName(fileObject.DisplayName),
-				LastUpdated: lastUpdated,
-				Ancestors:   ancestors,
-				DownloadUrl: fileObject.Url,
-			})
+			files = append(files, file)
 		}
 
 		if len(response) == 10 {
@@ -314,14 +315,164 @@ func (filesRequest FilesRequest) GetFiles() ([]File, error) {
 	return files, nil
 }
 
+// GetFilesUpdatedSince returns a slice of File objects of the module in the given CourseFilesRequest
+// that were updated at or after since, including nested files. Files in folders that are hidden are not returned.
+// Files updated in the same second as since are returned again, as they may not have been listed before.
+// Files are requested most recently updated first, so that the files updated before since are never requested,
+// except for the first of them which marks the end of the updated files. Files that move to a later page
+// while the pages are requested, as they are updated, are only returned once.
+func (courseFilesRequest CourseFilesRequest) GetFilesUpdatedSince(since time.Time) ([]File, error) {
+	files := []File{}
+
+	if courseFilesRequest.Request.Token == "" || !courseFilesRequest.Module.IsAccessible {
+		return files, nil
+	}
+
+	folders := map[int]Folder{}
+	listedIds := map[string]bool{}
+
+	for {
+		response := []interfaces.CanvasFileObject{}
+		reqErr := courseFilesRequest.Request.Send(&response)
+		if reqErr != nil {
+			return files, reqErr
+		}
+
+		for _, fileObject := range response {
+			updatedAt, err := time.Parse(time.RFC3339, fileObject.UpdatedAt)
+			if err != nil {
+				return files, err
+			}
+
+			if updatedAt.Before(since) {
+				return files, nil
+			}
+
+			folder, exists := folders[fileObject.FolderId]
+			if !exists {
+				folder, err = courseFilesRequest.getFolder(fileObject.FolderId)
+				if err != nil {
+					return files, err
+				}
+				folders[fileObject.FolderId] = folder
+			}
+
+			if !folder.Downloadable {
+				continue
+			}
+
+			file, err := newFile(fileObject, append(append([]string{}, folder.Ancestors...), folder.Name))
+			if err != nil {
+				return files, err
+			}
+
+			if listedIds[file.Id] {
+				continue
+			}
+			listedIds[file.Id] = true
+
+			files = append(files, file)
+		}
+
+		if len(response) < 10 {
+			return files, nil
+		}
+
+		url, _ := url.Parse(courseFilesRequest.Request.Url.Url)
+
+		currPage, _ := strconv.Atoi(url.Query().Get("page"))
+		if currPage == 0 {
+			currPage = 2
+		} else {
+			currPage += 1
+		}
+
+		q := url.Query()
+		q.Set("page", strconv.Itoa(currPage))
+		url.RawQuery = q.Encode()
+		courseFilesRequest.Request.Url.Url = url.String()
+	}
+}
+
+// getFolder is a helper function that retrieves the Folder with the given id in the module
+// of the CourseFilesRequest. The Folder is named after its full path in the module, eg. a folder
+// with the path /MA2001/Lectures/Week1 is named 'Week1' and its Ancestors are ['MA2001', 'Lectures'].
+// The root folder of the module is named after the module code.
+func (courseFilesRequest CourseFilesRequest) getFolder(folderId int) (Folder, error) {
+	request := courseFilesRequest.Request
+	request.Url = interfaces.Url{
+		Url:      fmt.Sprintf(constants.CANVAS_FOLDER_ENDPOINT, strconv.Itoa(folderId)),
+		Platform: constants.Canvas,
+	}
+
+	response := interfaces.CanvasFolderObject{}
+	reqErr := request.Send(&response)
+	if reqErr != nil {
+		return Folder{}, reqErr
+	}
+
+	// All the folders and files of a module are stored under the "course files" folder,
+	// which is named after the module code locally, as when the module is listed completely.
+	path := []string{appFile.NormalizeName(courseFilesRequest.Module.ModuleCode)}
+	for _, name := range strings.Split(response.FullName, "/")[1:] {
+		path = append(path, appFile.NormalizeName(name))
+	}
+
+	return Folder{
+		Id:           strconv.Itoa(response.Id),
+		Name:         path[len(path)-1],
+		Downloadable: !response.HiddenForUser,
+		HasSubFolder: response.FoldersCount > 0,
+		Ancestors:    path[:len(path)-1],
+		IsRootFolder: len(path) == 1,
+	}, nil
+}
+
+// newFile is a helper function that builds a File from the object returned by Canvas.
+func newFile(fileObject interfaces.CanvasFileObject, ancestors []string) (File, error) {
+	lastUpdated, err := time.Parse(time.RFC3339, fileObject.LastUpdated)
+	if err != nil {
+		return File{}, err
+	}
+	// Times are kept in UTC so that they compare the same wherever Lominus runs.
+	lastUpdated = lastUpdated.UTC()
+
+	// updated_at is only used to find the files changed since the last sync.
+	updatedAt, _ := time.Parse(time.RFC3339, fileObject.UpdatedAt)
+
+	return File{
+		Id:           strconv.Itoa(fileObject.Id),
+		Name:         appFile.NormalizeName(fileObject.DisplayName),
+		OriginalName: fileObject.DisplayName,
+		LastUpdated:  lastUpdated,
+		UpdatedAt:    updatedAt,
+		Ancestors:    ancestors,
+		DownloadUrl:  fileObject.Url,
+		Size:         fileObject.Size,
+	}, nil
+}
+
 // Download downloads the given file via the DownloadUrl of the File object.
 // The downloaded file will be placed in the folderPath specified in the parameter.
+// If the file already exists, the existing file is moved into the .versions folder of folderPath.
 func (file File) Download(folderPath string) error {
+	return file.DownloadContext(context.Background(), folderPath)
+}
+
+// DownloadContext is like Download, but stops downloading when ctx is cancelled and returns ctx.Err().
+// The file is written to a temporary file in folderPath first, which is removed if the download
+// does not complete, such that the existing file is only replaced by a complete download.
+func (file File) DownloadContext(ctx context.Context, folderPath string) error {
 	if file.DownloadUrl == "" {
 		return errors.New("file.DownloadUrl is empty")
 	}
 
-	response, err := http.Get(file.DownloadUrl)
+	request, err := http.NewRequestWithContext(ctx, "GET", file.DownloadUrl, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
 	if err != nil {
 		return err
 	}
@@ -329,7 +480,25 @@ func (file File) Download(folderPath string) error {
 	defer response.Body.Close()
 
 	if response.StatusCode != 200 {
-		return errors.New("received non 200 response code")
+		return StatusError{StatusCode: response.StatusCode}
+	}
+
+	f, err := os.CreateTemp(folderPath, "."+appFile.TruncateName(file.Name, appFile.MAX_NAME_LENGTH-appFile.MAX_SUFFIX_LENGTH)+".*"+appConstants.DOWNLOAD_TEMP_SUFFIX)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(f, response.Body)
+	closeErr := f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
 	}
 
 	filePath := filepath.Join(folderPath, file.Name)
@@ -337,23 +506,23 @@ func (file File) Download(folderPath string) error {
 	// This checks if there already exists the specified file
 	// to prevent overwritting of files.
 	if appFile.Exists(filePath) {
-		renameErr := appFile.AutoRename(filePath)
+		_, stashErr := versions.Stash(filePath, time.Now())
 
-		if renameErr != nil {
-			return renameErr
+		if stashErr != nil {
+			os.Remove(f.Name())
+			return stashErr
 		}
 	}
 
-	f, err := os.Create(filePath)
-	if err != nil {
+	if err := os.Rename(f.Name(), filePath); err != nil {
+		os.Remove(f.Name())
 		return err
 	}
 
-	defer f.Close()
-
-	_, err = io.Copy(f, response.Body)
-	if err != nil {
-		return err
+	// The local copy is stamped with the time it was modified on Canvas, so that it keeps
+	// its remote time when it is copied or backed up elsewhere.
+	if !file.LastUpdated.IsZero() {
+		return os.Chtimes(filePath, time.Now(), file.LastUpdated)
 	}
 
 	return nil
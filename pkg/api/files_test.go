@@ -0,0 +1,92 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	appFile "github.com/beebeeoii/lominus/internal/file"
+)
+
+// fakeCanvas is a http.RoundTripper that responds to requests with the JSON in Responses,
+// keyed by the path of the request.
+type fakeCanvas struct {
+	Responses map[string]string
+}
+
+func (canvas fakeCanvas) RoundTrip(request *http.Request) (*http.Response, error) {
+	body, exists := canvas.Responses[request.URL.Path]
+	status := http.StatusOK
+	if !exists {
+		body, status = "{}", http.StatusNotFound
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Header:     http.Header{},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    request,
+	}, nil
+}
+
+// useFakeCanvas is a helper function that sends the requests of the test to canvas.
+func useFakeCanvas(t *testing.T, canvas fakeCanvas) {
+	transport := http.DefaultTransport
+	http.DefaultTransport = canvas
+	t.Cleanup(func() { http.DefaultTransport = transport })
+}
+
+func TestGetFilesUpdatedSinceNormalizesTheModuleFolder(t *testing.T) {
+	useFakeCanvas(t, fakeCanvas{Responses: map[string]string{
+		"/api/v1/courses/42/files": `[
+			{"id": 1, "display_name": "Lecture 1.pdf", "folder_id": 7, "modified_at": "2024-03-01T10:00:00Z", "updated_at": "2024-03-01T10:00:00Z"},
+			{"id": 2, "display_name": "Old.pdf", "folder_id": 7, "modified_at": "2024-01-01T10:00:00Z", "updated_at": "2024-01-01T10:00:00Z"}
+		]`,
+		"/api/v1/folders/7": `{"id": 7, "name": "Week: 1", "full_name": "course files/Lectures/Week: 1"}`,
+	}})
+
+	module := Module{Id: "42", ModuleCode: "CS2040/CS2040S", IsAccessible: true}
+	request, _ := BuildCourseFilesRequest("token", module)
+
+	files, err := request.GetFilesUpdatedSince(time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 {
+		t.Fatalf("GetFilesUpdatedSince() = %+v, want the file updated since", files)
+	}
+
+	// The module folder is named as when the module is listed completely, such that the file keeps its key.
+	want := []string{appFile.NormalizeName(module.ModuleCode), "Lectures", appFile.NormalizeName("Week: 1")}
+	if !reflect.DeepEqual(files[0].Ancestors, want) || strings.Contains(files[0].Ancestors[0], "/") {
+		t.Errorf("Ancestors = %q, want %q", files[0].Ancestors, want)
+	}
+}
+
+func TestDownloadLongName(t *testing.T) {
+	useFakeCanvas(t, fakeCanvas{Responses: map[string]string{"/files/1/download": "contents"}})
+
+	folderPath := t.TempDir()
+	file := File{
+		Id:          "1",
+		Name:        strings.Repeat("a", appFile.MAX_NAME_LENGTH-4) + ".pdf",
+		DownloadUrl: "https://canvas.nus.edu.sg/files/1/download",
+	}
+
+	// The second download stashes the first, with the time appended to its name.
+	for i := 0; i < 2; i++ {
+		if err := file.Download(folderPath); err != nil {
+			t.Fatalf("Download() of a name of %d bytes returned error: %v", len(file.Name), err)
+		}
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(folderPath, file.Name)); string(data) != "contents" {
+		t.Errorf("downloaded %q, want the contents", data)
+	}
+}
@@ -0,0 +1,75 @@
+// Package api provides functions that link up and communicate with LMS servers,
+// such as Canvas.
+package api
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DisambiguateFiles renames files that would otherwise share a local path with another file, as Canvas
+// allows files with the same name in a folder and local paths are compared regardless of case.
+// Among the files sharing a path, the one with the lowest Id keeps its name while the others are
+// named with their Id, eg. Notes.pdf and Notes (12345).pdf, such that every file maps to the same
+// local path on every sync. The order of the files is kept.
+func DisambiguateFiles(files []File) []File {
+	groups := map[string][]int{}
+	for i, file := range files {
+		key := getPathKey(file)
+		groups[key] = append(groups[key], i)
+	}
+
+	disambiguated := append([]File{}, files...)
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			return isLowerId(files[group[i]].Id, files[group[j]].Id)
+		})
+
+		for _, i := range group[1:] {
+			disambiguated[i].Name = GetDisambiguatedName(files[i].Name, files[i].Id)
+		}
+	}
+
+	return disambiguated
+}
+
+// GetDisambiguatedName returns the name a file is given when another file shares its local path,
+// eg. Notes (12345).pdf for Notes.pdf.
+func GetDisambiguatedName(name string, id string) string {
+	extension := filepath.Ext(name)
+	return fmt.Sprintf("%s (%s)%s", strings.TrimSuffix(name, extension), id, extension)
+}
+
+// GetOriginalName reverses GetDisambiguatedName. It returns name as it is if it was not disambiguated.
+func GetOriginalName(name string, id string) string {
+	extension := filepath.Ext(name)
+	suffix := fmt.Sprintf(" (%s)", id)
+	base := strings.TrimSuffix(name, extension)
+
+	if !strings.HasSuffix(base, suffix) {
+		return name
+	}
+
+	return strings.TrimSuffix(base, suffix) + extension
+}
+
+// getPathKey is a helper function that returns the local path of the file in lower case,
+// which files must not share.
+func getPathKey(file File) string {
+	return strings.ToLower(strings.Join(append(append([]string{}, file.Ancestors...), file.Name), "/"))
+}
+
+// isLowerId is a helper function that compares Canvas Ids, which are numbers, without parsing them.
+func isLowerId(id string, other string) bool {
+	if len(id) != len(other) {
+		return len(id) < len(other)
+	}
+
+	return id < other
+}
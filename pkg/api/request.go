@@ -47,6 +47,24 @@ type ModuleFolderRequest struct {
 	Module  Module
 }
 
+// CourseFilesRequest struct is the datapack for containing details about a specific
+// HTTP request used for retrieving all the files of a module, most recently updated first,
+// regardless of the folders they are in.
+type CourseFilesRequest struct {
+	Request Request
+	Module  Module
+}
+
+// StatusError is returned when the LMS responds with an unsuccessful HTTP status code,
+// such as 401 when the token has expired.
+type StatusError struct {
+	StatusCode int
+}
+
+func (err StatusError) Error() string {
+	return fmt.Sprintf("received %d %s response code", err.StatusCode, http.StatusText(err.StatusCode))
+}
+
 const USER_AGENT = "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:94.0) Gecko/20100101 Firefox/94.0"
 const METHOD_GET = "GET"
 const CONTENT_TYPE_FORM = "application/x-www-form-urlencoded"
@@ -121,7 +139,7 @@ func BuildFoldersRequest(token string, platform constants.Platform, builder inte
 
 			builder = Folder{
 				Id:           rootFolderId,
-				Name:         appFile.CleanseFolderFileName(b.ModuleCode),
+				Name:         appFile.NormalizeName(b.ModuleCode),
 				Downloadable: b.IsAccessible,
 				HasSubFolder: true,
 				Ancestors:    []string{},
@@ -199,8 +217,30 @@ func BuildModuleFolderRequest(token string, module Module) (ModuleFolderRequest,
 	}, nil
 }
 
+// BuildCourseFilesRequest builds and returns a CourseFilesRequest that can be used to retrieve
+// the files of a module that were updated recently.
+func BuildCourseFilesRequest(token string, module Module) (CourseFilesRequest, error) {
+	url := fmt.Sprintf(constants.CANVAS_COURSE_FILES_ENDPOINT, module.Id)
+
+	return CourseFilesRequest{
+		Request: Request{
+			Method: METHOD_GET,
+			Token:  token,
+			Url: interfaces.Url{
+				Url:      url,
+				Platform: constants.Canvas,
+			},
+			UserAgent: USER_AGENT,
+		},
+		Module: module,
+	}, nil
+}
+
 // Send takes a Request that encapsulates a HTTP request and sends it. The response body is then
 // unmarshalled into the interface{} argument provided.
+// A StatusError is returned if the response has an unsuccessful status code.
+// If a ResponseCache is set via SetCache, GET requests are sent conditionally with the validators
+// of the cached response, which is reused if the LMS responds that it has not been modified.
 // Note that the argument parsed must be a pointer.
 func (req Request) Send(res interface{}) error {
 	request, err := http.NewRequest(req.Method, req.Url.Url, nil)
@@ -210,6 +250,26 @@ func (req Request) Send(res interface{}) error {
 
 	request.Header.Add("Authorization", "Bearer "+req.Token)
 
+	cache := getCache()
+	if req.Method != METHOD_GET {
+		cache = nil
+	}
+
+	var cacheKey string
+	var cached CachedResponse
+	var isCached bool
+	if cache != nil {
+		cacheKey = getCacheKey(req)
+		cached, isCached = cache.Get(cacheKey)
+	}
+
+	if isCached && cached.ETag != "" {
+		request.Header.Add("If-None-Match", cached.ETag)
+	}
+	if isCached && cached.LastModified != "" {
+		request.Header.Add("If-Modified-Since", cached.LastModified)
+	}
+
 	client := &http.Client{}
 
 	response, err := client.Do(request)
@@ -217,11 +277,28 @@ func (req Request) Send(res interface{}) error {
 		return err
 	}
 
+	defer response.Body.Close()
+
+	if response.StatusCode == http.StatusNotModified && isCached {
+		return json.Unmarshal(cached.Body, res)
+	}
+
+	if response.StatusCode >= 400 {
+		return StatusError{StatusCode: response.StatusCode}
+	}
+
 	body, err := io.ReadAll(response.Body)
 	if err != nil {
 		return err
 	}
 
+	eTag := response.Header.Get("ETag")
+	lastModified := response.Header.Get("Last-Modified")
+	if cache != nil && (eTag != "" || lastModified != "") {
+		// Failing to cache the response only means that it is transferred again next time.
+		cache.Put(cacheKey, CachedResponse{ETag: eTag, LastModified: lastModified, Body: body})
+	}
+
 	err = json.Unmarshal(body, res)
 	if err != nil {
 		return err
@@ -3,6 +3,8 @@ package auth
 
 import (
 	file "github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/secrets"
+	"github.com/beebeeoii/lominus/pkg/store"
 )
 
 // CredentialsData struct is the datapack that contains all the credentials for
@@ -14,6 +16,7 @@ type CredentialsData struct {
 }
 
 // saveCredentialsData saves the user's credentials data to local storage for future use.
+// Tokens are encrypted with the key in the key file at GetKeyPath, which is created if it does not exist.
 func saveCredentialsData(credentialsPath string, credentailsData CredentialsData) error {
 	if file.Exists(credentialsPath) {
 		localCredentialsData, err := LoadCredentialsData(credentialsPath)
@@ -24,18 +27,74 @@ func saveCredentialsData(credentialsPath string, credentailsData CredentialsData
 		credentailsData.Merge(localCredentialsData)
 	}
 
+	cipher, cipherErr := secrets.NewKeyFileCipher(GetKeyPath(credentialsPath), true)
+	if cipherErr != nil {
+		return cipherErr
+	}
+
+	canvasToken, encryptErr := cipher.Encrypt(credentailsData.CanvasCredentials.CanvasApiToken)
+	if encryptErr != nil {
+		return encryptErr
+	}
+	credentailsData.CanvasCredentials.CanvasApiToken = canvasToken
+
 	return file.EncodeStructToFile(credentialsPath, credentailsData)
 }
 
 // LoadCredentialsData loads the user's Credentials data from local storage.
+// Tokens saved before they were encrypted are read as they are. Encrypted tokens are decrypted with the key
+// in the key file at GetKeyPath, which is never created when loading.
+//
+// Deprecated: Use LoadCanvasCredentials, which loads the credentials from a Store.
 func LoadCredentialsData(credentialsPath string) (CredentialsData, error) {
 	credentialsData := CredentialsData{}
 	if !file.Exists(credentialsPath) {
 		return credentialsData, &file.FileNotFoundError{FileName: credentialsPath}
 	}
 	err := file.DecodeStructFromFile(credentialsPath, &credentialsData)
+	if err != nil || !secrets.IsEncrypted(credentialsData.CanvasCredentials.CanvasApiToken) {
+		return credentialsData, err
+	}
+
+	cipher, cipherErr := secrets.NewKeyFileCipher(GetKeyPath(credentialsPath), false)
+	if cipherErr != nil {
+		return CredentialsData{}, cipherErr
+	}
+
+	canvasToken, decryptErr := cipher.Decrypt(credentialsData.CanvasCredentials.CanvasApiToken)
+	if decryptErr != nil {
+		return CredentialsData{}, decryptErr
+	}
+	credentialsData.CanvasCredentials.CanvasApiToken = canvasToken
+
+	return credentialsData, nil
+}
+
+// ImportCredentialsData imports the credentials saved with Save at credentialsPath into the Store,
+// unless the Store already has credentials, such that callers moving from Save to SaveTo keep them.
+// It returns whether the credentials were imported. The CanvasApiToken is saved in the Store as it is
+// once decrypted, like SaveTo. The file and its key file are left as they are.
+// FileNotFoundError is returned if the file does not exist.
+func ImportCredentialsData(credentialsPath string, s store.Store) (bool, error) {
+	credentialsData, loadErr := LoadCredentialsData(credentialsPath)
+	if loadErr != nil {
+		return false, loadErr
+	}
 
-	return credentialsData, err
+	if credentialsData.CanvasCredentials.CanvasApiToken == "" {
+		return false, nil
+	}
+
+	credentials, getErr := LoadCanvasCredentials(s)
+	if getErr != nil {
+		return false, getErr
+	}
+
+	if credentials.CanvasApiToken != "" {
+		return false, nil
+	}
+
+	return true, credentialsData.CanvasCredentials.SaveTo(s)
 }
 
 // Merge takes n individual Credentials data encapsulated in CredentialsData and merge/combine them
@@ -45,3 +104,9 @@ func (t *CredentialsData) Merge(t2 CredentialsData) {
 		t.CanvasCredentials = t2.CanvasCredentials
 	}
 }
+
+// GetKeyPath returns the path of the key file the tokens in the credentials file are encrypted with,
+// which must be kept next to it. See the internal secrets package for what the key file protects against.
+func GetKeyPath(credentialsPath string) string {
+	return secrets.GetKeyPath(credentialsPath)
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/secrets"
+	"github.com/beebeeoii/lominus/pkg/store"
+)
+
+func TestSaveToAndLoadCanvasCredentials(t *testing.T) {
+	s := store.NewMemoryStore()
+
+	credentials, loadErr := LoadCanvasCredentials(s)
+	if loadErr != nil || credentials != (CanvasCredentials{}) {
+		t.Errorf("LoadCanvasCredentials() = %+v, %v, want empty credentials", credentials, loadErr)
+	}
+
+	if err := (CanvasCredentials{CanvasApiToken: "token"}).SaveTo(s); err != nil {
+		t.Fatal(err)
+	}
+
+	credentials, loadErr = LoadCanvasCredentials(s)
+	if loadErr != nil || credentials.CanvasApiToken != "token" {
+		t.Errorf("LoadCanvasCredentials() = %+v, %v, want the saved credentials", credentials, loadErr)
+	}
+}
+
+func TestSaveEncryptsWithKeyFile(t *testing.T) {
+	credentialsPath := filepath.Join(t.TempDir(), "credentials.gob")
+
+	if err := (CanvasCredentials{CanvasApiToken: "token"}).Save(credentialsPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if !file.Exists(GetKeyPath(credentialsPath)) {
+		t.Fatalf("Save() did not create the key file at %s", GetKeyPath(credentialsPath))
+	}
+
+	saved := CredentialsData{}
+	if err := file.DecodeStructFromFile(credentialsPath, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if !secrets.IsEncrypted(saved.CanvasCredentials.CanvasApiToken) {
+		t.Errorf("Save() saved the token as %q, want it encrypted", saved.CanvasCredentials.CanvasApiToken)
+	}
+
+	credentialsData, loadErr := LoadCredentialsData(credentialsPath)
+	if loadErr != nil || credentialsData.CanvasCredentials.CanvasApiToken != "token" {
+		t.Errorf("LoadCredentialsData() = %+v, %v, want the saved credentials", credentialsData, loadErr)
+	}
+
+	// Loading never creates a key file, which could not decrypt the token anyway.
+	if err := os.Remove(GetKeyPath(credentialsPath)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadCredentialsData(credentialsPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadCredentialsData() without the key file = %v, want an error wrapping os.ErrNotExist", err)
+	}
+	if file.Exists(GetKeyPath(credentialsPath)) {
+		t.Errorf("LoadCredentialsData() created the key file")
+	}
+}
+
+func TestImportCredentialsData(t *testing.T) {
+	credentialsPath := filepath.Join(t.TempDir(), "credentials.gob")
+	s := store.NewMemoryStore()
+
+	var fileNotFoundErr *file.FileNotFoundError
+	if _, err := ImportCredentialsData(credentialsPath, s); !errors.As(err, &fileNotFoundErr) {
+		t.Errorf("ImportCredentialsData() of a missing file = %v, want FileNotFoundError", err)
+	}
+
+	if err := (CanvasCredentials{CanvasApiToken: "token"}).Save(credentialsPath); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, importErr := ImportCredentialsData(credentialsPath, s)
+	if importErr != nil || !imported {
+		t.Fatalf("ImportCredentialsData() = %t, %v, want true, nil", imported, importErr)
+	}
+
+	credentials, _ := LoadCanvasCredentials(s)
+	if credentials.CanvasApiToken != "token" {
+		t.Errorf("LoadCanvasCredentials() = %+v after importing, want the decrypted token", credentials)
+	}
+
+	// Credentials already in the Store are kept.
+	if err := (CanvasCredentials{CanvasApiToken: "newer"}).SaveTo(s); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, importErr = ImportCredentialsData(credentialsPath, s)
+	if importErr != nil || imported {
+		t.Errorf("ImportCredentialsData() = %t, %v with credentials in the Store, want false, nil", imported, importErr)
+	}
+
+	if credentials, _ := LoadCanvasCredentials(s); credentials.CanvasApiToken != "newer" {
+		t.Errorf("LoadCanvasCredentials() = %+v, want the credentials in the Store kept", credentials)
+	}
+}
@@ -7,15 +7,48 @@ import (
 	"net/http"
 
 	"github.com/beebeeoii/lominus/pkg/constants"
+	"github.com/beebeeoii/lominus/pkg/store"
 )
 
+// CANVAS_CREDENTIALS_BUCKET is the Store bucket CanvasCredentials are saved in.
+const CANVAS_CREDENTIALS_BUCKET = "Auth"
+
+// CANVAS_TOKEN_KEY is the key the CanvasApiToken is saved with.
+const CANVAS_TOKEN_KEY = "canvasToken"
+
 // CanvasCredentials is a struct that encapsulates the credentials required for authentication.
 // In this case, it is the CanvasApiToken which is a string.
 type CanvasCredentials struct {
 	CanvasApiToken string
 }
 
+// LoadCanvasCredentials loads the CanvasCredentials saved in the Store with SaveTo.
+// CanvasApiToken is empty ("") if no credentials were saved.
+func LoadCanvasCredentials(s store.Store) (CanvasCredentials, error) {
+	canvasToken, getErr := s.Get(CANVAS_CREDENTIALS_BUCKET, CANVAS_TOKEN_KEY)
+	if getErr != nil {
+		return CanvasCredentials{}, getErr
+	}
+
+	return CanvasCredentials{CanvasApiToken: string(canvasToken)}, nil
+}
+
+// SaveTo takes in the CanvasCredentials and saves it in the Store provided as argument.
+// The CanvasApiToken is saved as it is, so callers that need it encrypted should encrypt it beforehand.
+func (credentials CanvasCredentials) SaveTo(s store.Store) error {
+	return s.Put(CANVAS_CREDENTIALS_BUCKET, CANVAS_TOKEN_KEY, []byte(credentials.CanvasApiToken))
+}
+
 // Save takes in the CanvasCredentials and saves it locally with the path provided as arguments.
+// The CanvasApiToken is encrypted with the key in the key file at GetKeyPath(credentialsPath),
+// which is created if it does not exist.
+//
+// Note that this changes the format of the file: it used to hold the CanvasApiToken in plaintext, and now
+// holds it encrypted. Programs that read the file without LoadCredentialsData must decrypt the token with
+// the key file, and copies of the file cannot be read without a copy of the key file.
+//
+// Deprecated: Use SaveTo, which saves the CanvasCredentials in a Store, and ImportCredentialsData
+// to move the credentials saved with Save into it.
 func (credentials CanvasCredentials) Save(credentialsPath string) error {
 	return saveCredentialsData(credentialsPath, CredentialsData{
 		CanvasCredentials: credentials,
@@ -10,6 +10,8 @@ const (
 	CANVAS_FOLDERS_ENDPOINT        = "https://canvas.nus.edu.sg/api/v1/folders/%s/folders"
 	CANVAS_FILES_ENDPOINT          = "https://canvas.nus.edu.sg/api/v1/folders/%s/files"
 	CANVAS_FILE_ENDPOINT           = "https://canvas.nus.edu.sg/api/v1/files/%s"
+	CANVAS_FOLDER_ENDPOINT         = "https://canvas.nus.edu.sg/api/v1/folders/%s"
+	CANVAS_COURSE_FILES_ENDPOINT   = "https://canvas.nus.edu.sg/api/v1/courses/%s/files?sort=updated_at&order=desc"
 )
 
 // Telegram Endpoints
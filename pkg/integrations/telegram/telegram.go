@@ -9,8 +9,10 @@ import (
 	"strings"
 
 	"github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/secrets"
 	"github.com/beebeeoii/lominus/pkg/api"
 	"github.com/beebeeoii/lominus/pkg/constants"
+	"github.com/beebeeoii/lominus/pkg/store"
 )
 
 // TelegramInfo struct is the datapack that holds the data required for Telegram integration.
@@ -27,6 +29,13 @@ type TelegramError struct {
 const CONTENT_TYPE = "application/x-www-form-urlencoded"
 const METHOD_POST = "POST"
 
+// TELEGRAM_BUCKET is the Store bucket TelegramInfo is saved in.
+const TELEGRAM_BUCKET = "Integrations"
+
+// TELEGRAM_USER_ID_KEY and TELEGRAM_BOT_ID_KEY are the keys the UserId and BotApi are saved with.
+const TELEGRAM_USER_ID_KEY = "telegramUserId"
+const TELEGRAM_BOT_ID_KEY = "telegramBotId"
+
 // SendMessage is a wrapper function that sends a message to the user using the Bot (specified by the botApi) created by the user.
 func SendMessage(botApi string, userId string, message string) error {
 	client := &http.Client{
@@ -69,26 +78,123 @@ func GenerateFileUpdatedMessageFormat(file api.File) string {
 	updatedFileMessage := fmt.Sprintf("<b><u>Files</u></b>\n<b>%s</b>: <i>%s</i>\n\nUpdated: %s",
 		file.Ancestors[0],
 		file.Name,
-		file.LastUpdated.Format("Monday, 02 January 2006 - 15:04:05"),
+		file.LastUpdated.Local().Format("Monday, 02 January 2006 - 15:04:05"),
 	)
 
 	return updatedFileMessage
 }
 
+// LoadTelegramInfo loads the user's Telegram data saved in the Store with SaveTelegramInfo.
+// Fields that were not saved are empty ("").
+func LoadTelegramInfo(s store.Store) (TelegramInfo, error) {
+	userId, userIdErr := s.Get(TELEGRAM_BUCKET, TELEGRAM_USER_ID_KEY)
+	if userIdErr != nil {
+		return TelegramInfo{}, userIdErr
+	}
+
+	botApi, botApiErr := s.Get(TELEGRAM_BUCKET, TELEGRAM_BOT_ID_KEY)
+	if botApiErr != nil {
+		return TelegramInfo{}, botApiErr
+	}
+
+	return TelegramInfo{BotApi: string(botApi), UserId: string(userId)}, nil
+}
+
+// SaveTelegramInfo saves the user's Telegram data in the Store provided as argument.
+// The BotApi is saved as it is, so callers that need it encrypted should encrypt it beforehand.
+func SaveTelegramInfo(s store.Store, telegramInfo TelegramInfo) error {
+	userIdErr := s.Put(TELEGRAM_BUCKET, TELEGRAM_USER_ID_KEY, []byte(telegramInfo.UserId))
+	if userIdErr != nil {
+		return userIdErr
+	}
+
+	return s.Put(TELEGRAM_BUCKET, TELEGRAM_BOT_ID_KEY, []byte(telegramInfo.BotApi))
+}
+
 // SaveTelegramData saves the user's Telegram data onto local storage.
+// The BotApi is encrypted with the key in the key file at GetKeyPath, which is created if it does not exist.
+//
+// Note that this changes the format of the file: it used to hold the BotApi in plaintext, and now holds it
+// encrypted. Programs that read the file without LoadTelegramData must decrypt the BotApi with the key file,
+// and copies of the file cannot be read without a copy of the key file.
+//
+// Deprecated: Use SaveTelegramInfo, which saves the Telegram data in a Store, and ImportTelegramData
+// to move the Telegram data saved with SaveTelegramData into it.
 func SaveTelegramData(telegramDataPath string, telegramInfo TelegramInfo) error {
+	cipher, cipherErr := secrets.NewKeyFileCipher(GetKeyPath(telegramDataPath), true)
+	if cipherErr != nil {
+		return cipherErr
+	}
+
+	botApi, encryptErr := cipher.Encrypt(telegramInfo.BotApi)
+	if encryptErr != nil {
+		return encryptErr
+	}
+	telegramInfo.BotApi = botApi
+
 	return file.EncodeStructToFile(telegramDataPath, telegramInfo)
 }
 
 // LoadTelegramData loads the user's Telegram data from local storage.
+// A BotApi saved before it was encrypted is read as it is. An encrypted BotApi is decrypted with the key
+// in the key file at GetKeyPath, which is never created when loading.
+//
+// Deprecated: Use LoadTelegramInfo, which loads the Telegram data from a Store.
 func LoadTelegramData(telegramDataPath string) (TelegramInfo, error) {
 	telegramInfo := TelegramInfo{}
 	if !file.Exists(telegramDataPath) {
 		return telegramInfo, &file.FileNotFoundError{FileName: telegramDataPath}
 	}
 	err := file.DecodeStructFromFile(telegramDataPath, &telegramInfo)
+	if err != nil || !secrets.IsEncrypted(telegramInfo.BotApi) {
+		return telegramInfo, err
+	}
+
+	cipher, cipherErr := secrets.NewKeyFileCipher(GetKeyPath(telegramDataPath), false)
+	if cipherErr != nil {
+		return TelegramInfo{}, cipherErr
+	}
+
+	botApi, decryptErr := cipher.Decrypt(telegramInfo.BotApi)
+	if decryptErr != nil {
+		return TelegramInfo{}, decryptErr
+	}
+	telegramInfo.BotApi = botApi
+
+	return telegramInfo, nil
+}
+
+// ImportTelegramData imports the Telegram data saved with SaveTelegramData at telegramDataPath into the Store,
+// unless the Store already has a BotApi, such that callers moving from SaveTelegramData to SaveTelegramInfo keep it.
+// It returns whether the Telegram data was imported. The BotApi is saved in the Store as it is once decrypted,
+// like SaveTelegramInfo. The file and its key file are left as they are.
+// FileNotFoundError is returned if the file does not exist.
+func ImportTelegramData(telegramDataPath string, s store.Store) (bool, error) {
+	telegramData, loadErr := LoadTelegramData(telegramDataPath)
+	if loadErr != nil {
+		return false, loadErr
+	}
+
+	if telegramData.BotApi == "" {
+		return false, nil
+	}
+
+	telegramInfo, getErr := LoadTelegramInfo(s)
+	if getErr != nil {
+		return false, getErr
+	}
+
+	if telegramInfo.BotApi != "" {
+		return false, nil
+	}
+
+	return true, SaveTelegramInfo(s, telegramData)
+}
 
-	return telegramInfo, err
+// GetKeyPath returns the path of the key file the BotApi in the Telegram data file is encrypted with,
+// which must be kept next to it. See the internal secrets package for what the key file protects against.
+func GetKeyPath(telegramDataPath string) string {
+	return secrets.GetKeyPath(telegramDataPath)
 }
 
 // TelegramError error will be thrown when an error is returned by Telegram servers.
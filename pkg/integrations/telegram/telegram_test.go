@@ -0,0 +1,101 @@
+package telegram
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beebeeoii/lominus/internal/file"
+	"github.com/beebeeoii/lominus/internal/secrets"
+	"github.com/beebeeoii/lominus/pkg/store"
+)
+
+func TestSaveAndLoadTelegramInfo(t *testing.T) {
+	s := store.NewMemoryStore()
+
+	telegramInfo, loadErr := LoadTelegramInfo(s)
+	if loadErr != nil || telegramInfo != (TelegramInfo{}) {
+		t.Errorf("LoadTelegramInfo() = %+v, %v, want empty Telegram data", telegramInfo, loadErr)
+	}
+
+	saved := TelegramInfo{BotApi: "bot", UserId: "user"}
+	if err := SaveTelegramInfo(s, saved); err != nil {
+		t.Fatal(err)
+	}
+
+	telegramInfo, loadErr = LoadTelegramInfo(s)
+	if loadErr != nil || telegramInfo != saved {
+		t.Errorf("LoadTelegramInfo() = %+v, %v, want %+v", telegramInfo, loadErr, saved)
+	}
+}
+
+func TestSaveTelegramDataEncryptsWithKeyFile(t *testing.T) {
+	telegramDataPath := filepath.Join(t.TempDir(), "telegram.gob")
+
+	if err := SaveTelegramData(telegramDataPath, TelegramInfo{BotApi: "bot", UserId: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	saved := TelegramInfo{}
+	if err := file.DecodeStructFromFile(telegramDataPath, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if !secrets.IsEncrypted(saved.BotApi) || saved.UserId != "user" {
+		t.Errorf("SaveTelegramData() saved %+v, want only the BotApi encrypted", saved)
+	}
+
+	telegramInfo, loadErr := LoadTelegramData(telegramDataPath)
+	if loadErr != nil || telegramInfo.BotApi != "bot" {
+		t.Errorf("LoadTelegramData() = %+v, %v, want the saved Telegram data", telegramInfo, loadErr)
+	}
+
+	// Loading never creates a key file, which could not decrypt the BotApi anyway.
+	if err := os.Remove(GetKeyPath(telegramDataPath)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadTelegramData(telegramDataPath); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("LoadTelegramData() without the key file = %v, want an error wrapping os.ErrNotExist", err)
+	}
+	if file.Exists(GetKeyPath(telegramDataPath)) {
+		t.Errorf("LoadTelegramData() created the key file")
+	}
+}
+
+func TestImportTelegramData(t *testing.T) {
+	telegramDataPath := filepath.Join(t.TempDir(), "telegram.gob")
+	s := store.NewMemoryStore()
+
+	var fileNotFoundErr *file.FileNotFoundError
+	if _, err := ImportTelegramData(telegramDataPath, s); !errors.As(err, &fileNotFoundErr) {
+		t.Errorf("ImportTelegramData() of a missing file = %v, want FileNotFoundError", err)
+	}
+
+	if err := SaveTelegramData(telegramDataPath, TelegramInfo{BotApi: "bot", UserId: "user"}); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, importErr := ImportTelegramData(telegramDataPath, s)
+	if importErr != nil || !imported {
+		t.Fatalf("ImportTelegramData() = %t, %v, want true, nil", imported, importErr)
+	}
+
+	if telegramInfo, _ := LoadTelegramInfo(s); telegramInfo != (TelegramInfo{BotApi: "bot", UserId: "user"}) {
+		t.Errorf("LoadTelegramInfo() = %+v after importing, want the decrypted Telegram data", telegramInfo)
+	}
+
+	// Telegram data already in the Store is kept.
+	newer := TelegramInfo{BotApi: "newer", UserId: "other"}
+	if err := SaveTelegramInfo(s, newer); err != nil {
+		t.Fatal(err)
+	}
+
+	imported, importErr = ImportTelegramData(telegramDataPath, s)
+	if importErr != nil || imported {
+		t.Errorf("ImportTelegramData() = %t, %v with Telegram data in the Store, want false, nil", imported, importErr)
+	}
+
+	if telegramInfo, _ := LoadTelegramInfo(s); telegramInfo != newer {
+		t.Errorf("LoadTelegramInfo() = %+v, want the Telegram data in the Store kept", telegramInfo)
+	}
+}
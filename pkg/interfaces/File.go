@@ -20,4 +20,8 @@ type CanvasFileObject struct {
 	Url           string `json:"url"`
 	HiddenForUser bool   `json:"hidden_for_user"`
 	LastUpdated   string `json:"modified_at"`
+	// UpdatedAt also changes when the File is renamed or moved, unlike LastUpdated.
+	UpdatedAt string `json:"updated_at"`
+	FolderId  int    `json:"folder_id"`
+	Size      int64  `json:"size"`
 }
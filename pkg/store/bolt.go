@@ -0,0 +1,90 @@
+package store
+
+import (
+	"github.com/boltdb/bolt"
+)
+
+// BoltStore is a Store that stores every bucket as a bucket of the bolt database DB.
+type BoltStore struct {
+	DB *bolt.DB
+}
+
+// TxStore is a Store that reads and writes within the bolt transaction Tx, such as in a migration.
+// Writes are only possible if Tx is writable.
+type TxStore struct {
+	Tx *bolt.Tx
+}
+
+func (store BoltStore) Get(bucket string, key string) ([]byte, error) {
+	var value []byte
+
+	err := store.DB.View(func(tx *bolt.Tx) error {
+		var getErr error
+		value, getErr = TxStore{Tx: tx}.Get(bucket, key)
+		return getErr
+	})
+
+	return value, err
+}
+
+func (store BoltStore) Put(bucket string, key string, value []byte) error {
+	return store.DB.Update(func(tx *bolt.Tx) error {
+		return TxStore{Tx: tx}.Put(bucket, key, value)
+	})
+}
+
+func (store BoltStore) Delete(bucket string, key string) error {
+	return store.DB.Update(func(tx *bolt.Tx) error {
+		return TxStore{Tx: tx}.Delete(bucket, key)
+	})
+}
+
+func (store BoltStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	return store.DB.View(func(tx *bolt.Tx) error {
+		return TxStore{Tx: tx}.ForEach(bucket, fn)
+	})
+}
+
+func (store TxStore) Get(bucket string, key string) ([]byte, error) {
+	b := store.Tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil, nil
+	}
+
+	value := b.Get([]byte(key))
+	if value == nil {
+		return nil, nil
+	}
+
+	// Values are only valid for the life of the transaction.
+	return append([]byte{}, value...), nil
+}
+
+func (store TxStore) Put(bucket string, key string, value []byte) error {
+	b, bucketErr := store.Tx.CreateBucketIfNotExists([]byte(bucket))
+	if bucketErr != nil {
+		return bucketErr
+	}
+
+	return b.Put([]byte(key), value)
+}
+
+func (store TxStore) Delete(bucket string, key string) error {
+	b := store.Tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return b.Delete([]byte(key))
+}
+
+func (store TxStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	b := store.Tx.Bucket([]byte(bucket))
+	if b == nil {
+		return nil
+	}
+
+	return b.ForEach(func(key, value []byte) error {
+		return fn(string(key), append([]byte{}, value...))
+	})
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore is a Store that keeps every value in memory, such as for tests.
+type MemoryStore struct {
+	mutex   sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]map[string][]byte{}}
+}
+
+func (store *MemoryStore) Get(bucket string, key string) ([]byte, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	value, exists := store.buckets[bucket][key]
+	if !exists {
+		return nil, nil
+	}
+
+	return append([]byte{}, value...), nil
+}
+
+func (store *MemoryStore) Put(bucket string, key string, value []byte) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.buckets[bucket] == nil {
+		store.buckets[bucket] = map[string][]byte{}
+	}
+	store.buckets[bucket][key] = append([]byte{}, value...)
+
+	return nil
+}
+
+func (store *MemoryStore) Delete(bucket string, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.buckets[bucket], key)
+
+	return nil
+}
+
+func (store *MemoryStore) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	keys := []string{}
+	for key := range store.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := fn(key, append([]byte{}, store.buckets[bucket][key]...)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+// Package store provides the storage the public API and Lominus keep their data in,
+// such as credentials, integrations, preferences and the history of syncs.
+package store
+
+// Store stores values by bucket and key. Buckets are created when a value is first put in them.
+type Store interface {
+	// Get returns the value of the key in the bucket, or nil if there is none.
+	Get(bucket string, key string) ([]byte, error)
+	// Put sets the value of the key in the bucket.
+	Put(bucket string, key string, value []byte) error
+	// Delete removes the key from the bucket. Keys that do not exist are ignored.
+	Delete(bucket string, key string) error
+	// ForEach calls fn with every key in the bucket and its value, in the order of the keys,
+	// and stops at the first error fn returns. fn must not modify the Store.
+	ForEach(bucket string, fn func(key string, value []byte) error) error
+}
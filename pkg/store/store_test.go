@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/boltdb/bolt"
+)
+
+func TestStores(t *testing.T) {
+	stores := map[string]func(t *testing.T) Store{
+		"MemoryStore": func(t *testing.T) Store {
+			return NewMemoryStore()
+		},
+		"BoltStore": func(t *testing.T) Store {
+			db, openErr := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+			if openErr != nil {
+				t.Fatal(openErr)
+			}
+			t.Cleanup(func() { db.Close() })
+
+			return BoltStore{DB: db}
+		},
+	}
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			testStore(t, newStore(t))
+		})
+	}
+}
+
+// testStore is a helper function that checks that s behaves as described by Store.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+
+	if value, err := s.Get("Missing", "key"); value != nil || err != nil {
+		t.Errorf("Get() of a missing bucket = %q, %v, want nil, nil", value, err)
+	}
+	if err := s.Delete("Missing", "key"); err != nil {
+		t.Errorf("Delete() of a missing bucket = %v, want nil", err)
+	}
+	if err := s.ForEach("Missing", func(string, []byte) error { return errors.New("called") }); err != nil {
+		t.Errorf("ForEach() of a missing bucket = %v, want nil", err)
+	}
+
+	value := []byte("1")
+	for _, key := range []string{"b", "c", "a"} {
+		if err := s.Put("Bucket", key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Values are copied, such that changing them does not change the Store.
+	value[0] = 'x'
+	got, getErr := s.Get("Bucket", "a")
+	if getErr != nil || string(got) != "1" {
+		t.Errorf("Get() = %q, %v, want \"1\", nil", got, getErr)
+	}
+	got[0] = 'y'
+	if again, _ := s.Get("Bucket", "a"); string(again) != "1" {
+		t.Errorf("Get() = %q after changing the value returned, want \"1\"", again)
+	}
+
+	if err := s.Delete("Bucket", "c"); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := s.Get("Bucket", "c"); value != nil {
+		t.Errorf("Get() = %q after Delete(), want nil", value)
+	}
+
+	keys := []string{}
+	forEachErr := s.ForEach("Bucket", func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	})
+	if forEachErr != nil || !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("ForEach() visited %v (%v), want [a b]", keys, forEachErr)
+	}
+
+	stopErr := errors.New("stop")
+	calls := 0
+	forEachErr = s.ForEach("Bucket", func(string, []byte) error {
+		calls++
+		return stopErr
+	})
+	if forEachErr != stopErr || calls != 1 {
+		t.Errorf("ForEach() = %v after %d calls, want it to stop at the first error", forEachErr, calls)
+	}
+}